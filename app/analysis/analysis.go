@@ -0,0 +1,195 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/framework/env"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+const reportsDir = "reports"
+
+// Simulate runs the controller through the whole beatmap without rendering or playing audio.
+// Beatmap and its objects have to be parsed beforehand and settings.HEADLESS has to be set.
+func Simulate(controller *dance.ReplayController) {
+	simulateWith(controller, nil)
+}
+
+// simulateWith runs Simulate and calls step after every simulated millisecond, if it's not nil
+func simulateWith(controller *dance.ReplayController, step func(time float64)) {
+	bMap := controller.GetBeatMap()
+
+	if len(bMap.HitObjects) == 0 {
+		return
+	}
+
+	startTime, endTime := simulationRange(bMap)
+
+	for time := startTime; time <= endTime; time++ {
+		controller.Update(time, 1)
+
+		if step != nil {
+			step(time)
+		}
+	}
+}
+
+// simulationRange returns times between which all objects of the beatmap can be judged
+func simulationRange(bMap *beatmap.BeatMap) (startTime, endTime float64) {
+	startTime = math.Floor(min(bMap.HitObjects[0].GetStartTime(), 0) - bMap.Diff.Preempt - 1000)
+	endTime = bMap.HitObjects[len(bMap.HitObjects)-1].GetEndTime() + float64(bMap.Diff.Hit50) + 100
+
+	return
+}
+
+// Analyze loads settings.REPLAY, simulates it and collects all judgements it got
+func Analyze(beatMap *beatmap.BeatMap) (*Report, error) {
+	if len(beatMap.HitObjects) == 0 {
+		return nil, errors.New("beatmap doesn't have any hit objects")
+	}
+
+	controller, err := loadReplay(beatMap)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleset := controller.GetRuleset()
+	cursor := controller.GetCursors()[0]
+	diff := ruleset.GetPlayerDifficulty(cursor)
+
+	report := &Report{
+		Beatmap:    newBeatmapInfo(beatMap),
+		Player:     cursor.Name,
+		Mods:       diff.GetModString(),
+		Judgements: make([]Judgement, 0),
+		HitDeltas:  make([]HitDelta, 0),
+	}
+
+	ruleset.SetListener(func(_ *graphics.Cursor, result osu.JudgementResult, _ osu.Score) {
+		report.addJudgement(beatMap, diff, result)
+	})
+
+	ruleset.SetFailListener(func(_ *graphics.Cursor) {
+		report.Failed = true
+	})
+
+	log.Println("Simulating replay...")
+
+	Simulate(controller)
+
+	report.Stars = ruleset.GetFinalDiffAttribs(cursor).Total
+	report.Score = newScoreInfo(ruleset.GetScore(cursor))
+	report.FCPP = newPPInfo(ruleset.GetFCPP(cursor))
+	report.SSPP = newPPInfo(ruleset.GetSSPP(cursor))
+	report.UnstableRate = unstableRate(report.HitDeltas)
+
+	return report, nil
+}
+
+// loadReplay creates ReplayController with settings.REPLAY loaded and cursors initialized
+func loadReplay(beatMap *beatmap.BeatMap) (*dance.ReplayController, error) {
+	controller := dance.NewReplayController().(*dance.ReplayController)
+	controller.SetBeatMap(beatMap)
+
+	if len(controller.GetReplays()) != 1 {
+		return nil, errors.New("analysis needs exactly one replay")
+	}
+
+	controller.InitCursors()
+
+	return controller, nil
+}
+
+func (report *Report) addJudgement(beatMap *beatmap.BeatMap, diff *difficulty.Difficulty, result osu.JudgementResult) {
+	object := beatMap.HitObjects[result.Number]
+
+	report.Judgements = append(report.Judgements, Judgement{
+		Time:   result.Time,
+		Offset: result.Time - int64(object.GetStartTime()),
+		Object: result.Number,
+		Result: result.HitResult.String(),
+		Combo:  result.ComboResult.String(),
+		X:      result.Position.X,
+		Y:      result.Position.Y,
+	})
+
+	if delta, ok := hitDelta(beatMap, diff, result); ok {
+		report.HitDeltas = append(report.HitDeltas, delta)
+	}
+}
+
+// hitDelta returns the hit offset of the judgement. Only hits counted by hit error meter in ScoreOverlay have one.
+func hitDelta(beatMap *beatmap.BeatMap, diff *difficulty.Difficulty, result osu.JudgementResult) (HitDelta, bool) {
+	object := beatMap.HitObjects[result.Number]
+
+	_, isCircle := object.(*objects.Circle)
+	_, isSlider := object.(*objects.Slider)
+
+	if (isCircle && result.HitResult&osu.BaseHits > 0) || (isSlider && result.HitResult&osu.SliderAccuracyResults(diff) > 0) {
+		return HitDelta{
+			Object: result.Number,
+			Time:   result.Time,
+			Delta:  float64(result.Time) - object.GetStartTime(),
+			Result: result.HitResult.String(),
+		}, true
+	}
+
+	return HitDelta{}, false
+}
+
+func unstableRate(deltas []HitDelta) float64 {
+	if len(deltas) == 0 {
+		return 0
+	}
+
+	average := 0.0
+	for _, d := range deltas {
+		average += d.Delta
+	}
+
+	average /= float64(len(deltas))
+
+	urBase := 0.0
+	for _, d := range deltas {
+		urBase += math.Pow(d.Delta-average, 2)
+	}
+
+	return math.Sqrt(urBase/float64(len(deltas))) * 10
+}
+
+// SaveJSON writes v to reports/{name}.json in danser's data directory, or to stdout if name is empty
+func SaveJSON(v any, name string) error {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		_, err = fmt.Fprintln(os.Stdout, string(data))
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Join(env.DataDir(), reportsDir), 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(env.DataDir(), reportsDir, name+".json")
+
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+
+	log.Println("Report saved to:", path)
+
+	return nil
+}
@@ -0,0 +1,440 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/rplpa"
+	"io"
+	"log"
+	"math"
+	"os"
+)
+
+const (
+	// Frames shorter than that fraction of the median frame time are irregular
+	shortFrameFactor = 0.75
+
+	// Share of short frames above which frame timing is suspicious
+	irregularFrameThreshold = 0.1
+
+	// Unstable rate, after applying rate changing mods, below which hit timing is suspicious
+	unstableRateThreshold = 40.0
+
+	// Clicks closer to object's centre than that, in circle radii, are snapped
+	snapDistance = 0.05
+
+	// Share of snapped clicks above which aim is suspicious
+	snapThreshold = 0.1
+
+	// Share of key presses with the most common duration above which presses are suspicious
+	identicalPressThreshold = 0.3
+
+	// Metrics based on fewer samples are not evaluated
+	minAnomalySamples = 30
+)
+
+// Names of anomaly metrics
+const (
+	MetricFrameTime       = "median_frame_time"
+	MetricIrregularFrames = "irregular_frames"
+	MetricUnstableRate    = "unstable_rate"
+	MetricSnappedClicks   = "snapped_clicks"
+	MetricIdenticalPress  = "identical_press_durations"
+)
+
+// AnomalyMetric is a single statistic of the replay compared against its threshold
+type AnomalyMetric struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Value       float64 `json:"value"`
+	Threshold   float64 `json:"threshold"`
+	FlaggedWhen string  `json:"flagged_when"`
+	Samples     int     `json:"samples"`
+	Flagged     bool    `json:"flagged"`
+	Skipped     string  `json:"skipped,omitempty"`
+}
+
+// AnomalyReport lists statistical anomalies found in the replay's input. A flagged metric is not a proof of cheating, replays need to be reviewed manually.
+type AnomalyReport struct {
+	Replay  string          `json:"replay"`
+	Player  string          `json:"player"`
+	Beatmap BeatmapInfo     `json:"beatmap"`
+	Mods    string          `json:"mods"`
+	Flagged bool            `json:"flagged"`
+	Error   string          `json:"error,omitempty"`
+	Metrics []AnomalyMetric `json:"metrics"`
+}
+
+// below creates a metric flagged when value is lower than threshold
+func below(name, description string, value, threshold float64, samples int) AnomalyMetric {
+	return AnomalyMetric{
+		Name:        name,
+		Description: description,
+		Value:       value,
+		Threshold:   threshold,
+		FlaggedWhen: "below",
+		Samples:     samples,
+		Flagged:     value < threshold,
+	}
+}
+
+// above creates a metric flagged when value is higher than threshold
+func above(name, description string, value, threshold float64, samples int) AnomalyMetric {
+	return AnomalyMetric{
+		Name:        name,
+		Description: description,
+		Value:       value,
+		Threshold:   threshold,
+		FlaggedWhen: "above",
+		Samples:     samples,
+		Flagged:     value > threshold,
+	}
+}
+
+// skip marks the metric as not evaluated
+func (metric AnomalyMetric) skip(reason string) AnomalyMetric {
+	metric.Flagged = false
+	metric.Skipped = reason
+
+	return metric
+}
+
+// AnalyzeAnomalies simulates every replay on its beatmap and checks its frames, hit timing, aim and key presses for patterns unusual for human play.
+// Beatmaps are matched by MD5. Replays that can't be analyzed get a report with Error set.
+func AnalyzeAnomalies(paths []string, beatMaps []*beatmap.BeatMap) []*AnomalyReport {
+	reports := make([]*AnomalyReport, 0, len(paths))
+
+	for _, path := range paths {
+		log.Println("Checking for anomalies:", path)
+
+		report, err := analyzeAnomalies(path, beatMaps)
+		if err != nil {
+			log.Println(fmt.Sprintf("Failed to analyze \"%s\": %s", path, err))
+
+			if report == nil {
+				report = &AnomalyReport{Replay: path}
+			}
+
+			report.Error = err.Error()
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports
+}
+
+func analyzeAnomalies(path string, beatMaps []*beatmap.BeatMap) (*AnomalyReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	replay, err := rplpa.ParseReplay(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AnomalyReport{
+		Replay:  path,
+		Player:  replay.Username,
+		Metrics: make([]AnomalyMetric, 0),
+	}
+
+	if replay.PlayMode != rulesets.ModeOsu {
+		return report, errors.New("only osu!standard replays are supported")
+	}
+
+	if replay.ReplayData == nil || len(replay.ReplayData) < 2 {
+		return report, errors.New("replay is missing input data")
+	}
+
+	beatMap, err := parseReplayBeatMap(replay, beatMaps)
+	if err != nil {
+		return report, err
+	}
+
+	defer beatMap.Clear()
+
+	report.Beatmap = newBeatmapInfo(beatMap)
+
+	if len(beatMap.HitObjects) == 0 {
+		return report, errors.New("beatmap doesn't have any hit objects")
+	}
+
+	// ReplayController reads the replay again, so these frames are not shared with the simulation
+	frames := dance.CleanFrames(replay.ReplayData)
+
+	settings.REPLAY = path
+	settings.PLAYMODE = rulesets.ModeOsu
+
+	controller, err := loadReplay(beatMap)
+	if err != nil {
+		return report, err
+	}
+
+	ruleset := controller.GetRuleset()
+	cursor := controller.GetCursors()[0]
+	diff := ruleset.GetPlayerDifficulty(cursor)
+
+	report.Mods = diff.GetModString()
+
+	deltas := make([]HitDelta, 0)
+
+	clicks, snapped := 0, 0
+
+	ruleset.SetListener(func(c *graphics.Cursor, result osu.JudgementResult, _ osu.Score) {
+		if delta, ok := hitDelta(beatMap, diff, result); ok {
+			deltas = append(deltas, delta)
+		}
+
+		if _, isCircle := beatMap.HitObjects[result.Number].(*objects.Circle); isCircle && result.HitResult&osu.BaseHits > 0 {
+			clicks++
+
+			if c.RawPosition.Dst(result.Position) < snapDistance*diff.GetRadius() {
+				snapped++
+			}
+		}
+	})
+
+	log.Println("Simulating replay...")
+
+	Simulate(controller)
+
+	report.Metrics = append(report.Metrics, frameMetrics(frames, diff)...)
+	report.Metrics = append(report.Metrics, unstableRateMetric(deltas, diff))
+	report.Metrics = append(report.Metrics, snapMetric(clicks, snapped, diff))
+	report.Metrics = append(report.Metrics, pressMetric(frames, diff))
+
+	for _, m := range report.Metrics {
+		report.Flagged = report.Flagged || m.Flagged
+	}
+
+	return report, nil
+}
+
+// frameMetrics checks if the game clock was sped up for the whole play (timewarp) or only for parts of it, which shows as frames shorter than usual
+func frameMetrics(frames []*rplpa.ReplayData, diff *difficulty.Difficulty) []AnomalyMetric {
+	median := dance.MedianFrameTime(frames)
+
+	frameTime := below(MetricFrameTime, "Median time between frames in ms, after applying rate changing mods", diff.GetModifiedTime(median), dance.TimewarpFrameTime, len(frames))
+
+	// Same condition as the timewarp warning printed when the replay is loaded
+	frameTime.Flagged = frameTime.Value <= dance.TimewarpFrameTime
+
+	// Frames where key state changed are written out of the regular interval
+	regular, short := 0, 0
+
+	for i := 1; i < len(frames); i++ {
+		if frames[i].Time <= 0 || keyState(frames[i]) != keyState(frames[i-1]) {
+			continue
+		}
+
+		regular++
+
+		if frames[i].Time < median*shortFrameFactor {
+			short++
+		}
+	}
+
+	irregular := above(MetricIrregularFrames, "Share of frames without key changes that are much shorter than the median frame time", share(short, regular), irregularFrameThreshold, regular)
+
+	if diff.CheckModActive(difficulty.Autoplay | difficulty.Relax | difficulty.Relax2) {
+		frameTime = frameTime.skip("mods active")
+		irregular = irregular.skip("mods active")
+	} else if regular < minAnomalySamples {
+		irregular = irregular.skip("not enough frames")
+	}
+
+	return []AnomalyMetric{frameTime, irregular}
+}
+
+// unstableRateMetric checks for hit timing too consistent for a human, like relax hacks produce
+func unstableRateMetric(deltas []HitDelta, diff *difficulty.Difficulty) AnomalyMetric {
+	metric := below(MetricUnstableRate, "Unstable rate of hits, after applying rate changing mods", diff.GetModifiedTime(unstableRate(deltas)), unstableRateThreshold, len(deltas))
+
+	if diff.CheckModActive(difficulty.Autoplay | difficulty.Relax) {
+		return metric.skip("mods active")
+	}
+
+	if len(deltas) < minAnomalySamples {
+		return metric.skip("not enough hits")
+	}
+
+	return metric
+}
+
+// snapMetric checks for clicks landing on circles' centres more often than a human would, like aim assist does
+func snapMetric(clicks, snapped int, diff *difficulty.Difficulty) AnomalyMetric {
+	metric := above(MetricSnappedClicks, fmt.Sprintf("Share of circle hits closer than %.2f radius to circle's centre", snapDistance), share(snapped, clicks), snapThreshold, clicks)
+
+	if diff.CheckModActive(difficulty.Autoplay | difficulty.Relax2) {
+		return metric.skip("mods active")
+	}
+
+	if clicks < minAnomalySamples {
+		return metric.skip("not enough hits")
+	}
+
+	return metric
+}
+
+// pressMetric checks for key presses held for the same time over and over, like macros and relax hacks produce
+func pressMetric(frames []*rplpa.ReplayData, diff *difficulty.Difficulty) AnomalyMetric {
+	durations := pressDurations(frames)
+
+	counts := make(map[int64]int)
+	mostCommon := 0
+
+	for _, d := range durations {
+		counts[d]++
+		mostCommon = max(mostCommon, counts[d])
+	}
+
+	metric := above(MetricIdenticalPress, "Share of key presses held for the most common duration", share(mostCommon, len(durations)), identicalPressThreshold, len(durations))
+
+	if diff.CheckModActive(difficulty.Autoplay | difficulty.Relax) {
+		return metric.skip("mods active")
+	}
+
+	if len(durations) < minAnomalySamples {
+		return metric.skip("not enough key presses")
+	}
+
+	return metric
+}
+
+// pressDurations returns how long each key press was held, in beatmap time rounded to ms
+func pressDurations(frames []*rplpa.ReplayData) []int64 {
+	durations := make([]int64, 0)
+
+	var pressStart [4]float64
+
+	time := 0.0
+	lastState := uint8(0)
+
+	for _, frame := range frames {
+		time += frame.Time
+
+		state := keyState(frame)
+
+		for i := 0; i < len(pressStart); i++ {
+			key := uint8(1) << i
+
+			if state&key > 0 && lastState&key == 0 {
+				pressStart[i] = time
+			} else if state&key == 0 && lastState&key > 0 {
+				durations = append(durations, int64(math.Round(time-pressStart[i])))
+			}
+		}
+
+		lastState = state
+	}
+
+	return durations
+}
+
+// keyState returns pressed keys as K1, K2, M1, M2 bits. Keyboard keys set mouse buttons too, so those are only counted when pressed alone.
+func keyState(frame *rplpa.ReplayData) (state uint8) {
+	if frame.KeyPressed == nil {
+		return
+	}
+
+	keys := frame.KeyPressed
+
+	if keys.Key1 {
+		state |= 1
+	}
+
+	if keys.Key2 {
+		state |= 2
+	}
+
+	if keys.LeftClick && !keys.Key1 {
+		state |= 4
+	}
+
+	if keys.RightClick && !keys.Key2 {
+		state |= 8
+	}
+
+	return
+}
+
+func share(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(count) / float64(total)
+}
+
+// WriteAnomalySummary writes a human-readable clean/flagged line for every report, followed by all metrics
+func WriteAnomalySummary(w io.Writer, reports []*AnomalyReport) error {
+	flagged := 0
+
+	for _, r := range reports {
+		status := "CLEAN"
+
+		switch {
+		case r.Error != "":
+			status = "ERROR"
+		case r.Flagged:
+			status = "FLAGGED"
+			flagged++
+		}
+
+		line := fmt.Sprintf("%-8s %s", status, r.Replay)
+		if r.Player != "" {
+			line += fmt.Sprintf(" (%s)", r.Player)
+		}
+
+		if r.Beatmap.MD5 != "" {
+			line += fmt.Sprintf(" %s - %s [%s]", r.Beatmap.Artist, r.Beatmap.Title, r.Beatmap.Difficulty)
+		}
+
+		if r.Mods != "" {
+			line += " +" + r.Mods
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+
+		if r.Error != "" {
+			if _, err := fmt.Fprintln(w, "\t"+r.Error); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		for _, m := range r.Metrics {
+			mark := "   "
+			if m.Flagged {
+				mark = "[!]"
+			}
+
+			line = fmt.Sprintf("\t%s %-26s %8.3f (flagged %s %.3f, %d samples)", mark, m.Name, m.Value, m.FlaggedWhen, m.Threshold, m.Samples)
+			if m.Skipped != "" {
+				line += ", skipped: " + m.Skipped
+			}
+
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d/%d replays flagged\n", flagged, len(reports))
+
+	return err
+}
@@ -0,0 +1,260 @@
+package analysis
+
+import (
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/api"
+	"github.com/wieku/danser-go/framework/env"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BatchEntry holds difficulty attributes and pp of a single beatmap, mod combination and pp version
+type BatchEntry struct {
+	File       string         `json:"file"`
+	Beatmap    BeatmapInfo    `json:"beatmap"`
+	Mods       string         `json:"mods"`
+	PPVersion  string         `json:"pp_version"`
+	Attributes api.Attributes `json:"attributes"`
+	SSPP       PPInfo         `json:"ss_pp"`
+	Accuracy   float64        `json:"accuracy"`
+	Misses     int            `json:"misses"`
+	PP         PPInfo         `json:"pp"`
+}
+
+// CollectBeatmaps returns all .osu files found in given paths. Directories are searched recursively.
+func CollectBeatmaps(paths []string) ([]string, error) {
+	return collectFiles(paths, ".osu")
+}
+
+// collectFiles returns given files and files with given extension found recursively in given directories
+func collectFiles(paths []string, extension string) ([]string, error) {
+	var result []string
+
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !stat.IsDir() {
+			result = append(result, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(fPath string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), extension) {
+				result = append(result, fPath)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// CalculateBatch runs difficulty and performance calculators of all pp versions on given osu!standard beatmaps with every mod combination.
+// Beatmaps that fail to load are skipped. PP is calculated for SS and for given accuracy (0-1) and miss count.
+func CalculateBatch(paths []string, modCombinations []difficulty.Modifier, accuracy float64, misses int) []BatchEntry {
+	entries := make([]BatchEntry, 0)
+
+	for _, path := range paths {
+		beatMap, err := loadBeatMap(path, true)
+		if err == nil && len(beatMap.HitObjects) < 2 {
+			err = errors.New("not enough hit objects")
+		}
+
+		if err != nil {
+			log.Println(fmt.Sprintf("Failed to load \"%s\": %s", path, err))
+			continue
+		}
+
+		log.Println("Calculating:", path)
+
+		for _, mods := range modCombinations {
+			diff := difficulty.NewDifficulty(beatMap.Diff.GetBaseHP(), beatMap.Diff.GetBaseCS(), beatMap.Diff.GetBaseOD(), beatMap.Diff.GetBaseAR())
+			diff.SetMods(mods)
+
+			diff.DiffCalcMode = true // Same as in osu ruleset, lazer's stack offset is used for all plays
+
+			beatMap.CalculateStackLeniency(diff)
+
+			for _, version := range performance.Versions {
+				attribs := performance.NewDifficultyCalculator(version).CalculateSingle(beatMap.HitObjects, diff)
+				ppCalc := performance.NewPPCalculator(version)
+
+				entries = append(entries, BatchEntry{
+					File:       path,
+					Beatmap:    newBeatmapInfo(beatMap),
+					Mods:       diff.GetModString(),
+					PPVersion:  version,
+					Attributes: attribs,
+					SSPP:       newPPInfo(ppCalc.Calculate(attribs, api.PerfScore{CountGreat: -1, MaxCombo: -1, Accuracy: 1, SliderEnd: -1}, diff)),
+					Accuracy:   accuracy,
+					Misses:     misses,
+					PP:         newPPInfo(ppCalc.Calculate(attribs, scoreFromAccuracy(attribs, accuracy, misses), diff)),
+				})
+			}
+		}
+
+		beatMap.Clear()
+	}
+
+	return entries
+}
+
+// loadBeatMap parses osu!standard beatmap with its objects. If diffCalcOnly is true, only the data needed by difficulty calculators is parsed.
+func loadBeatMap(path string, diffCalcOnly bool) (*beatmap.BeatMap, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	beatMap := beatmap.ParseBeatMapFile(file)
+	if beatMap == nil {
+		return nil, errors.New("corrupted file")
+	}
+
+	if beatMap.Mode != 0 {
+		return nil, errors.New("only osu!standard beatmaps are supported")
+	}
+
+	hash := md5.New()
+	if _, err = io.Copy(hash, file); err == nil {
+		beatMap.MD5 = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, diffCalcOnly, false)
+
+	return beatMap, nil
+}
+
+// scoreFromAccuracy distributes hits so the score has given accuracy, using 100s first and 50s only if accuracy is too low.
+// Misses are counted as combo breaks, so max combo is lowered accordingly.
+func scoreFromAccuracy(attribs api.Attributes, accuracy float64, misses int) api.PerfScore {
+	total := attribs.ObjectCount
+
+	misses = min(max(misses, 0), total)
+	hits := total - misses
+
+	count100 := int(math.Round(1.5 * (float64(total)*(1-accuracy) - float64(misses))))
+	count50 := 0
+
+	if count100 > hits {
+		count100 = int(math.Round(6*float64(total)*accuracy)) - hits
+		count50 = hits - count100
+	}
+
+	count100 = min(max(count100, 0), hits)
+	count50 = min(max(count50, 0), hits-count100)
+
+	count300 := hits - count100 - count50
+
+	return api.PerfScore{
+		Accuracy:   float64(300*count300+100*count100+50*count50) / float64(300*max(total, 1)),
+		MaxCombo:   max(attribs.MaxCombo-misses, 0),
+		CountGreat: count300,
+		CountOk:    count100,
+		CountMeh:   count50,
+		CountMiss:  misses,
+		SliderEnd:  -1,
+	}
+}
+
+// SaveCSV writes batch entries to reports/{name}.csv in danser's data directory, or to stdout if name is empty
+func SaveCSV(entries []BatchEntry, name string) error {
+	var out io.Writer = os.Stdout
+
+	var path string
+
+	if name != "" {
+		if err := os.MkdirAll(filepath.Join(env.DataDir(), reportsDir), 0755); err != nil {
+			return err
+		}
+
+		path = filepath.Join(env.DataDir(), reportsDir, name+".csv")
+
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	w := csv.NewWriter(out)
+
+	header := []string{
+		"file", "md5", "artist", "title", "difficulty", "creator", "mods", "pp_version",
+		"stars", "aim", "aim_no_sliders", "speed", "speed_note_count", "aim_difficult_strain_count", "aim_difficult_slider_count",
+		"speed_difficult_strain_count", "flashlight", "slider_factor", "object_count", "circles", "sliders", "spinners", "max_combo",
+		"ss_aim", "ss_speed", "ss_acc", "ss_flashlight", "ss_total",
+		"accuracy", "misses", "aim_pp", "speed_pp", "acc_pp", "flashlight_pp", "total_pp",
+	}
+
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	for _, e := range entries {
+		a := e.Attributes
+
+		record := []string{
+			e.File, e.Beatmap.MD5, e.Beatmap.Artist, e.Beatmap.Title, e.Beatmap.Difficulty, e.Beatmap.Creator, e.Mods, e.PPVersion,
+			f(a.Total), f(a.Aim), f(a.AimNoSliders), f(a.Speed), f(a.SpeedNoteCount), f(a.AimDifficultStrainCount), f(a.AimDifficultSliderCount),
+			f(a.SpeedDifficultStrainCount), f(a.Flashlight), f(a.SliderFactor), strconv.Itoa(a.ObjectCount), strconv.Itoa(a.Circles), strconv.Itoa(a.Sliders), strconv.Itoa(a.Spinners), strconv.Itoa(a.MaxCombo),
+			f(e.SSPP.Aim), f(e.SSPP.Speed), f(e.SSPP.Acc), f(e.SSPP.Flashlight), f(e.SSPP.Total),
+			f(e.Accuracy), strconv.Itoa(e.Misses), f(e.PP.Aim), f(e.PP.Speed), f(e.PP.Acc), f(e.PP.Flashlight), f(e.PP.Total),
+		}
+
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	if path != "" {
+		log.Println("Report saved to:", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,636 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/files"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Snap divisors allowed by the checks, the same ones osu! editor offers
+var snapDivisors = []int{1, 2, 3, 4, 6, 8, 12, 16}
+
+// unsnapThreshold is the distance in milliseconds from the closest snap above which object is considered unsnapped.
+// Editor rounds times to whole milliseconds, so 1ms errors are expected.
+const unsnapThreshold = 1.0
+
+// Objects closer than that are treated as stacked, not overlapping
+const overlapStackDistance = 3.0
+
+// CheckIssue is a single problem found in a beatmap set or difficulty
+type CheckIssue struct {
+	Severity  string `json:"severity"`
+	Check     string `json:"check"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Message   string `json:"message"`
+}
+
+// CheckReport holds issues of a single difficulty
+type CheckReport struct {
+	File     string       `json:"file"`
+	Beatmap  BeatmapInfo  `json:"beatmap"`
+	Errors   int          `json:"errors"`
+	Warnings int          `json:"warnings"`
+	Issues   []CheckIssue `json:"issues"`
+}
+
+// CheckSetReport holds issues of a beatmap set, set-wide issues like inconsistent metadata or .osb problems are stored in Issues
+type CheckSetReport struct {
+	Directory string         `json:"directory"`
+	Errors    int            `json:"errors"`
+	Warnings  int            `json:"warnings"`
+	Issues    []CheckIssue   `json:"issues"`
+	Beatmaps  []*CheckReport `json:"beatmaps"`
+}
+
+func (report *CheckSetReport) add(severity, check, format string, args ...any) {
+	report.Issues = append(report.Issues, newIssue(severity, check, "", format, args...))
+}
+
+func (report *CheckReport) add(severity, check, timestamp, format string, args ...any) {
+	report.Issues = append(report.Issues, newIssue(severity, check, timestamp, format, args...))
+}
+
+func newIssue(severity, check, timestamp, format string, args ...any) CheckIssue {
+	return CheckIssue{
+		Severity:  severity,
+		Check:     check,
+		Timestamp: timestamp,
+		Message:   fmt.Sprintf(format, args...),
+	}
+}
+
+func countIssues(issues []CheckIssue) (errs, warnings int) {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+
+	return
+}
+
+// HasErrors returns true if the set or any of its difficulties has an error-level issue
+func (report *CheckSetReport) HasErrors() bool {
+	return report.Errors > 0
+}
+
+// CheckBeatmaps runs quality checks on given .osu files. Files are grouped by directory,
+// difficulties from the same directory are treated as one beatmap set, so set-wide checks only see files given here.
+func CheckBeatmaps(paths []string) []*CheckSetReport {
+	dirs := make([]string, 0)
+	sets := make(map[string][]string)
+
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+
+		if _, ok := sets[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+
+		sets[dir] = append(sets[dir], path)
+	}
+
+	reports := make([]*CheckSetReport, 0, len(dirs))
+
+	for _, dir := range dirs {
+		log.Println("Checking:", dir)
+
+		reports = append(reports, checkSet(dir, sets[dir]))
+	}
+
+	return reports
+}
+
+func checkSet(dir string, paths []string) *CheckSetReport {
+	report := &CheckSetReport{
+		Directory: dir,
+		Issues:    make([]CheckIssue, 0),
+		Beatmaps:  make([]*CheckReport, 0, len(paths)),
+	}
+
+	index, err := newFileIndex(dir)
+	if err != nil {
+		report.add(SeverityError, "files", "Failed to list files: %s", err)
+	}
+
+	beatMaps := make([]*beatmap.BeatMap, 0, len(paths))
+
+	for _, path := range paths {
+		bReport := &CheckReport{
+			File:   path,
+			Issues: make([]CheckIssue, 0),
+		}
+
+		beatMap, err := loadBeatMap(path, false)
+		if err != nil {
+			log.Println(fmt.Sprintf("Failed to load \"%s\": %s", path, err))
+
+			bReport.add(SeverityError, "load", "", "Failed to load the beatmap: %s", err)
+		} else {
+			bReport.Beatmap = newBeatmapInfo(beatMap)
+
+			checkTiming(beatMap, bReport)
+			checkObjects(beatMap, bReport)
+
+			if index != nil {
+				checkFiles(beatMap, index, bReport)
+				checkStoryboard(path, index, bReport.add)
+			}
+
+			beatMaps = append(beatMaps, beatMap)
+		}
+
+		report.Beatmaps = append(report.Beatmaps, bReport)
+	}
+
+	checkMetadata(beatMaps, report)
+
+	if index != nil {
+		for _, osb := range index.withExtension(".osb") {
+			checkStoryboard(filepath.Join(dir, osb), index, func(severity, check, timestamp, format string, args ...any) {
+				report.add(severity, check, "%s: "+format, append([]any{osb}, args...)...)
+			})
+		}
+	}
+
+	report.Errors, report.Warnings = countIssues(report.Issues)
+
+	for _, b := range report.Beatmaps {
+		b.Errors, b.Warnings = countIssues(b.Issues)
+
+		report.Errors += b.Errors
+		report.Warnings += b.Warnings
+	}
+
+	return report
+}
+
+// formatTimestamp returns time in osu! editor format, e.g. 01:02:345 (1,2), which can be pasted into the editor
+func formatTimestamp(time float64, objs ...objects.IHitObject) string {
+	ms := int64(math.Round(time))
+
+	sign := ""
+	if ms < 0 {
+		sign = "-"
+		ms = -ms
+	}
+
+	timestamp := fmt.Sprintf("%s%02d:%02d:%03d", sign, ms/60000, ms/1000%60, ms%1000)
+
+	if len(objs) > 0 {
+		combos := make([]string, 0, len(objs))
+		for _, o := range objs {
+			combos = append(combos, strconv.FormatInt(o.GetComboNumber(), 10))
+		}
+
+		timestamp += " (" + strings.Join(combos, ",") + ")"
+	}
+
+	return timestamp
+}
+
+func objectEndTime(obj objects.IHitObject) float64 {
+	if s, ok := obj.(*objects.Slider); ok {
+		return s.EndTimeLazer
+	}
+
+	return obj.GetEndTime()
+}
+
+func checkTiming(beatMap *beatmap.BeatMap, report *CheckReport) {
+	points := beatMap.Timings.GetPoints()
+
+	if !slices.ContainsFunc(points, func(p objects.TimingPoint) bool { return !p.Inherited }) {
+		report.add(SeverityError, "timing", "", "Beatmap doesn't have any uninherited timing points")
+		return
+	}
+
+	objs := beatMap.HitObjects
+	if len(objs) == 0 {
+		return
+	}
+
+	for _, obj := range objs {
+		times := []float64{obj.GetStartTime()}
+		names := []string{"Object"}
+
+		switch obj.(type) {
+		case *objects.Slider:
+			times = append(times, objectEndTime(obj))
+			names = append(names, "Slider end")
+		case *objects.Spinner:
+			times = append(times, objectEndTime(obj))
+			names = append(names, "Spinner end")
+		}
+
+		for i, t := range times {
+			if offset, ok := getUnsnap(beatMap.Timings, t); !ok {
+				report.add(SeverityError, "unsnapped", formatTimestamp(t, obj), "%s is unsnapped by %.0fms", names[i], offset)
+			}
+		}
+	}
+
+	lastEnd := math.Inf(-1)
+	for _, obj := range objs {
+		lastEnd = max(lastEnd, objectEndTime(obj))
+	}
+
+	for i, point := range points {
+		if point.Time > lastEnd {
+			report.add(SeverityWarning, "timing", formatTimestamp(point.Time), "Timing point is after the last object")
+			continue
+		}
+
+		// Uninherited points without objects are legit during breaks, as well as kiai toggles
+		if !point.Inherited || (i > 0 && points[i-1].Kiai != point.Kiai) {
+			continue
+		}
+
+		end := math.Inf(1)
+		if i < len(points)-1 {
+			end = points[i+1].Time
+		}
+
+		used := slices.ContainsFunc(objs, func(o objects.IHitObject) bool {
+			return o.GetStartTime() < end && objectEndTime(o) >= point.Time
+		})
+
+		if !used {
+			report.add(SeverityWarning, "timing", formatTimestamp(point.Time), "Inherited timing point doesn't affect any object")
+		}
+	}
+}
+
+// getUnsnap returns how far given time is from the closest snap of the active uninherited timing point.
+// Returns false if the distance exceeds unsnapThreshold.
+func getUnsnap(timings *objects.Timings, time float64) (float64, bool) {
+	point := timings.GetOriginalPointAt(time)
+	beatLength := point.GetBaseBeatLength()
+
+	if beatLength <= 0 || math.IsNaN(beatLength) || math.IsInf(beatLength, 0) {
+		return 0, true
+	}
+
+	best := math.Inf(1)
+
+	for _, divisor := range snapDivisors {
+		step := beatLength / float64(divisor)
+		offset := time - (point.Time + math.Round((time-point.Time)/step)*step)
+
+		if math.Abs(offset) < math.Abs(best) {
+			best = offset
+		}
+	}
+
+	return best, math.Abs(best) <= unsnapThreshold
+}
+
+func isOutsidePlayfield(x, y float32) bool {
+	return x < 0 || x > 512 || y < 0 || y > 384
+}
+
+func checkObjects(beatMap *beatmap.BeatMap, report *CheckReport) {
+	if len(beatMap.HitObjects) == 0 {
+		report.add(SeverityError, "objects", "", "Beatmap doesn't have any hit objects")
+		return
+	}
+
+	radius := float32(beatMap.Diff.CircleRadius)
+
+	var previous objects.IHitObject
+
+	for _, obj := range beatMap.HitObjects {
+		if _, ok := obj.(*objects.Spinner); ok {
+			previous = nil
+			continue
+		}
+
+		timestamp := formatTimestamp(obj.GetStartTime(), obj)
+
+		pos := obj.GetStartPosition()
+		if isOutsidePlayfield(pos.X, pos.Y) {
+			report.add(SeverityError, "playfield", timestamp, "Object is outside of the playfield at %.0f,%.0f", pos.X, pos.Y)
+		}
+
+		if s, ok := obj.(*objects.Slider); ok {
+			if s.IsRetarded() {
+				report.add(SeverityError, "slider", timestamp, "Slider has zero length or duration")
+			} else {
+				for _, p := range s.GetCurve().GetPointsLazer() {
+					if isOutsidePlayfield(p.X, p.Y) {
+						report.add(SeverityWarning, "playfield", timestamp, "Slider body goes outside of the playfield at %.0f,%.0f", p.X, p.Y)
+						break
+					}
+				}
+			}
+		}
+
+		if previous != nil && previous.GetComboSet() == obj.GetComboSet() {
+			for _, dst := range []float32{pos.Dst(previous.GetStartPosition()), pos.Dst(previous.GetEndPosition())} {
+				if dst > overlapStackDistance && dst < radius {
+					report.add(SeverityWarning, "overlap", formatTimestamp(previous.GetStartTime(), previous, obj), "Objects in the same combo overlap")
+					break
+				}
+			}
+		}
+
+		previous = obj
+	}
+}
+
+func checkFiles(beatMap *beatmap.BeatMap, index *fileIndex, report *CheckReport) {
+	if beatMap.Audio == "" {
+		report.add(SeverityError, "files", "", "Audio file is not specified")
+	} else {
+		index.check(beatMap.Audio, "Audio file", report.add)
+	}
+
+	if beatMap.Bg == "" {
+		report.add(SeverityWarning, "files", "", "Background is not specified")
+	} else {
+		index.check(beatMap.Bg, "Background", report.add)
+	}
+}
+
+// checkMetadata compares metadata of all difficulties with the first one
+func checkMetadata(beatMaps []*beatmap.BeatMap, report *CheckSetReport) {
+	if len(beatMaps) < 2 {
+		return
+	}
+
+	base := beatMaps[0]
+
+	fields := func(b *beatmap.BeatMap) [][2]string {
+		tags := strings.Fields(b.Tags)
+		slices.Sort(tags)
+
+		return [][2]string{
+			{"Artist", b.Artist},
+			{"ArtistUnicode", b.ArtistUnicode},
+			{"Title", b.Name},
+			{"TitleUnicode", b.NameUnicode},
+			{"Creator", b.Creator},
+			{"Source", b.Source},
+			{"Tags", strings.Join(tags, " ")},
+			{"AudioFilename", b.Audio},
+			{"PreviewTime", strconv.FormatInt(b.PreviewTime, 10)},
+		}
+	}
+
+	baseFields := fields(base)
+
+	for _, b := range beatMaps[1:] {
+		for i, field := range fields(b) {
+			if field[1] != baseFields[i][1] {
+				report.add(SeverityError, "metadata", "%s in [%s] is \"%s\", but \"%s\" in [%s]", field[0], b.Difficulty, field[1], baseFields[i][1], base.Difficulty)
+			}
+		}
+	}
+}
+
+// checkStoryboard reports missing images, animation frames, samples and videos referenced by .osu or .osb file
+func checkStoryboard(path string, index *fileIndex, add func(severity, check, timestamp, format string, args ...any)) {
+	file, err := os.Open(path)
+	if err != nil {
+		add(SeverityError, "storyboard", "", "Failed to open: %s", err)
+		return
+	}
+
+	defer file.Close()
+
+	scanner := files.NewScannerBuf(file, 10*1024*1024)
+
+	var currentSection string
+	var variables []string
+
+	checked := make(map[string]bool)
+
+	checkFile := func(name, def string) {
+		name = strings.TrimSpace(strings.ReplaceAll(name, "\"", ""))
+		if filepath.Ext(name) == "" {
+			name += def
+		}
+
+		if !checked[name] {
+			checked[name] = true
+			index.check(name, "Storyboard file", add)
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			currentSection = strings.Trim(trimmed, "[]")
+			continue
+		}
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+
+		switch currentSection {
+		case "Variables", "256":
+			if split := strings.SplitN(trimmed, "=", 2); len(split) == 2 && strings.HasPrefix(split[0], "$") {
+				variables = append(variables, split[0], split[1])
+			}
+		case "Events", "32":
+			// Commands are indented
+			if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "_") {
+				continue
+			}
+
+			if len(variables) > 0 {
+				trimmed = strings.NewReplacer(variables...).Replace(trimmed)
+			}
+
+			spl := strings.Split(trimmed, ",")
+
+			switch spl[0] {
+			case "Sprite", "4":
+				if len(spl) >= 4 {
+					checkFile(spl[3], ".png")
+				}
+			case "Animation", "6":
+				if len(spl) >= 7 {
+					name := strings.TrimSpace(strings.ReplaceAll(spl[3], "\"", ""))
+					ext := filepath.Ext(name)
+					base := strings.TrimSuffix(name, ext)
+
+					frames, _ := strconv.Atoi(spl[6])
+
+					for i := 0; i < frames; i++ {
+						checkFile(base+strconv.Itoa(i)+ext, ".png")
+					}
+				}
+			case "Sample", "5":
+				if len(spl) >= 4 {
+					checkFile(spl[3], ".wav")
+				}
+			case "Video", "1":
+				if len(spl) >= 3 {
+					checkFile(spl[2], "")
+				}
+			}
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		add(SeverityError, "storyboard", "", "Failed to read: %s", err)
+	}
+}
+
+// fileIndex lists all files in beatmap set directory. osu! is case-insensitive on Windows,
+// so files differing only by case are reported separately because they fail to load on Linux and macOS.
+type fileIndex struct {
+	exact map[string]bool
+	lower map[string]string
+	names []string
+}
+
+func newFileIndex(dir string) (*fileIndex, error) {
+	index := &fileIndex{
+		exact: make(map[string]bool),
+		lower: make(map[string]string),
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		index.exact[rel] = true
+		index.lower[strings.ToLower(rel)] = rel
+		index.names = append(index.names, rel)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// withExtension returns files with given extension in the root of the directory
+func (index *fileIndex) withExtension(ext string) (result []string) {
+	for _, name := range index.names {
+		if !strings.Contains(name, "/") && strings.EqualFold(filepath.Ext(name), ext) {
+			result = append(result, name)
+		}
+	}
+
+	return
+}
+
+func (index *fileIndex) check(name, kind string, add func(severity, check, timestamp, format string, args ...any)) {
+	name = strings.TrimPrefix(filepath.ToSlash(strings.ReplaceAll(name, "\\", "/")), "./")
+
+	if index.exact[name] {
+		return
+	}
+
+	if actual, ok := index.lower[strings.ToLower(name)]; ok {
+		add(SeverityWarning, "files", "", "%s \"%s\" differs in case from \"%s\", it won't load on case-sensitive systems", kind, name, actual)
+		return
+	}
+
+	add(SeverityError, "files", "", "%s \"%s\" is missing", kind, name)
+}
+
+// WriteCheckTable writes found issues in human-readable form followed by a summary line
+func WriteCheckTable(w io.Writer, reports []*CheckSetReport) error {
+	errs, warnings, maps := 0, 0, 0
+
+	writeIssues := func(issues []CheckIssue) error {
+		for _, issue := range issues {
+			line := fmt.Sprintf("\t%-8s %-11s", issue.Severity, issue.Check)
+			if issue.Timestamp != "" {
+				line += " " + issue.Timestamp + " -"
+			}
+
+			if _, err := fmt.Fprintln(w, line+" "+issue.Message); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, r := range reports {
+		errs += r.Errors
+		warnings += r.Warnings
+		maps += len(r.Beatmaps)
+
+		if len(r.Issues) > 0 {
+			if _, err := fmt.Fprintln(w, fmt.Sprintf("%-8s %s", checkStatus(r.Issues), r.Directory)); err != nil {
+				return err
+			}
+
+			if err := writeIssues(r.Issues); err != nil {
+				return err
+			}
+		}
+
+		for _, b := range r.Beatmaps {
+			line := fmt.Sprintf("%-8s %s", checkStatus(b.Issues), b.File)
+			if b.Beatmap.MD5 != "" {
+				line += fmt.Sprintf(" %s - %s [%s]", b.Beatmap.Artist, b.Beatmap.Title, b.Beatmap.Difficulty)
+			}
+
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+
+			if err := writeIssues(b.Issues); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintln(w, fmt.Sprintf("%d errors, %d warnings in %d beatmaps", errs, warnings, maps))
+
+	return err
+}
+
+func checkStatus(issues []CheckIssue) string {
+	errs, warnings := countIssues(issues)
+
+	switch {
+	case errs > 0:
+		return "ERROR"
+	case warnings > 0:
+		return "WARNING"
+	}
+
+	return "OK"
+}
@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/rulesets"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/rplpa"
+	"log"
+	"time"
+)
+
+// ExportDance simulates cursordance on the beatmap and saves input of every cursor as osu!stable replay in dir.
+// If name is empty, osu!stable's replay name format is used. Score saved in the replay comes from simulating the replay itself,
+// so it's the same score osu! and danser will show when watching it. Beatmap has to be parsed beforehand and settings.HEADLESS has to be set.
+func ExportDance(beatMap *beatmap.BeatMap, dir, name string) ([]string, error) {
+	if beatMap.Mode != rulesets.ModeOsu {
+		return nil, errors.New("cursordance can only play osu!standard beatmaps")
+	}
+
+	if len(beatMap.HitObjects) == 0 {
+		return nil, errors.New("beatmap doesn't have any hit objects")
+	}
+
+	if beatMap.Diff.CheckModActive(difficulty.Lazer) {
+		return nil, errors.New("osu!stable replays can't store lazer mods")
+	}
+
+	// Replays with those mods are not treated as real plays
+	beatMap.Diff.RemoveMod(difficulty.Autoplay | difficulty.Cinema)
+
+	controller := dance.NewGenericController()
+	controller.SetBeatMap(beatMap)
+	controller.InitCursors()
+
+	cursors := controller.GetCursors()
+	recorders := make([]*dance.ReplayRecorder, len(cursors))
+
+	for i, cursor := range cursors {
+		cursor.Name = settings.Knockout.DanserName
+		if len(cursors) > 1 {
+			cursor.Name += fmt.Sprintf(" %d", i+1)
+		}
+
+		cursor.ScoreTime = time.Now()
+
+		recorders[i] = dance.NewReplayRecorder(beatMap, cursor)
+	}
+
+	log.Println("Simulating cursordance...")
+
+	startTime, endTime := simulationRange(beatMap)
+
+	for t := startTime; t <= endTime; t++ {
+		controller.Update(t, 1)
+
+		for _, recorder := range recorders {
+			recorder.Update(int64(t))
+		}
+	}
+
+	diff := beatMap.Diff.Clone()
+
+	paths := make([]string, 0, len(recorders))
+
+	for i, recorder := range recorders {
+		rName := name
+
+		if rName == "" {
+			rName = recorder.ReplayName()
+		} else if len(recorders) > 1 {
+			rName += fmt.Sprintf(" %d", i+1)
+		}
+
+		// Score isn't known until the replay is simulated, so it's encoded without one first
+		data, err := recorder.Encode(osu.Score{}, diff)
+		if err != nil {
+			return paths, err
+		}
+
+		score, err := scoreReplay(beatMap, data)
+		if err != nil {
+			return paths, err
+		}
+
+		path, err := recorder.Save(score, diff, dir, rName)
+		if err != nil {
+			return paths, err
+		}
+
+		log.Println(fmt.Sprintf("Replay of \"%s\" saved to: %s (%d/%d/%d/%d, %dx)", cursors[i].Name, path, score.Count300, score.Count100, score.Count50, score.CountMiss, score.Combo))
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// scoreReplay simulates encoded replay on a freshly parsed beatmap and returns its final score
+func scoreReplay(beatMap *beatmap.BeatMap, data []byte) (osu.Score, error) {
+	replay, err := rplpa.ParseReplay(data)
+	if err != nil {
+		return osu.Score{}, err
+	}
+
+	beatMap.Clear()
+	beatMap.Pauses = nil
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, false, false)
+
+	settings.PLAYMODE = rulesets.ModeOsu
+
+	controller := dance.NewReplayControllerWithReplays(beatMap, []*rplpa.Replay{replay})
+	controller.InitCursors()
+
+	Simulate(controller)
+
+	return controller.GetRuleset().GetScore(controller.GetCursors()[0]), nil
+}
@@ -0,0 +1,368 @@
+package analysis
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance"
+	"github.com/wieku/danser-go/app/settings"
+	"log"
+	"math"
+	"slices"
+)
+
+// Criteria by which highlight windows are ranked
+const (
+	HighlightAuto       = "auto"
+	HighlightDifficulty = "difficulty"
+	HighlightCombo      = "combo"
+	HighlightPP         = "pp"
+	HighlightEvents     = "events"
+)
+
+// HighlightCriteria lists all valid ranking criteria
+var HighlightCriteria = []string{HighlightAuto, HighlightDifficulty, HighlightCombo, HighlightPP, HighlightEvents}
+
+const (
+	// Time between starts of candidate windows in milliseconds
+	highlightStep = 500.0
+
+	// Circles closer than that in real time are considered a stream
+	streamMaxGap = 110.0
+
+	// Minimum number of circles in a stream to be reported
+	streamMinLength = 16
+
+	// Number of stream circles that give full events score
+	streamFullScore = 64.0
+
+	// Every combo break in the window lowers its score by that factor in auto mode
+	comboBreakPenalty = 0.8
+)
+
+// HighlightEvent is a notable moment inside a highlight, like a long stream or a near-miss
+type HighlightEvent struct {
+	Time    int64  `json:"time"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Length  int    `json:"length,omitempty"`
+}
+
+// Highlight is a suggested clip. Start and End are in seconds, so they can be passed directly to -start and -end.
+type Highlight struct {
+	Start       float64          `json:"start"`
+	End         float64          `json:"end"`
+	Score       float64          `json:"score"`
+	Difficulty  float64          `json:"difficulty"`
+	Combo       uint             `json:"combo"`
+	PPGain      float64          `json:"pp_gain"`
+	ComboBreaks int              `json:"combo_breaks"`
+	Events      []HighlightEvent `json:"events"`
+}
+
+type HighlightReport struct {
+	Beatmap    BeatmapInfo `json:"beatmap"`
+	Player     string      `json:"player,omitempty"`
+	Mods       string      `json:"mods"`
+	Criteria   string      `json:"criteria"`
+	Length     float64     `json:"length"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// playSample is the state of the score after a judgement
+type playSample struct {
+	time       float64
+	combo      uint
+	pp         float64
+	comboBreak bool
+}
+
+// FindHighlights ranks windows of given length in seconds and returns up to count best non-overlapping ones.
+// If settings.REPLAY is set, the replay is simulated, so combo, pp gain and near-misses can be taken into account,
+// otherwise only difficulty and streams are used.
+func FindHighlights(beatMap *beatmap.BeatMap, count int, length float64, criteria string) (*HighlightReport, error) {
+	if len(beatMap.HitObjects) == 0 {
+		return nil, errors.New("beatmap doesn't have any hit objects")
+	}
+
+	if !slices.Contains(HighlightCriteria, criteria) {
+		return nil, fmt.Errorf("unknown highlight criteria: %s", criteria)
+	}
+
+	hasReplay := settings.REPLAY != ""
+
+	if !hasReplay && (criteria == HighlightCombo || criteria == HighlightPP) {
+		return nil, fmt.Errorf("highlights by %s need a replay", criteria)
+	}
+
+	diff := beatMap.Diff
+
+	report := &HighlightReport{
+		Beatmap:    newBeatmapInfo(beatMap),
+		Mods:       diff.GetModString(),
+		Criteria:   criteria,
+		Length:     length,
+		Highlights: make([]Highlight, 0, count),
+	}
+
+	var samples []playSample
+	var events []HighlightEvent
+
+	lastCombo := uint(0)
+
+	if hasReplay {
+		controller, err := loadReplay(beatMap)
+		if err != nil {
+			return nil, err
+		}
+
+		ruleset := controller.GetRuleset()
+		cursor := controller.GetCursors()[0]
+		diff = ruleset.GetPlayerDifficulty(cursor)
+
+		report.Player = cursor.Name
+		report.Mods = diff.GetModString()
+
+		ruleset.SetListener(func(_ *graphics.Cursor, result osu.JudgementResult, score osu.Score) {
+			samples = append(samples, playSample{
+				time:       float64(result.Time),
+				combo:      score.CurrentCombo,
+				pp:         score.PP.Total,
+				comboBreak: result.ComboResult == osu.Reset,
+			})
+
+			switch {
+			case result.HitResult&osu.Miss > 0:
+				events = append(events, HighlightEvent{Time: result.Time, Type: "miss", Message: fmt.Sprintf("Miss at %d combo", lastCombo)})
+			case result.HitResult&osu.Hit50 > 0:
+				events = append(events, HighlightEvent{Time: result.Time, Type: "near_miss", Message: "Hit50"})
+			}
+
+			lastCombo = score.CurrentCombo
+		})
+
+		log.Println("Simulating replay...")
+
+		Simulate(controller)
+	}
+
+	events = append(events, findStreams(beatMap, diff.GetSpeed())...)
+
+	slices.SortStableFunc(events, func(a, b HighlightEvent) int {
+		return cmp.Compare(a.Time, b.Time)
+	})
+
+	peakTimes, peaks := strainTimeline(beatMap, diff)
+
+	lengthMs := length * 1000
+
+	firstTime := beatMap.HitObjects[0].GetStartTime()
+	lastTime := beatMap.HitObjects[len(beatMap.HitObjects)-1].GetEndTime()
+
+	var candidates []Highlight
+
+	for start := firstTime; ; start += highlightStep {
+		end := start + lengthMs
+
+		candidates = append(candidates, rateWindow(start, end, peakTimes, peaks, samples, events))
+
+		if end >= lastTime {
+			break
+		}
+	}
+
+	finalCombo, finalPP := uint(1), 1.0
+	for _, s := range samples {
+		finalCombo = max(finalCombo, s.combo)
+		finalPP = max(finalPP, s.pp)
+	}
+
+	for i := range candidates {
+		c := &candidates[i]
+
+		comboScore := float64(c.Combo) / float64(finalCombo)
+		ppScore := c.PPGain / finalPP
+		eventScore := highlightEventScore(c.Events)
+
+		switch criteria {
+		case HighlightDifficulty:
+			c.Score = c.Difficulty
+		case HighlightCombo:
+			c.Score = comboScore
+		case HighlightPP:
+			c.Score = ppScore
+		case HighlightEvents:
+			c.Score = eventScore
+		default:
+			if hasReplay {
+				c.Score = (0.35*c.Difficulty + 0.25*comboScore + 0.25*ppScore + 0.15*eventScore) * math.Pow(comboBreakPenalty, float64(c.ComboBreaks))
+			} else {
+				c.Score = 0.7*c.Difficulty + 0.3*eventScore
+			}
+		}
+	}
+
+	// Stable sort keeps earlier windows first if scores are equal
+	slices.SortStableFunc(candidates, func(a, b Highlight) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+
+		return 0
+	})
+
+	for _, c := range candidates {
+		if len(report.Highlights) >= count {
+			break
+		}
+
+		overlaps := slices.ContainsFunc(report.Highlights, func(h Highlight) bool {
+			return c.Start < h.End && h.Start < c.End
+		})
+
+		if !overlaps {
+			report.Highlights = append(report.Highlights, c)
+		}
+	}
+
+	return report, nil
+}
+
+// strainTimeline returns total strain peaks with times they refer to, spread between the second and last object the same way strain graph does
+func strainTimeline(beatMap *beatmap.BeatMap, diff *difficulty.Difficulty) (times, peaks []float64) {
+	objs := beatMap.HitObjects
+
+	peaks = performance.GetDifficultyCalculator().CalculateStrainPeaks(objs, diff).Total
+
+	startTime := objs[min(1, len(objs)-1)].GetStartTime()
+	endTime := objs[len(objs)-1].GetStartTime()
+
+	times = make([]float64, len(peaks))
+	for i := range peaks {
+		times[i] = startTime + float64(i)/float64(max(1, len(peaks)-1))*(endTime-startTime)
+	}
+
+	return
+}
+
+func rateWindow(start, end float64, peakTimes, peaks []float64, samples []playSample, events []HighlightEvent) Highlight {
+	h := Highlight{
+		Start:  start / 1000,
+		End:    end / 1000,
+		Events: make([]HighlightEvent, 0),
+	}
+
+	maxPeak, sum, n := 0.0, 0.0, 0
+	for i, p := range peaks {
+		maxPeak = max(maxPeak, p)
+
+		if peakTimes[i] >= start && peakTimes[i] < end {
+			sum += p
+			n++
+		}
+	}
+
+	if n > 0 && maxPeak > 0 {
+		h.Difficulty = sum / float64(n) / maxPeak
+	}
+
+	ppBefore, ppAfter := 0.0, 0.0
+
+	for _, s := range samples {
+		if s.time < start {
+			ppBefore = s.pp
+			ppAfter = s.pp
+			continue
+		}
+
+		if s.time >= end {
+			break
+		}
+
+		ppAfter = s.pp
+		h.Combo = max(h.Combo, s.combo)
+
+		if s.comboBreak {
+			h.ComboBreaks++
+		}
+	}
+
+	h.PPGain = ppAfter - ppBefore
+
+	for _, e := range events {
+		if float64(e.Time) >= start && float64(e.Time) < end {
+			h.Events = append(h.Events, e)
+		}
+	}
+
+	return h
+}
+
+func highlightEventScore(events []HighlightEvent) float64 {
+	score := 0.0
+
+	for _, e := range events {
+		switch e.Type {
+		case "stream":
+			score += float64(e.Length) / streamFullScore
+		case "near_miss":
+			score += 0.25
+		}
+	}
+
+	return min(score, 1)
+}
+
+// findStreams returns runs of at least streamMinLength circles spaced closer than streamMaxGap at given speed
+func findStreams(beatMap *beatmap.BeatMap, speed float64) (streams []HighlightEvent) {
+	var first objects.IHitObject
+
+	length := 0
+	lastTime := math.Inf(-1)
+
+	flush := func() {
+		if length >= streamMinLength {
+			streams = append(streams, HighlightEvent{
+				Time:    int64(first.GetStartTime()),
+				Type:    "stream",
+				Message: fmt.Sprintf("%d note stream", length),
+				Length:  length,
+			})
+		}
+
+		first = nil
+		length = 0
+	}
+
+	for _, obj := range beatMap.HitObjects {
+		if _, ok := obj.(*objects.Circle); !ok {
+			flush()
+			lastTime = math.Inf(-1)
+
+			continue
+		}
+
+		if (obj.GetStartTime()-lastTime)/speed > streamMaxGap {
+			flush()
+		}
+
+		if first == nil {
+			first = obj
+		}
+
+		length++
+		lastTime = obj.GetStartTime()
+	}
+
+	flush()
+
+	return
+}
@@ -0,0 +1,622 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/env"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"github.com/wieku/rplpa"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Osu pixels around the playfield that are still shown on the heatmap
+	heatmapMargin = 64.0
+
+	// Image pixels per osu pixel on the heatmap
+	heatmapScale = 2
+
+	// Radius of the blur applied to cursor density, in image pixels
+	heatmapBlur = 6
+
+	aimSize = 640
+
+	// Distance from circle's centre shown on the aim image, in circle radii
+	aimRange = 1.5
+
+	histogramWidth  = 960
+	histogramHeight = 480
+
+	// Width of a histogram bar in milliseconds
+	histogramBin = 2.0
+
+	imagePadding = 40
+)
+
+var (
+	imageBackground = color.RGBA{R: 20, G: 20, B: 24, A: 255}
+	imageGrid       = color.RGBA{R: 90, G: 90, B: 100, A: 255}
+	imageText       = color.RGBA{R: 230, G: 230, B: 230, A: 255}
+
+	// Same colors as hit error and aim error meters use for 300s, 100s, 50s and misses
+	resultColors = []color.RGBA{{R: 51, G: 204, B: 255, A: 255}, {R: 112, G: 250, B: 46, A: 255}, {R: 217, G: 173, B: 69, A: 255}, {R: 250, G: 28, B: 3, A: 255}}
+
+	heatmapGradient = []color.RGBA{
+		{R: 20, G: 20, B: 24, A: 255},
+		{R: 30, G: 30, B: 120, A: 255},
+		{R: 0, G: 140, B: 255, A: 255},
+		{R: 0, G: 230, B: 120, A: 255},
+		{R: 255, G: 230, B: 0, A: 255},
+		{R: 255, G: 60, B: 0, A: 255},
+		{R: 255, G: 255, B: 255, A: 255},
+	}
+)
+
+// ImagesReport lists images exported for a replay
+type ImagesReport struct {
+	Replay       string      `json:"replay"`
+	Player       string      `json:"player"`
+	Beatmap      BeatmapInfo `json:"beatmap"`
+	Mods         string      `json:"mods"`
+	UnstableRate float64     `json:"unstable_rate"`
+	Files        []string    `json:"files"`
+	Error        string      `json:"error,omitempty"`
+}
+
+// aimHit is a click relative to the centre of the object, in circle radii
+type aimHit struct {
+	offset vector.Vector2f
+	result osu.HitResult
+}
+
+type replayImageData struct {
+	title string
+
+	diff *difficulty.Difficulty
+
+	cursor []vector.Vector2f
+	aim    []aimHit
+	deltas []HitDelta
+}
+
+// ExportReplayImages simulates every replay and saves its cursor heatmap, aim distribution and hit offset histogram as PNG images.
+// Images are saved next to the replay as {replay}_heatmap.png, {replay}_aim.png and {replay}_offsets.png.
+// If name is not empty, they're saved to reports/{name} instead, with replay's name appended if there's more than one replay.
+func ExportReplayImages(paths []string, beatMaps []*beatmap.BeatMap, name string) []*ImagesReport {
+	reports := make([]*ImagesReport, 0, len(paths))
+
+	for _, path := range paths {
+		log.Println("Exporting images of:", path)
+
+		base := strings.TrimSuffix(path, filepath.Ext(path))
+
+		if name != "" {
+			base = filepath.Join(env.DataDir(), reportsDir, name)
+
+			if len(paths) > 1 {
+				base += "_" + strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			}
+		}
+
+		report, err := exportReplayImages(path, beatMaps, base)
+		if err != nil {
+			log.Println(fmt.Sprintf("Failed to export images of \"%s\": %s", path, err))
+
+			if report == nil {
+				report = &ImagesReport{Replay: path}
+			}
+
+			report.Error = err.Error()
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports
+}
+
+func exportReplayImages(path string, beatMaps []*beatmap.BeatMap, base string) (*ImagesReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	replay, err := rplpa.ParseReplay(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImagesReport{
+		Replay: path,
+		Player: replay.Username,
+		Files:  make([]string, 0, 3),
+	}
+
+	if replay.PlayMode != rulesets.ModeOsu {
+		return report, errors.New("only osu!standard replays are supported")
+	}
+
+	if replay.ReplayData == nil || len(replay.ReplayData) < 2 {
+		return report, errors.New("replay is missing input data")
+	}
+
+	beatMap, err := parseReplayBeatMap(replay, beatMaps)
+	if err != nil {
+		return report, err
+	}
+
+	defer beatMap.Clear()
+
+	report.Beatmap = newBeatmapInfo(beatMap)
+
+	if len(beatMap.HitObjects) == 0 {
+		return report, errors.New("beatmap doesn't have any hit objects")
+	}
+
+	settings.REPLAY = path
+	settings.PLAYMODE = rulesets.ModeOsu
+
+	imageData, err := collectImageData(beatMap)
+	if err != nil {
+		return report, err
+	}
+
+	report.Mods = imageData.diff.GetModString()
+	report.UnstableRate = imageData.diff.GetModifiedTime(unstableRate(imageData.deltas))
+
+	if err = os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		return report, err
+	}
+
+	images := []struct {
+		suffix string
+		img    image.Image
+	}{
+		{"heatmap", drawHeatmap(imageData)},
+		{"aim", drawAimDistribution(imageData)},
+		{"offsets", drawOffsetHistogram(imageData)},
+	}
+
+	for _, i := range images {
+		file := base + "_" + i.suffix + ".png"
+
+		if err = savePNG(i.img, file); err != nil {
+			return report, err
+		}
+
+		log.Println("Image saved to:", file)
+
+		report.Files = append(report.Files, file)
+	}
+
+	return report, nil
+}
+
+// collectImageData simulates settings.REPLAY and records cursor positions during the map, clicks relative to objects and hit offsets
+func collectImageData(beatMap *beatmap.BeatMap) (*replayImageData, error) {
+	controller, err := loadReplay(beatMap)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleset := controller.GetRuleset()
+	cursor := controller.GetCursors()[0]
+	diff := ruleset.GetPlayerDifficulty(cursor)
+
+	imageData := &replayImageData{
+		title: fmt.Sprintf("%s - %s - %s [%s] %s", cursor.Name, beatMap.Artist, beatMap.Name, beatMap.Difficulty, diff.GetModString()),
+		diff:  diff,
+	}
+
+	// Same rules as aim error meter in ScoreOverlay
+	sliderChecks := osu.SliderAccuracyResults(diff) | osu.PositionalMiss
+
+	radius := float32(diff.CircleRadius)
+
+	ruleset.SetListener(func(c *graphics.Cursor, result osu.JudgementResult, _ osu.Score) {
+		if delta, ok := hitDelta(beatMap, diff, result); ok {
+			imageData.deltas = append(imageData.deltas, delta)
+		}
+
+		object := beatMap.HitObjects[result.Number]
+
+		_, isCircle := object.(*objects.Circle)
+		_, isSlider := object.(*objects.Slider)
+
+		if (isCircle && result.HitResult&(osu.BaseHits|osu.PositionalMiss) > 0) || (isSlider && result.HitResult&sliderChecks > 0) {
+			imageData.aim = append(imageData.aim, aimHit{
+				offset: c.RawPosition.Sub(result.Position).Scl(1 / radius),
+				result: result.HitResult,
+			})
+		}
+	})
+
+	startTime := beatMap.HitObjects[0].GetStartTime()
+	endTime := beatMap.HitObjects[len(beatMap.HitObjects)-1].GetEndTime()
+
+	log.Println("Simulating replay...")
+
+	simulateWith(controller, func(time float64) {
+		if time >= startTime && time <= endTime {
+			imageData.cursor = append(imageData.cursor, cursor.RawPosition)
+		}
+	})
+
+	return imageData, nil
+}
+
+// drawHeatmap draws blurred cursor density over the playfield, density is log-scaled so short visits stay visible
+func drawHeatmap(data *replayImageData) image.Image {
+	w := int((512 + 2*heatmapMargin) * heatmapScale)
+	h := int((384 + 2*heatmapMargin) * heatmapScale)
+
+	top := imagePadding
+
+	img := newImage(w, h+top)
+
+	density := make([]float64, w*h)
+
+	for _, pos := range data.cursor {
+		x := int((float64(pos.X) + heatmapMargin) * heatmapScale)
+		y := int((float64(pos.Y) + heatmapMargin) * heatmapScale)
+
+		if x >= 0 && x < w && y >= 0 && y < h {
+			density[y*w+x]++
+		}
+	}
+
+	// Three box blurs are close enough to a gaussian one
+	for i := 0; i < 3; i++ {
+		density = boxBlur(density, w, h, heatmapBlur)
+	}
+
+	maxDensity := 0.0
+	for _, d := range density {
+		maxDensity = max(maxDensity, d)
+	}
+
+	if maxDensity > 0 {
+		logMax := math.Log1p(maxDensity)
+
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if d := density[y*w+x]; d > 0 {
+					img.SetRGBA(x, y+top, gradientAt(math.Log1p(d)/logMax))
+				}
+			}
+		}
+	}
+
+	left := int(heatmapMargin * heatmapScale)
+	drawRect(img, left, top+left, left+512*heatmapScale, top+left+384*heatmapScale, imageGrid)
+
+	drawText(img, 10, 24, data.title, imageText)
+
+	return img
+}
+
+// drawAimDistribution draws clicks relative to circle's centre, rings split the circle in thirds like the aim error meter does
+func drawAimDistribution(data *replayImageData) image.Image {
+	top := imagePadding
+
+	img := newImage(aimSize, aimSize+top)
+
+	centre := aimSize / 2
+	scale := float64(aimSize) / 2 / aimRange
+
+	for _, r := range []float64{1.0 / 3, 2.0 / 3, 1} {
+		drawCircle(img, centre, centre+top, int(r*scale), imageGrid)
+	}
+
+	drawLine(img, 0, centre+top, aimSize-1, centre+top, imageGrid)
+	drawLine(img, centre, top, centre, aimSize+top-1, imageGrid)
+
+	var mean vector.Vector2f
+
+	for _, hit := range data.aim {
+		dst := float64(hit.offset.Len())
+
+		col := resultColors[3]
+
+		switch {
+		case hit.result == osu.PositionalMiss || dst > 1:
+		case dst < 1.0/3:
+			col = resultColors[0]
+		case dst < 2.0/3:
+			col = resultColors[1]
+		default:
+			col = resultColors[2]
+		}
+
+		// Cap far misses like aim error meter does
+		pos := hit.offset
+		if dst > 1.2 {
+			pos = pos.Nor().Scl(1.2)
+		}
+
+		drawCross(img, centre+int(float64(pos.X)*scale), centre+top+int(float64(pos.Y)*scale), 3, col)
+
+		mean = mean.Add(hit.offset)
+	}
+
+	drawText(img, 10, 24, data.title, imageText)
+
+	if len(data.aim) > 0 {
+		mean = mean.Scl(1 / float32(len(data.aim)))
+
+		drawCross(img, centre+int(float64(mean.X)*scale), centre+top+int(float64(mean.Y)*scale), 8, imageText)
+
+		drawText(img, 10, aimSize+top-12, fmt.Sprintf("%d clicks, mean offset: %.1f, %.1f osu!px", len(data.aim), mean.X*float32(data.diff.CircleRadius), mean.Y*float32(data.diff.CircleRadius)), imageText)
+	}
+
+	return img
+}
+
+// drawOffsetHistogram draws hit offsets with 300, 100 and 50 hit windows marked in the background
+func drawOffsetHistogram(data *replayImageData) image.Image {
+	img := newImage(histogramWidth, histogramHeight)
+
+	windows := []float64{float64(data.diff.Hit300), float64(data.diff.Hit100), float64(data.diff.Hit50)}
+	if data.diff.CheckModActive(difficulty.Lazer) {
+		windows = []float64{data.diff.Hit300U, data.diff.Hit100U, data.diff.Hit50U}
+	}
+
+	// Offsets are shown in real time, like hit error meter shows them
+	for i := range windows {
+		windows[i] = data.diff.GetModifiedTime(windows[i])
+	}
+
+	hRange := windows[2] + histogramBin
+
+	left, right := imagePadding, histogramWidth-imagePadding
+	top, bottom := imagePadding*2, histogramHeight-imagePadding
+
+	toX := func(delta float64) int {
+		return left + int((delta+hRange)/(2*hRange)*float64(right-left))
+	}
+
+	// Widest window first, so narrower ones are drawn over it
+	for i := len(windows) - 1; i >= 0; i-- {
+		col := color.NRGBA{R: resultColors[i].R, G: resultColors[i].G, B: resultColors[i].B, A: 60}
+
+		fillRect(img, toX(-windows[i]), top, toX(windows[i]), bottom, col)
+	}
+
+	bins := make([]int, int(math.Ceil(2*hRange/histogramBin)))
+	maxBin := 0
+
+	average := 0.0
+
+	for _, d := range data.deltas {
+		delta := data.diff.GetModifiedTime(d.Delta)
+
+		average += delta
+
+		i := int((delta + hRange) / histogramBin)
+		if i < 0 || i >= len(bins) {
+			continue
+		}
+
+		bins[i]++
+		maxBin = max(maxBin, bins[i])
+	}
+
+	if maxBin > 0 {
+		for i, count := range bins {
+			if count == 0 {
+				continue
+			}
+
+			start := -hRange + float64(i)*histogramBin
+			height := int(float64(count) / float64(maxBin) * float64(bottom-top))
+
+			fillRect(img, toX(start), bottom-height, max(toX(start)+1, toX(start+histogramBin)-1), bottom, imageText)
+		}
+	}
+
+	drawLine(img, left, bottom, right, bottom, imageGrid)
+	drawLine(img, toX(0), top, toX(0), bottom, imageGrid)
+
+	drawText(img, 10, 24, data.title, imageText)
+
+	if len(data.deltas) > 0 {
+		average /= float64(len(data.deltas))
+
+		drawLine(img, toX(average), top, toX(average), bottom, resultColors[3])
+
+		drawText(img, 10, 48, fmt.Sprintf("%d hits, UR: %.2f, mean: %+.2fms, windows: %.0f/%.0f/%.0fms", len(data.deltas), data.diff.GetModifiedTime(unstableRate(data.deltas)), average, windows[0], windows[1], windows[2]), imageText)
+	}
+
+	drawText(img, left, bottom+20, fmt.Sprintf("%.0fms (early)", -hRange), imageText)
+	drawText(img, right-90, bottom+20, fmt.Sprintf("+%.0fms (late)", hRange), imageText)
+
+	return img
+}
+
+// boxBlur blurs values horizontally and then vertically with given radius
+func boxBlur(values []float64, w, h, radius int) []float64 {
+	tmp := make([]float64, len(values))
+	out := make([]float64, len(values))
+
+	size := float64(2*radius + 1)
+
+	for y := 0; y < h; y++ {
+		sum := 0.0
+
+		for x := -radius; x <= radius; x++ {
+			if x >= 0 && x < w {
+				sum += values[y*w+x]
+			}
+		}
+
+		for x := 0; x < w; x++ {
+			tmp[y*w+x] = sum / size
+
+			if x-radius >= 0 {
+				sum -= values[y*w+x-radius]
+			}
+
+			if x+radius+1 < w {
+				sum += values[y*w+x+radius+1]
+			}
+		}
+	}
+
+	for x := 0; x < w; x++ {
+		sum := 0.0
+
+		for y := -radius; y <= radius; y++ {
+			if y >= 0 && y < h {
+				sum += tmp[y*w+x]
+			}
+		}
+
+		for y := 0; y < h; y++ {
+			out[y*w+x] = sum / size
+
+			if y-radius >= 0 {
+				sum -= tmp[(y-radius)*w+x]
+			}
+
+			if y+radius+1 < h {
+				sum += tmp[(y+radius+1)*w+x]
+			}
+		}
+	}
+
+	return out
+}
+
+// gradientAt returns heatmap color at v in [0, 1] range
+func gradientAt(v float64) color.RGBA {
+	v = min(max(v, 0), 1) * float64(len(heatmapGradient)-1)
+
+	i := min(int(v), len(heatmapGradient)-2)
+	t := v - float64(i)
+
+	a, b := heatmapGradient[i], heatmapGradient[i+1]
+
+	lerp := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t)
+	}
+
+	return color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: 255}
+}
+
+func newImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(imageBackground), image.Point{}, draw.Src)
+
+	return img
+}
+
+// fillRect blends col over the rectangle
+func fillRect(img *image.RGBA, x1, y1, x2, y2 int, col color.Color) {
+	draw.Draw(img, image.Rect(x1, y1, x2, y2), image.NewUniform(col), image.Point{}, draw.Over)
+}
+
+func drawRect(img *image.RGBA, x1, y1, x2, y2 int, col color.RGBA) {
+	drawLine(img, x1, y1, x2, y1, col)
+	drawLine(img, x2, y1, x2, y2, col)
+	drawLine(img, x2, y2, x1, y2, col)
+	drawLine(img, x1, y2, x1, y1, col)
+}
+
+// drawLine draws a line using Bresenham's algorithm
+func drawLine(img *image.RGBA, x1, y1, x2, y2 int, col color.RGBA) {
+	dx := abs(x2 - x1)
+	dy := -abs(y2 - y1)
+
+	sx, sy := 1, 1
+	if x1 > x2 {
+		sx = -1
+	}
+
+	if y1 > y2 {
+		sy = -1
+	}
+
+	err := dx + dy
+
+	for {
+		img.SetRGBA(x1, y1, col)
+
+		if x1 == x2 && y1 == y2 {
+			return
+		}
+
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x1 += sx
+		} else if e2 <= dx {
+			err += dx
+			y1 += sy
+		}
+	}
+}
+
+func drawCircle(img *image.RGBA, cx, cy, radius int, col color.RGBA) {
+	steps := max(16, radius*8)
+
+	for i := 0; i < steps; i++ {
+		angle := float64(i) / float64(steps) * 2 * math.Pi
+		img.SetRGBA(cx+int(math.Round(math.Cos(angle)*float64(radius))), cy+int(math.Round(math.Sin(angle)*float64(radius))), col)
+	}
+}
+
+func drawCross(img *image.RGBA, x, y, size int, col color.RGBA) {
+	drawLine(img, x-size, y-size, x+size, y+size, col)
+	drawLine(img, x-size, y+size, x+size, y-size, col)
+}
+
+// drawText draws text with its baseline at y
+func drawText(img *image.RGBA, x, y int, text string, col color.RGBA) {
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+
+	drawer.DrawString(text)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+func savePNG(img image.Image, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err = png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
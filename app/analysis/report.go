@@ -0,0 +1,117 @@
+package analysis
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/api"
+)
+
+type BeatmapInfo struct {
+	MD5        string `json:"md5"`
+	ID         int64  `json:"id"`
+	SetID      int64  `json:"set_id"`
+	Artist     string `json:"artist"`
+	Title      string `json:"title"`
+	Difficulty string `json:"difficulty"`
+	Creator    string `json:"creator"`
+}
+
+func newBeatmapInfo(beatMap *beatmap.BeatMap) BeatmapInfo {
+	return BeatmapInfo{
+		MD5:        beatMap.MD5,
+		ID:         beatMap.ID,
+		SetID:      beatMap.SetID,
+		Artist:     beatMap.Artist,
+		Title:      beatMap.Name,
+		Difficulty: beatMap.Difficulty,
+		Creator:    beatMap.Creator,
+	}
+}
+
+type PPInfo struct {
+	Aim        float64 `json:"aim"`
+	Speed      float64 `json:"speed"`
+	Acc        float64 `json:"acc"`
+	Flashlight float64 `json:"flashlight"`
+	Total      float64 `json:"total"`
+}
+
+func newPPInfo(results api.PPv2Results) PPInfo {
+	return PPInfo{
+		Aim:        results.Aim,
+		Speed:      results.Speed,
+		Acc:        results.Acc,
+		Flashlight: results.Flashlight,
+		Total:      results.Total,
+	}
+}
+
+type ScoreInfo struct {
+	Score        int64   `json:"score"`
+	Accuracy     float64 `json:"accuracy"`
+	Grade        string  `json:"grade"`
+	Combo        uint    `json:"combo"`
+	PerfectCombo bool    `json:"perfect_combo"`
+	Count300     uint    `json:"count_300"`
+	CountGeki    uint    `json:"count_geki"`
+	Count100     uint    `json:"count_100"`
+	CountKatu    uint    `json:"count_katu"`
+	Count50      uint    `json:"count_50"`
+	CountMiss    uint    `json:"count_miss"`
+	CountSB      uint    `json:"count_slider_breaks"`
+	SliderEnd    uint    `json:"slider_ends"`
+	MaxSliderEnd uint    `json:"max_slider_ends"`
+	PP           PPInfo  `json:"pp"`
+}
+
+func newScoreInfo(score osu.Score) ScoreInfo {
+	return ScoreInfo{
+		Score:        score.Score,
+		Accuracy:     score.Accuracy,
+		Grade:        score.Grade.String(),
+		Combo:        score.Combo,
+		PerfectCombo: score.PerfectCombo,
+		Count300:     score.Count300,
+		CountGeki:    score.CountGeki,
+		Count100:     score.Count100,
+		CountKatu:    score.CountKatu,
+		Count50:      score.Count50,
+		CountMiss:    score.CountMiss,
+		CountSB:      score.CountSB,
+		SliderEnd:    score.SliderEnd,
+		MaxSliderEnd: score.MaxSliderEnd,
+		PP:           newPPInfo(score.PP),
+	}
+}
+
+type Judgement struct {
+	Time   int64   `json:"time"`
+	Offset int64   `json:"offset"`
+	Object int64   `json:"object"`
+	Result string  `json:"result"`
+	Combo  string  `json:"combo"`
+	X      float32 `json:"x"`
+	Y      float32 `json:"y"`
+}
+
+// HitDelta is the timing error of a click on circle or slider head, the same value the hit error meter shows
+type HitDelta struct {
+	Object int64   `json:"object"`
+	Time   int64   `json:"time"`
+	Delta  float64 `json:"delta"`
+	Result string  `json:"result"`
+}
+
+type Report struct {
+	Beatmap      BeatmapInfo `json:"beatmap"`
+	Player       string      `json:"player"`
+	Mods         string      `json:"mods"`
+	Stars        float64     `json:"stars"`
+	Score        ScoreInfo   `json:"score"`
+	Failed       bool        `json:"failed"`
+	FCPP         PPInfo      `json:"fc_pp"`
+	SSPP         PPInfo      `json:"ss_pp"`
+	UnstableRate float64     `json:"unstable_rate"`
+	Judgements   []Judgement `json:"judgements"`
+	HitDeltas    []HitDelta  `json:"hit_deltas"`
+}
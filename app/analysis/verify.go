@@ -0,0 +1,327 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/rplpa"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxComboBreaks limits how many combo breaks are reported when simulated max combo is lower than the recorded one
+const maxComboBreaks = 10
+
+// Counts holds hit counts and max combo of a play
+type Counts struct {
+	Count300  uint `json:"count_300"`
+	Count100  uint `json:"count_100"`
+	Count50   uint `json:"count_50"`
+	CountMiss uint `json:"count_miss"`
+	MaxCombo  uint `json:"max_combo"`
+}
+
+// Divergence is a judgement at or before which the simulation provably differs from the recorded play
+type Divergence struct {
+	Object int64  `json:"object"`
+	Time   int64  `json:"time"`
+	Result string `json:"result"`
+	Reason string `json:"reason"`
+}
+
+// VerifyReport compares score recorded in a replay with the score danser simulated from its input
+type VerifyReport struct {
+	Replay      string       `json:"replay"`
+	Player      string       `json:"player"`
+	Beatmap     BeatmapInfo  `json:"beatmap"`
+	Mods        string       `json:"mods"`
+	Passed      bool         `json:"passed"`
+	Error       string       `json:"error,omitempty"`
+	Recorded    Counts       `json:"recorded"`
+	Simulated   Counts       `json:"simulated"`
+	Mismatches  []string     `json:"mismatches"`
+	Divergences []Divergence `json:"divergences"`
+}
+
+// CollectReplays returns all .osr files found in given paths. Directories are searched recursively.
+func CollectReplays(paths []string) ([]string, error) {
+	return collectFiles(paths, ".osr")
+}
+
+// ReplayMods returns mods the replay was played with. Lazer replays get LZ mod added, modsNew is nil if replay doesn't have lazer mod info.
+func ReplayMods(replay *rplpa.Replay) (mods difficulty.Modifier, modsNew []rplpa.ModInfo) {
+	mods = difficulty.Modifier(replay.Mods)
+
+	if replay.ScoreInfo != nil && replay.ScoreInfo.Mods != nil && len(replay.ScoreInfo.Mods) > 0 {
+		modsNew = make([]rplpa.ModInfo, 0, len(replay.ScoreInfo.Mods))
+
+		for _, mod := range replay.ScoreInfo.Mods {
+			modsNew = append(modsNew, *mod)
+		}
+	}
+
+	if replay.OsuVersion >= 30000000 { // Lazer is 1000 years in the future
+		mods |= difficulty.Lazer
+
+		if modsNew != nil {
+			modsNew = append(modsNew, rplpa.ModInfo{Acronym: "LZ"})
+		}
+	}
+
+	return
+}
+
+// parseReplayBeatMap finds the beatmap of the replay by MD5 and parses it with replay's mods. Beatmap has to be cleared after use.
+func parseReplayBeatMap(replay *rplpa.Replay, beatMaps []*beatmap.BeatMap) (*beatmap.BeatMap, error) {
+	var beatMap *beatmap.BeatMap
+
+	for _, b := range beatMaps {
+		if strings.EqualFold(b.MD5, replay.BeatmapMD5) {
+			beatMap = b
+			break
+		}
+	}
+
+	if beatMap == nil {
+		return nil, fmt.Errorf("beatmap with MD5 %s not found", replay.BeatmapMD5)
+	}
+
+	mods, modsNew := ReplayMods(replay)
+	if modsNew != nil {
+		beatMap.Diff.SetMods2(modsNew)
+	} else {
+		beatMap.Diff.SetMods(mods)
+	}
+
+	// Beatmap may have been simulated by a previous replay
+	beatMap.Clear()
+	beatMap.Pauses = nil
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, false, false)
+
+	return beatMap, nil
+}
+
+// VerifyReplays simulates every replay on its beatmap and compares the outcome with hit counts and max combo osu! saved in the replay.
+// Beatmaps are matched by MD5. Replays that can't be simulated get a report with Error set.
+func VerifyReplays(paths []string, beatMaps []*beatmap.BeatMap) []*VerifyReport {
+	reports := make([]*VerifyReport, 0, len(paths))
+
+	for _, path := range paths {
+		log.Println("Verifying:", path)
+
+		report, err := verifyReplay(path, beatMaps)
+		if err != nil {
+			log.Println(fmt.Sprintf("Failed to verify \"%s\": %s", path, err))
+
+			if report == nil {
+				report = &VerifyReport{Replay: path}
+			}
+
+			report.Error = err.Error()
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports
+}
+
+func verifyReplay(path string, beatMaps []*beatmap.BeatMap) (*VerifyReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	replay, err := rplpa.ParseReplay(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{
+		Replay: path,
+		Player: replay.Username,
+		Recorded: Counts{
+			Count300:  uint(replay.Count300),
+			Count100:  uint(replay.Count100),
+			Count50:   uint(replay.Count50),
+			CountMiss: uint(replay.CountMiss),
+			MaxCombo:  uint(replay.MaxCombo),
+		},
+		Mismatches:  make([]string, 0),
+		Divergences: make([]Divergence, 0),
+	}
+
+	if replay.PlayMode != rulesets.ModeOsu {
+		return report, errors.New("only osu!standard replays are supported")
+	}
+
+	if replay.ReplayData == nil || len(replay.ReplayData) < 2 {
+		return report, errors.New("replay is missing input data")
+	}
+
+	beatMap, err := parseReplayBeatMap(replay, beatMaps)
+	if err != nil {
+		return report, err
+	}
+
+	defer beatMap.Clear()
+
+	report.Beatmap = newBeatmapInfo(beatMap)
+
+	if len(beatMap.HitObjects) == 0 {
+		return report, errors.New("beatmap doesn't have any hit objects")
+	}
+
+	settings.REPLAY = path
+	settings.PLAYMODE = rulesets.ModeOsu
+
+	controller, err := loadReplay(beatMap)
+	if err != nil {
+		return report, err
+	}
+
+	ruleset := controller.GetRuleset()
+	cursor := controller.GetCursors()[0]
+
+	report.Mods = ruleset.GetPlayerDifficulty(cursor).GetModString()
+
+	expected := report.Recorded
+	exceeded := make(map[string]bool)
+	comboBreaks := make([]Divergence, 0)
+
+	ruleset.SetListener(func(_ *graphics.Cursor, result osu.JudgementResult, score osu.Score) {
+		// Once a simulated count is higher than the recorded one, this judgement or one before it is different from the real play
+		check := func(name string, simulated, recorded uint) {
+			if simulated > recorded && !exceeded[name] {
+				exceeded[name] = true
+
+				report.Divergences = append(report.Divergences, Divergence{
+					Object: result.Number,
+					Time:   result.Time,
+					Result: result.HitResult.String(),
+					Reason: fmt.Sprintf("%s exceeded recorded %d", name, recorded),
+				})
+			}
+		}
+
+		check("300s", score.Count300, expected.Count300)
+		check("100s", score.Count100, expected.Count100)
+		check("50s", score.Count50, expected.Count50)
+		check("misses", score.CountMiss, expected.CountMiss)
+		check("max combo", score.Combo, expected.MaxCombo)
+
+		if result.ComboResult == osu.Reset && len(comboBreaks) < maxComboBreaks {
+			comboBreaks = append(comboBreaks, Divergence{
+				Object: result.Number,
+				Time:   result.Time,
+				Result: result.HitResult.String(),
+				Reason: "combo break, simulated max combo is lower than recorded",
+			})
+		}
+	})
+
+	Simulate(controller)
+
+	score := ruleset.GetScore(cursor)
+
+	report.Simulated = Counts{
+		Count300:  score.Count300,
+		Count100:  score.Count100,
+		Count50:   score.Count50,
+		CountMiss: score.CountMiss,
+		MaxCombo:  score.Combo,
+	}
+
+	compare := func(name string, recorded, simulated uint) {
+		if recorded != simulated {
+			report.Mismatches = append(report.Mismatches, fmt.Sprintf("%s: recorded %d, simulated %d", name, recorded, simulated))
+		}
+	}
+
+	compare("300s", report.Recorded.Count300, report.Simulated.Count300)
+	compare("100s", report.Recorded.Count100, report.Simulated.Count100)
+	compare("50s", report.Recorded.Count50, report.Simulated.Count50)
+	compare("misses", report.Recorded.CountMiss, report.Simulated.CountMiss)
+	compare("max combo", report.Recorded.MaxCombo, report.Simulated.MaxCombo)
+
+	// A combo break that didn't happen in the real play can't be pinpointed, so all of them are suspects
+	if report.Simulated.MaxCombo < report.Recorded.MaxCombo {
+		report.Divergences = append(report.Divergences, comboBreaks...)
+
+		sort.SliceStable(report.Divergences, func(i, j int) bool {
+			return report.Divergences[i].Time < report.Divergences[j].Time
+		})
+	}
+
+	report.Passed = len(report.Mismatches) == 0
+
+	return report, nil
+}
+
+// WriteVerifySummary writes a human-readable pass/mismatch line for every report, followed by mismatched values and divergences
+func WriteVerifySummary(w io.Writer, reports []*VerifyReport) error {
+	passed := 0
+
+	for _, r := range reports {
+		status := "MISMATCH"
+
+		switch {
+		case r.Error != "":
+			status = "ERROR"
+		case r.Passed:
+			status = "PASS"
+			passed++
+		}
+
+		line := fmt.Sprintf("%-8s %s", status, r.Replay)
+		if r.Player != "" {
+			line += fmt.Sprintf(" (%s)", r.Player)
+		}
+
+		if r.Beatmap.MD5 != "" {
+			line += fmt.Sprintf(" %s - %s [%s]", r.Beatmap.Artist, r.Beatmap.Title, r.Beatmap.Difficulty)
+		}
+
+		if r.Mods != "" {
+			line += " +" + r.Mods
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+
+		if r.Error != "" {
+			if _, err := fmt.Fprintln(w, "\t"+r.Error); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		for _, m := range r.Mismatches {
+			if _, err := fmt.Fprintln(w, "\t"+m); err != nil {
+				return err
+			}
+		}
+
+		for _, d := range r.Divergences {
+			if _, err := fmt.Fprintf(w, "\tobject %d at %dms (%s): %s\n", d.Object, d.Time, d.Result, d.Reason); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d/%d replays passed\n", passed, len(reports))
+
+	return err
+}
@@ -7,14 +7,17 @@ import (
 	"fmt"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/wieku/danser-go/app/analysis"
 	"github.com/wieku/danser-go/app/audio"
 	"github.com/wieku/danser-go/app/beatmap"
 	difficulty2 "github.com/wieku/danser-go/app/beatmap/difficulty"
 	camera2 "github.com/wieku/danser-go/app/bmath/camera"
 	"github.com/wieku/danser-go/app/database"
 	"github.com/wieku/danser-go/app/discord"
+	"github.com/wieku/danser-go/app/export"
 	"github.com/wieku/danser-go/app/ffmpeg"
 	"github.com/wieku/danser-go/app/input"
+	"github.com/wieku/danser-go/app/rulesets"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/states"
 	"github.com/wieku/danser-go/app/utils"
@@ -39,6 +42,7 @@ import (
 	"log"
 	"math"
 	"os"
+	"os/exec"
 	"runtime"
 	"slices"
 	"strings"
@@ -75,6 +79,17 @@ var screenshotTime float64
 
 var preciseProgress bool
 
+var analyzeMode bool
+var exportMode bool
+var batchMode bool
+var verifyMode bool
+var checkMode bool
+var highlightMode bool
+var danceOsrMode bool
+var matchMode bool
+var imagesMode bool
+var anomalyMode bool
+
 var monitorHz int
 
 func run() {
@@ -107,7 +122,7 @@ func run() {
 		tag := flag.Int("tag", 1, "How many cursors should be \"playing\" specific map. 2 means that 1st cursor clicks the 1st object, 2nd clicks 2nd object, 1st clicks 3rd and so on")
 
 		knockout := flag.Bool("knockout", false, "Use (classic) knockout feature. Replays are sourced from \"replays/{a}\" where {a} is an md5 hash of .osu file. Danser automatically organizes replay files put directly in \"replays\", using maps' md5s provided by the replay files.")
-		knockout2 := flag.String("knockout2", "", "Use (new) knockout feature, JSON list of paths to compatible replay files has to be provided. \"Knockout.ExcludeMods\" and \"Knockout.MaxPlayers\" options are ignored, they have to be filtered beforehand.")
+		knockout2 := flag.String("knockout2", "", "Use (new) knockout feature, JSON list of paths to compatible replay files has to be provided. For team knockout, entries can be objects like {\"replay\": \"path.osr\", \"team\": \"Red\", \"color\": \"#ff0000\"}. \"Knockout.ExcludeMods\" and \"Knockout.MaxPlayers\" options are ignored, they have to be filtered beforehand.")
 
 		speed := flag.Float64("speed", 1.0, "Specify music's speed, set to 1.5 to have DoubleTime mod experience")
 		pitch := flag.Float64("pitch", 1.0, "Specify music's pitch, set to 1.5 with -speed=1.5 to have Nightcore mod experience")
@@ -149,29 +164,93 @@ func run() {
 
 		sPatch := flag.String("sPatch", "", "Patches the currently loaded settings")
 
+		analyze := flag.Bool("analyze", false, "Simulate the replay given by -replay without creating a window and print a JSON report with score, judgements and pp. If -out is specified, the report is saved to reports/{out}.json instead")
+
+		exportFlag := flag.Bool("export", false, "Save the beatmap rescaled to -speed and -pitch as a new beatmap set in Songs directory and exit. AR and OD are adjusted to keep the mod feel and clamped to 10, -ar/-od/-cs/-hp override them")
+
+		ppBatch := flag.String("ppbatch", "", "Calculate star rating and pp of all pp versions for given .osu file or directory, JSON list of them can be provided too. Results are printed as JSON, or saved to reports/{out} if -out is specified")
+		ppMods := flag.String("ppmods", "NM", "Comma separated list of mod combinations for -ppbatch, e.g. NM,HD,HR,HDDT")
+		ppAcc := flag.Float64("ppacc", 100, "Accuracy in percent used for -ppbatch pp calculation next to SS")
+		ppMisses := flag.Int("ppmisses", 0, "Miss count used for -ppbatch pp calculation next to SS")
+		ppCSV := flag.Bool("ppcsv", false, "Output -ppbatch results as CSV instead of JSON")
+
+		remoteAddr := flag.String("remote", "", "Start a local HTTP/JSON API on given address, e.g. 127.0.0.1:9045, that exposes player's state and accepts commands like pause, seek, speed change, loading another map or replay and settings patches. Only loopback addresses are allowed unless -remotetoken is set, replays can be loaded only from songs and replays directories. Not available in record, screenshot and play modes")
+		remoteToken := flag.String("remotetoken", "", "Token required in \"Authorization: Bearer <token>\" header of every -remote request. Required if -remote listens on a non-loopback address")
+
+		verify := flag.String("verify", "", "Simulate given .osr file or all replays in given directory, JSON list of them can be provided too, and check if hit counts and max combo match the ones osu! recorded. Prints a pass/mismatch report and exits with code 1 if any replay doesn't match. Full report is saved to reports/{out}.json if -out is specified")
+
+		check := flag.String("check", "", "Run beatmap quality checks on given .osu file or all beatmaps in given directory, JSON list of them can be provided too. Difficulties in the same directory are checked as one set. Prints a table of issues and exits with code 1 if any error is found. Full report is saved to reports/{out}.json if -out is specified")
+		checkJSON := flag.Bool("checkjson", false, "Print -check report as JSON instead of a table")
+
+		highlights := flag.Int("highlights", 0, "Find given number of best non-overlapping clips in the beatmap, using the replay given by -replay if specified, and print suggested -start/-end pairs as JSON. If -out is specified, the report is saved to reports/{out}.json instead")
+		clipLength := flag.Float64("cliplen", 30, "Length of -highlights clips in seconds")
+		highlightBy := flag.String("highlightby", analysis.HighlightAuto, "How -highlights clips are ranked: "+strings.Join(analysis.HighlightCriteria, ", ")+". combo and pp need a replay")
+		renderClips := flag.Bool("renderclips", false, "Record every clip found by -highlights as a separate video. If -out is specified, clips are named {out}_1, {out}_2...")
+
+		saveOsr := flag.Bool("saveosr", false, "Simulate cursordance on the beatmap without creating a window and save input of every cursor as osu!stable replay in Gameplay.PlayReplaysDir. Score in the replay is calculated by simulating it. If -out is specified, it's used as the file name")
+
+		matchFile := flag.String("match", "", "Record a multi-map tournament match described by given JSON file: {\"name\": \"...\", \"scoring\": \"win\" or \"cumulative\", \"intermission\": 5, \"standings_time\": 10, \"maps\": [{\"name\": \"NM1\", \"replays\": [...]}]}. Replay lists have the same format as in -knockout2, relative paths are resolved against the match file. Maps are played back to back as knockouts with intermission cards and final standings. Implies -record")
+
+		replayImages := flag.String("replayimages", "", "Simulate given .osr file or all replays in given directory, JSON list of them can be provided too, and save cursor heatmap, hit position distribution and hit offset histogram of each replay as PNG images next to it. If -out is specified, images are saved to reports/{out}_*.png instead. Prints a JSON list of saved files")
+
+		anomalies := flag.String("anomalies", "", "Simulate given .osr file or all replays in given directory, JSON list of them can be provided too, and check input for statistical anomalies: timewarp and irregular frame times, unnaturally low unstable rate, cursor snapping to circle centres and identical key press durations. Prints every metric with its threshold and exits with code 1 if any replay is flagged. Full report is saved to reports/{out}.json if -out is specified")
+
 		flag.Parse()
 
+		analyzeMode = *analyze
+		exportMode = *exportFlag
+		batchMode = *ppBatch != ""
+		verifyMode = *verify != ""
+		checkMode = *check != ""
+		highlightMode = *highlights > 0
+		danceOsrMode = *saveOsr
+		matchMode = *matchFile != ""
+		imagesMode = *replayImages != ""
+		anomalyMode = *anomalies != ""
+
+		if ((analyzeMode || batchMode || highlightMode) && *out == "") || verifyMode || checkMode || imagesMode || anomalyMode {
+			platform.RedirectLogsToStderr()
+		}
+
 		if *mods != "" && *mods2 != "" {
 			panic("You can't specify classic and lazer mods at the same time")
 		}
 
 		var knockoutReplays []string
+		var knockoutTeams []*settings.KnockoutTeam
 
 		if *knockout2 != "" {
-			if err := json.Unmarshal([]byte(*knockout2), &knockoutReplays); err != nil {
+			var err error
+
+			if knockoutReplays, knockoutTeams, err = settings.ParseKnockoutReplays(*knockout2); err != nil {
 				panic(fmt.Sprintf("Failed to parse replay list: %s", err))
 			}
 
 			*knockout = true
 		}
 
+		var matchData *settings.Match
+		var matchPicks []*states.MatchPick
+
+		if matchMode {
+			var err error
+
+			if matchData, err = settings.LoadMatch(*matchFile); err != nil {
+				panic(fmt.Sprintf("Failed to load the match: %s", err))
+			}
+		}
+
 		if !*noUpdCheck {
 			checkForUpdates()
 		}
 
+		if matchMode {
+			*record = true
+		}
+
 		if *out != "" {
 			output = *out
-			if math.IsNaN(*ss) {
+			if math.IsNaN(*ss) && !analyzeMode && !batchMode && !verifyMode && !checkMode && !highlightMode && !danceOsrMode && !imagesMode && !anomalyMode {
 				*record = true
 			}
 		}
@@ -192,6 +271,32 @@ func run() {
 			panic("Incompatible flags selected: -ss, -play")
 		} else if screenshotMode && recordMode {
 			panic("Incompatible flags selected: -ss, -record")
+		} else if analyzeMode && *replay == "" {
+			panic("-analyze needs a replay specified by -replay")
+		} else if analyzeMode && (*record || screenshotMode) {
+			panic("Incompatible flags selected: -analyze, -record/-ss")
+		} else if exportMode && (analyzeMode || *play || *record || screenshotMode || *replay != "" || *knockout) {
+			panic("Incompatible flags selected: -export, -analyze/-play/-record/-ss/-replay/-knockout")
+		} else if batchMode && (analyzeMode || exportMode || *play || *record || screenshotMode || *replay != "" || *knockout) {
+			panic("Incompatible flags selected: -ppbatch, -analyze/-export/-play/-record/-ss/-replay/-knockout")
+		} else if verifyMode && (analyzeMode || exportMode || batchMode || *play || *record || screenshotMode || *replay != "" || *knockout) {
+			panic("Incompatible flags selected: -verify, -analyze/-export/-ppbatch/-play/-record/-ss/-replay/-knockout")
+		} else if checkMode && (analyzeMode || exportMode || batchMode || verifyMode || *play || *record || screenshotMode || *replay != "" || *knockout) {
+			panic("Incompatible flags selected: -check, -analyze/-export/-ppbatch/-verify/-play/-record/-ss/-replay/-knockout")
+		} else if highlightMode && (analyzeMode || exportMode || batchMode || verifyMode || checkMode || *play || *record || screenshotMode || *knockout) {
+			panic("Incompatible flags selected: -highlights, -analyze/-export/-ppbatch/-verify/-check/-play/-record/-ss/-knockout")
+		} else if highlightMode && *clipLength <= 0 {
+			panic("-cliplen has to be positive")
+		} else if danceOsrMode && (analyzeMode || exportMode || batchMode || verifyMode || checkMode || highlightMode || *play || *record || screenshotMode || *replay != "" || *knockout) {
+			panic("Incompatible flags selected: -saveosr, -analyze/-export/-ppbatch/-verify/-check/-highlights/-play/-record/-ss/-replay/-knockout")
+		} else if matchMode && (analyzeMode || exportMode || batchMode || verifyMode || checkMode || highlightMode || danceOsrMode || *play || screenshotMode || *replay != "" || *knockout || *remoteAddr != "") {
+			panic("Incompatible flags selected: -match, -analyze/-export/-ppbatch/-verify/-check/-highlights/-saveosr/-play/-ss/-replay/-knockout/-remote")
+		} else if imagesMode && (analyzeMode || exportMode || batchMode || verifyMode || checkMode || highlightMode || danceOsrMode || matchMode || *play || *record || screenshotMode || *replay != "" || *knockout) {
+			panic("Incompatible flags selected: -replayimages, -analyze/-export/-ppbatch/-verify/-check/-highlights/-saveosr/-match/-play/-record/-ss/-replay/-knockout")
+		} else if anomalyMode && (analyzeMode || exportMode || batchMode || verifyMode || checkMode || highlightMode || danceOsrMode || matchMode || imagesMode || *play || *record || screenshotMode || *replay != "" || *knockout) {
+			panic("Incompatible flags selected: -anomalies, -analyze/-export/-ppbatch/-verify/-check/-highlights/-saveosr/-match/-replayimages/-play/-record/-ss/-replay/-knockout")
+		} else if *remoteAddr != "" && (analyzeMode || exportMode || batchMode || verifyMode || checkMode || highlightMode || danceOsrMode || imagesMode || anomalyMode || *play || *record || screenshotMode) {
+			panic("Incompatible flags selected: -remote, -analyze/-export/-ppbatch/-verify/-check/-highlights/-saveosr/-replayimages/-anomalies/-play/-record/-ss")
 		}
 
 		modsParsed := difficulty2.ParseMods(*mods)
@@ -208,33 +313,19 @@ func run() {
 				panic(err)
 			}
 
-			if rp.PlayMode != 0 {
-				panic("Modes other than osu!standard are not supported")
+			if !rulesets.IsModeSupported(int64(rp.PlayMode)) {
+				panic("Modes other than osu!standard and osu!taiko are not supported")
 			}
 
+			settings.PLAYMODE = int64(rp.PlayMode)
+
 			if rp.ReplayData == nil || len(rp.ReplayData) < 2 {
 				panic("Replay is missing input data")
 			}
 
 			*md5 = rp.BeatmapMD5
 			*id = -1
-			modsParsed = difficulty2.Modifier(rp.Mods)
-
-			if rp.ScoreInfo != nil && rp.ScoreInfo.Mods != nil && len(rp.ScoreInfo.Mods) > 0 {
-				modsNew = make([]rplpa.ModInfo, 0, len(rp.ScoreInfo.Mods))
-
-				for _, mod := range rp.ScoreInfo.Mods {
-					modsNew = append(modsNew, *mod)
-				}
-			}
-
-			if rp.OsuVersion >= 30000000 { // Lazer is 1000 years in the future
-				modsParsed |= difficulty2.Lazer
-
-				if modsNew != nil {
-					modsNew = append(modsNew, rplpa.ModInfo{Acronym: "LZ"})
-				}
-			}
+			modsParsed, modsNew = analysis.ReplayMods(rp)
 
 			*knockout = true
 			settings.REPLAY = *replay
@@ -262,14 +353,15 @@ func run() {
 
 		closeAfterSettingsLoad := false
 
-		if (*md5+*artist+*title+*difficulty+*creator) == "" && *id < 0 {
+		if (*md5+*artist+*title+*difficulty+*creator) == "" && *id < 0 && !batchMode && !verifyMode && !checkMode && !matchMode && !imagesMode && !anomalyMode {
 			log.Println("No beatmap specified, closing...")
 			closeAfterSettingsLoad = true
 		}
 
 		settings.DEBUG = *debug
-		settings.KNOCKOUT = *knockout
+		settings.KNOCKOUT = *knockout || matchMode
 		settings.KNOCKOUTREPLAYS = knockoutReplays
+		settings.KNOCKOUTTEAMS = knockoutTeams
 		settings.PLAY = *play
 		settings.DIVIDES = *cursors
 		settings.TAG = *tag
@@ -280,6 +372,7 @@ func run() {
 		settings.END = *end
 		settings.RECORD = recordMode || screenshotMode
 		settings.LOCALOFFSET = *offset
+		settings.HEADLESS = analyzeMode || exportMode || batchMode || verifyMode || checkMode || highlightMode || danceOsrMode || imagesMode || anomalyMode
 
 		if *settingsVersion == "credentials" || *settingsVersion == "launcher" {
 			panic(fmt.Sprintf("flag -settings: name \"%s\" is forbidden", *settingsVersion))
@@ -298,6 +391,71 @@ func run() {
 			closeAfterSettingsLoad = true
 		}
 
+		if batchMode {
+			if newSettings {
+				settings.JsonPatch = *sPatch
+				settings.LoadPatch()
+			}
+
+			if !closeAfterSettingsLoad {
+				runBatch(*ppBatch, *ppMods, *ppAcc, *ppMisses, *ppCSV)
+			}
+
+			return
+		}
+
+		if verifyMode {
+			if newSettings {
+				settings.JsonPatch = *sPatch
+				settings.LoadPatch()
+			}
+
+			if !closeAfterSettingsLoad {
+				runVerify(*verify, *noDbCheck)
+			}
+
+			return
+		}
+
+		if checkMode {
+			if newSettings {
+				settings.JsonPatch = *sPatch
+				settings.LoadPatch()
+			}
+
+			if !closeAfterSettingsLoad {
+				runCheck(*check, *checkJSON)
+			}
+
+			return
+		}
+
+		if imagesMode {
+			if newSettings {
+				settings.JsonPatch = *sPatch
+				settings.LoadPatch()
+			}
+
+			if !closeAfterSettingsLoad {
+				runReplayImages(*replayImages, *noDbCheck)
+			}
+
+			return
+		}
+
+		if anomalyMode {
+			if newSettings {
+				settings.JsonPatch = *sPatch
+				settings.LoadPatch()
+			}
+
+			if !closeAfterSettingsLoad {
+				runAnomalies(*anomalies, *noDbCheck)
+			}
+
+			return
+		}
+
 		player = nil
 		var beatMap *beatmap.BeatMap = nil
 
@@ -308,7 +466,17 @@ func run() {
 			} else {
 				beatmaps := database.LoadBeatmaps(*noDbCheck, nil)
 
-				if *id > -1 {
+				if *remoteAddr != "" {
+					remoteBeatmaps = beatmaps
+				}
+
+				if matchMode {
+					if matchPicks, err = resolveMatchPicks(matchData, beatmaps); err != nil {
+						log.Println("Failed to load the match:", err)
+					} else {
+						beatMap = matchPicks[0].BeatMap
+					}
+				} else if *id > -1 {
 					for _, b := range beatmaps {
 						if b.ID == *id {
 							beatMap = b
@@ -363,6 +531,58 @@ func run() {
 			database.Close()
 		}
 
+		if analyzeMode {
+			if newSettings {
+				settings.JsonPatch = *sPatch
+				settings.LoadPatch()
+			}
+
+			if !closeAfterSettingsLoad {
+				runAnalysis(beatMap, modsParsed, modsNew)
+			}
+
+			return
+		}
+
+		if highlightMode {
+			if newSettings {
+				settings.JsonPatch = *sPatch
+				settings.LoadPatch()
+			}
+
+			if !closeAfterSettingsLoad {
+				runHighlights(beatMap, modsParsed, modsNew, *highlights, *clipLength, *highlightBy, *renderClips)
+			}
+
+			return
+		}
+
+		if exportMode {
+			if newSettings {
+				settings.JsonPatch = *sPatch
+				settings.LoadPatch()
+			}
+
+			if !closeAfterSettingsLoad {
+				runExport(beatMap, *speed, *pitch, *ar, *od, *cs, *hp)
+			}
+
+			return
+		}
+
+		if danceOsrMode {
+			if newSettings {
+				settings.JsonPatch = *sPatch
+				settings.LoadPatch()
+			}
+
+			if !closeAfterSettingsLoad {
+				runDanceOsr(beatMap, modsParsed, modsNew)
+			}
+
+			return
+		}
+
 		assets.Init(build.Stream == "Dev")
 
 		if !closeAfterSettingsLoad {
@@ -466,7 +686,12 @@ func run() {
 			})
 		}
 
-		win.SetTitle("danser " + build.VERSION + " - " + beatMap.Artist + " - " + beatMap.Name + " [" + beatMap.Difficulty + "]")
+		if matchMode {
+			win.SetTitle("danser " + build.VERSION + " - " + matchData.Name)
+		} else {
+			win.SetTitle("danser " + build.VERSION + " - " + beatMap.Artist + " - " + beatMap.Name + " [" + beatMap.Difficulty + "]")
+		}
+
 		input.Win = win
 
 		if cTime := time.Now(); cTime.Month() == 12 && cTime.Day() >= 6 {
@@ -592,20 +817,32 @@ func run() {
 			}
 		}
 
-		if modsNew != nil {
-			beatMap.Diff.SetMods2(modsNew)
+		if matchMode {
+			player = states.NewMatch(matchData, matchPicks)
 		} else {
-			beatMap.Diff.SetMods(modsParsed)
-		}
+			if modsNew != nil {
+				beatMap.Diff.SetMods2(modsNew)
+			} else {
+				beatMap.Diff.SetMods(modsParsed)
+			}
 
-		beatmap.ParseTimingPointsAndPauses(beatMap)
-		beatmap.ParseObjects(beatMap, false, true)
-		beatMap.LoadCustomSamples()
-		player = states.NewPlayer(beatMap)
+			beatmap.ParseTimingPointsAndPauses(beatMap)
+			beatmap.ParseObjects(beatMap, false, true)
+			beatMap.LoadCustomSamples()
+			player = states.NewPlayer(beatMap)
+		}
 
 		limiter = frame.NewLimiter(int(settings.Graphics.FPSCap))
+
+		if *remoteAddr != "" {
+			startRemote(*remoteAddr, *remoteToken)
+		}
 	})
 
+	if analyzeMode || exportMode || batchMode || verifyMode || checkMode || highlightMode || danceOsrMode || imagesMode || anomalyMode {
+		return
+	}
+
 	if recordMode {
 		mainLoopRecord()
 	} else if screenshotMode {
@@ -615,6 +852,303 @@ func run() {
 	}
 }
 
+func runAnalysis(beatMap *beatmap.BeatMap, modsParsed difficulty2.Modifier, modsNew []rplpa.ModInfo) {
+	if modsNew != nil {
+		beatMap.Diff.SetMods2(modsNew)
+	} else {
+		beatMap.Diff.SetMods(modsParsed)
+	}
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, false, false)
+
+	report, err := analysis.Analyze(beatMap)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = analysis.SaveJSON(report, output); err != nil {
+		panic(err)
+	}
+}
+
+func runHighlights(beatMap *beatmap.BeatMap, modsParsed difficulty2.Modifier, modsNew []rplpa.ModInfo, count int, length float64, criteria string, render bool) {
+	if modsNew != nil {
+		beatMap.Diff.SetMods2(modsNew)
+	} else {
+		beatMap.Diff.SetMods(modsParsed)
+	}
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, false, false)
+
+	report, err := analysis.FindHighlights(beatMap, count, length, criteria)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = analysis.SaveJSON(report, output); err != nil {
+		panic(err)
+	}
+
+	if render {
+		renderHighlights(report)
+	}
+}
+
+// highlightFlags are removed from arguments passed to danser instances recording highlight clips
+var highlightFlags = []string{"highlights", "cliplen", "highlightby", "renderclips", "out", "start", "end", "skip", "record", "ss", "play"}
+
+// renderHighlights records every highlight by running danser again with the same arguments and -start/-end of the clip
+func renderHighlights(report *analysis.HighlightReport) {
+	executable, err := os.Executable()
+	if err != nil {
+		log.Println("Failed to find danser's executable, clips won't be recorded:", err)
+		return
+	}
+
+	arguments := make([]string, 0, len(os.Args))
+
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+
+		if !strings.HasPrefix(arg, "-") || !slices.Contains(highlightFlags, name) {
+			arguments = append(arguments, arg)
+			continue
+		}
+
+		// Skip the value of non-boolean flags given as separate argument
+		if f := flag.Lookup(name); f != nil && !hasValue {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				i++
+			}
+		}
+	}
+
+	for i, h := range report.Highlights {
+		clipArgs := append(slices.Clone(arguments), "-record", fmt.Sprintf("-start=%.3f", h.Start), fmt.Sprintf("-end=%.3f", h.End))
+
+		if output != "" {
+			clipArgs = append(clipArgs, fmt.Sprintf("-out=%s_%d", output, i+1))
+		}
+
+		log.Println(fmt.Sprintf("Recording clip %d/%d (%.1fs - %.1fs)...", i+1, len(report.Highlights), h.Start, h.End))
+
+		cmd := exec.Command(executable, clipArgs...)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			log.Println(fmt.Sprintf("Failed to record clip %d: %s", i+1, err))
+		}
+	}
+}
+
+func runExport(beatMap *beatmap.BeatMap, speed, pitch, ar, od, cs, hp float64) {
+	// Spinners have to be kept in the exported map
+	settings.Objects.LoadSpinners = true
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, false, false)
+
+	bass.Init(true)
+
+	path, err := export.RateChange(beatMap, speed, pitch, ar, od, cs, hp)
+	if err != nil {
+		panic(err)
+	}
+
+	log.Println("Beatmap exported to:", path)
+}
+
+func runDanceOsr(beatMap *beatmap.BeatMap, modsParsed difficulty2.Modifier, modsNew []rplpa.ModInfo) {
+	if modsNew != nil {
+		beatMap.Diff.SetMods2(modsNew)
+	} else {
+		beatMap.Diff.SetMods(modsParsed)
+	}
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, false, false)
+
+	// Saved replays are scored the same way as in -replay mode
+	settings.KNOCKOUT = true
+
+	if _, err := analysis.ExportDance(beatMap, settings.Gameplay.GetPlayReplaysDir(), output); err != nil {
+		panic(err)
+	}
+}
+
+func runBatch(paths, mods string, accuracy float64, misses int, csv bool) {
+	var pathList []string
+
+	if strings.HasPrefix(strings.TrimSpace(paths), "[") {
+		if err := json.Unmarshal([]byte(paths), &pathList); err != nil {
+			panic(fmt.Sprintf("Failed to parse beatmap list: %s", err))
+		}
+	} else {
+		pathList = []string{paths}
+	}
+
+	var modCombinations []difficulty2.Modifier
+
+	for _, combination := range strings.Split(mods, ",") {
+		modCombinations = append(modCombinations, difficulty2.ParseMods(strings.ToUpper(strings.TrimSpace(combination))))
+	}
+
+	// Spinners count towards object count in pp calculations
+	settings.Objects.LoadSpinners = true
+
+	beatMaps, err := analysis.CollectBeatmaps(pathList)
+	if err != nil {
+		panic(err)
+	}
+
+	entries := analysis.CalculateBatch(beatMaps, modCombinations, accuracy/100, misses)
+
+	if csv {
+		err = analysis.SaveCSV(entries, output)
+	} else {
+		err = analysis.SaveJSON(entries, output)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// loadReplaysForAnalysis collects replays from a path, a directory or a JSON list of them and loads all beatmaps from the database
+func loadReplaysForAnalysis(paths string, noDbCheck bool) ([]string, []*beatmap.BeatMap) {
+	var pathList []string
+
+	if strings.HasPrefix(strings.TrimSpace(paths), "[") {
+		if err := json.Unmarshal([]byte(paths), &pathList); err != nil {
+			panic(fmt.Sprintf("Failed to parse replay list: %s", err))
+		}
+	} else {
+		pathList = []string{paths}
+	}
+
+	replays, err := analysis.CollectReplays(pathList)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = database.Init(); err != nil {
+		panic(fmt.Sprintf("Failed to initialize database: %s", err))
+	}
+
+	beatMaps := database.LoadBeatmaps(noDbCheck, nil)
+
+	database.Close()
+
+	// Replays are simulated the same way as in -replay mode
+	settings.KNOCKOUT = true
+
+	return replays, beatMaps
+}
+
+func runVerify(paths string, noDbCheck bool) {
+	replays, beatMaps := loadReplaysForAnalysis(paths, noDbCheck)
+
+	reports := analysis.VerifyReplays(replays, beatMaps)
+
+	if err := analysis.WriteVerifySummary(os.Stdout, reports); err != nil {
+		panic(err)
+	}
+
+	if output != "" {
+		if err := analysis.SaveJSON(reports, output); err != nil {
+			panic(err)
+		}
+	}
+
+	for _, r := range reports {
+		if !r.Passed {
+			log.Println("Some replays don't match, exiting with code 1")
+			os.Exit(1)
+		}
+	}
+}
+
+func runReplayImages(paths string, noDbCheck bool) {
+	replays, beatMaps := loadReplaysForAnalysis(paths, noDbCheck)
+
+	reports := analysis.ExportReplayImages(replays, beatMaps, output)
+
+	if err := analysis.SaveJSON(reports, ""); err != nil {
+		panic(err)
+	}
+}
+
+func runAnomalies(paths string, noDbCheck bool) {
+	replays, beatMaps := loadReplaysForAnalysis(paths, noDbCheck)
+
+	reports := analysis.AnalyzeAnomalies(replays, beatMaps)
+
+	if err := analysis.WriteAnomalySummary(os.Stdout, reports); err != nil {
+		panic(err)
+	}
+
+	if output != "" {
+		if err := analysis.SaveJSON(reports, output); err != nil {
+			panic(err)
+		}
+	}
+
+	for _, r := range reports {
+		if r.Flagged {
+			log.Println("Some replays were flagged, exiting with code 1")
+			os.Exit(1)
+		}
+	}
+}
+
+func runCheck(paths string, asJSON bool) {
+	var pathList []string
+
+	if strings.HasPrefix(strings.TrimSpace(paths), "[") {
+		if err := json.Unmarshal([]byte(paths), &pathList); err != nil {
+			panic(fmt.Sprintf("Failed to parse beatmap list: %s", err))
+		}
+	} else {
+		pathList = []string{paths}
+	}
+
+	// Spinners are checked for snapping too
+	settings.Objects.LoadSpinners = true
+
+	beatMaps, err := analysis.CollectBeatmaps(pathList)
+	if err != nil {
+		panic(err)
+	}
+
+	reports := analysis.CheckBeatmaps(beatMaps)
+
+	if asJSON {
+		err = analysis.SaveJSON(reports, output)
+	} else {
+		err = analysis.WriteCheckTable(os.Stdout, reports)
+
+		if err == nil && output != "" {
+			err = analysis.SaveJSON(reports, output)
+		}
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, r := range reports {
+		if r.HasErrors() {
+			log.Println("Errors found in beatmaps, exiting with code 1")
+			os.Exit(1)
+		}
+	}
+}
+
 func mainLoopRecord() {
 	count := int64(0)
 
@@ -643,7 +1177,7 @@ func mainLoopRecord() {
 	deltaSumF := fpsDelta
 	deltaSumA := 0.0
 
-	p, _ := player.(*states.Player)
+	p, _ := player.(states.Recordable)
 
 	lastCount := int64(0)
 	lastRealTime := qpc.GetMilliTimeF()
@@ -680,12 +1214,13 @@ func mainLoopRecord() {
 				count++
 
 				timeOffset := p.GetTimeOffset()
-				progress = int(math.Round(timeOffset / p.RunningTime * 100))
+				runningTime := p.GetRunningTime()
+				progress = int(math.Round(timeOffset / runningTime * 100))
 
 				if (preciseProgress || progress%5 == 0) && lastProgress != progress {
 					speed := float64(count-lastCount) * (1000 / fps) / (qpc.GetMilliTimeF() - lastRealTime)
 
-					eta := int((p.RunningTime - timeOffset) / 1000 / speed)
+					eta := int((runningTime - timeOffset) / 1000 / speed)
 
 					etaText := util.FormatSeconds(eta)
 
@@ -822,6 +1357,8 @@ func mainLoopNormal() {
 		profiler.EndGroup()
 	})
 
+	stopRemote()
+
 	settings.CloseWatcher()
 }
 
@@ -35,6 +35,17 @@ func AddListener(function func(sampleSet int, hitsoundIndex, index int, volume f
 	listeners = append(listeners, function)
 }
 
+var hitListeners = make([]func(sampleSet, additionSet, hitsound, index int), 0)
+
+// AddHitListener adds a function called once for every hit played by PlaySample, with its sample sets already resolved
+func AddHitListener(function func(sampleSet, additionSet, hitsound, index int)) {
+	hitListeners = append(hitListeners, function)
+}
+
+func ClearHitListeners() {
+	hitListeners = hitListeners[:0]
+}
+
 func LoadSamples() {
 	Samples[0][0] = LoadSample("normal-hitnormal")
 	Samples[0][1] = LoadSample("normal-hitwhistle")
@@ -68,6 +79,10 @@ func PlaySample(sampleSet, additionSet, hitsound, index int, volume float64, obj
 
 	volume = max(volume, 0.08)
 
+	for _, f := range hitListeners {
+		f(resolveSampleSet(sampleSet), resolveSampleSet(additionSet), hitsound, index)
+	}
+
 	// Play normal
 	if skin.GetInfo().LayeredHitSounds || hitsound&1 > 0 || hitsound == 0 {
 		playSample(sampleSet, 0, index, volume*0.8, objNum, xPos)
@@ -99,11 +114,7 @@ func playSample(sampleSet int, hitsoundIndex, index int, volume float64, objNum
 		volume = 1.0
 	}
 
-	if sampleSet == 0 {
-		sampleSet = 2
-	} else if sampleSet < 0 || sampleSet > 3 {
-		sampleSet = 1
-	}
+	sampleSet = resolveSampleSet(sampleSet)
 
 	for _, f := range listeners {
 		f(sampleSet, hitsoundIndex, index, volume, objNum)
@@ -116,6 +127,16 @@ func playSample(sampleSet int, hitsoundIndex, index int, volume float64, objNum
 	}
 }
 
+func resolveSampleSet(sampleSet int) int {
+	if sampleSet == 0 {
+		return 2
+	} else if sampleSet < 0 || sampleSet > 3 {
+		return 1
+	}
+
+	return sampleSet
+}
+
 var whistleChannel *bass.SampleChannel = nil
 var slideChannel *bass.SampleChannel = nil
 var lastSampleSet = 0
@@ -178,11 +199,7 @@ func playSampleLoop(sampleSet int, hitsoundIndex, index int, volume float64, obj
 		volume = 1.0
 	}
 
-	if sampleSet == 0 {
-		sampleSet = 2
-	} else if sampleSet < 0 || sampleSet > 3 {
-		sampleSet = 1
-	}
+	sampleSet = resolveSampleSet(sampleSet)
 
 	for _, f := range listeners {
 		f(sampleSet, hitsoundIndex, index, volume, objNum)
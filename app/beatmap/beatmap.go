@@ -7,6 +7,7 @@ import (
 	"github.com/wieku/danser-go/app/beatmap/objects"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/framework/files"
+	color2 "github.com/wieku/danser-go/framework/math/color"
 	"math"
 	"path/filepath"
 	"slices"
@@ -36,8 +37,11 @@ type BeatMap struct {
 	File  string
 	Audio string
 	Bg    string
+	Video string
 	MD5   string
 
+	VideoOffset float64
+
 	SetID int64
 	ID    int64
 
@@ -63,6 +67,16 @@ type BeatMap struct {
 
 	ARSpecified bool
 
+	ComboColors         []color2.Color
+	SliderBorder        *color2.Color
+	SliderTrackOverride *color2.Color
+
+	AudioLeadIn int64
+
+	// [General] settings and [Events] storyboard lines danser doesn't use, they're kept only to be saved back by the beatmap writer
+	GeneralExtra     []string
+	StoryboardEvents []string
+
 	LocalOffset int
 
 	pathCache *files.FileMap
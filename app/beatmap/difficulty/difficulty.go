@@ -66,6 +66,10 @@ type Difficulty struct {
 	modSettings map[reflect.Type]any
 	adjustPitch bool
 
+	rampStart float64
+	rampEnd   float64
+	adaptive  *AdaptiveRate
+
 	DiffCalcMode bool
 }
 
@@ -164,6 +168,23 @@ func (diff *Difficulty) calculate() {
 		diff.adjustPitch = s.AdjustPitch
 	}
 
+	// Variable rate mods use initial rate for difficulty calculation, like lazer does
+	if s, ok := diff.modSettings[rfType[TimeRampSettings]()].(TimeRampSettings); ok {
+		diff.Speed = s.InitialRate
+		diff.adjustPitch = s.AdjustPitch
+	}
+
+	if s, ok := diff.modSettings[rfType[AdaptiveSpeedSettings]()].(AdaptiveSpeedSettings); ok {
+		diff.Speed = s.InitialRate
+		diff.adjustPitch = s.AdjustPitch
+
+		if diff.adaptive == nil || diff.adaptive.GetInitialRate() != s.InitialRate {
+			diff.adaptive = NewAdaptiveRate(s.InitialRate)
+		}
+	} else {
+		diff.adaptive = nil
+	}
+
 	diff.ARReal = DiffFromRate(diff.GetModifiedTime(diff.PreemptU), 1800, 1200, 450)
 	diff.ODReal = (80 - diff.GetModifiedTime(diff.Hit300U)) / 6 //DiffFromRate(diff.GetModifiedTime(diff.Hit300U), 80, 50, 20)
 }
@@ -213,6 +234,30 @@ func (diff *Difficulty) AddMod(mods Modifier) {
 		diff.modSettings[rfType[MirrorSettings]()] = NewMirrorSettings()
 	}
 
+	if mods.Active(Random) {
+		diff.modSettings[rfType[RandomSettings]()] = NewRandomSettings()
+	}
+
+	if mods.Active(Wiggle) {
+		diff.modSettings[rfType[WiggleSettings]()] = NewWiggleSettings()
+	}
+
+	if mods.Active(Grow | Deflate) {
+		diff.modSettings[rfType[ScaleTweenSettings]()] = NewScaleTweenSettings(mods.Active(Grow))
+	}
+
+	if mods.Active(BarrelRoll) {
+		diff.modSettings[rfType[BarrelRollSettings]()] = NewBarrelRollSettings()
+	}
+
+	if mods.Active(WindUp | WindDown) {
+		diff.modSettings[rfType[TimeRampSettings]()] = NewTimeRampSettings(mods.Active(WindUp))
+	}
+
+	if mods.Active(AdaptiveSpeed) {
+		diff.modSettings[rfType[AdaptiveSpeedSettings]()] = NewAdaptiveSpeedSettings()
+	}
+
 	diff.calculate()
 }
 
@@ -255,6 +300,30 @@ func (diff *Difficulty) RemoveMod(mods Modifier) {
 		delete(diff.modSettings, rfType[MirrorSettings]())
 	}
 
+	if mods.Active(Random) {
+		delete(diff.modSettings, rfType[RandomSettings]())
+	}
+
+	if mods.Active(Wiggle) {
+		delete(diff.modSettings, rfType[WiggleSettings]())
+	}
+
+	if mods.Active(Grow | Deflate) {
+		delete(diff.modSettings, rfType[ScaleTweenSettings]())
+	}
+
+	if mods.Active(BarrelRoll) {
+		delete(diff.modSettings, rfType[BarrelRollSettings]())
+	}
+
+	if mods.Active(WindUp | WindDown) {
+		delete(diff.modSettings, rfType[TimeRampSettings]())
+	}
+
+	if mods.Active(AdaptiveSpeed) {
+		delete(diff.modSettings, rfType[AdaptiveSpeedSettings]())
+	}
+
 	diff.calculate()
 }
 
@@ -294,6 +363,30 @@ func (diff *Difficulty) SetMods2(mods []rplpa.ModInfo) {
 			if mod.Active(Mirror) {
 				diff.modSettings[rfType[MirrorSettings]()] = parseConfig(NewMirrorSettings(), mInfo.Settings)
 			}
+
+			if mod.Active(Random) {
+				diff.modSettings[rfType[RandomSettings]()] = parseConfig(NewRandomSettings(), mInfo.Settings)
+			}
+
+			if mod.Active(Wiggle) {
+				diff.modSettings[rfType[WiggleSettings]()] = parseConfig(NewWiggleSettings(), mInfo.Settings)
+			}
+
+			if mod.Active(Grow | Deflate) {
+				diff.modSettings[rfType[ScaleTweenSettings]()] = parseConfig(NewScaleTweenSettings(mod.Active(Grow)), mInfo.Settings)
+			}
+
+			if mod.Active(BarrelRoll) {
+				diff.modSettings[rfType[BarrelRollSettings]()] = parseConfig(NewBarrelRollSettings(), mInfo.Settings)
+			}
+
+			if mod.Active(WindUp | WindDown) {
+				diff.modSettings[rfType[TimeRampSettings]()] = parseConfig(NewTimeRampSettings(mod.Active(WindUp)), mInfo.Settings)
+			}
+
+			if mod.Active(AdaptiveSpeed) {
+				diff.modSettings[rfType[AdaptiveSpeedSettings]()] = parseConfig(NewAdaptiveSpeedSettings(), mInfo.Settings)
+			}
 		}
 	}
 
@@ -363,6 +456,31 @@ func (diff *Difficulty) GetSpeed() float64 {
 	return diff.Speed
 }
 
+// SetRampRange sets the time span of Wind Up and Wind Down rate changes. Like in lazer, final rate is reached at 75% of the map.
+func (diff *Difficulty) SetRampRange(firstObjectStart, lastObjectEnd float64) {
+	diff.rampStart = firstObjectStart
+	diff.rampEnd = firstObjectStart + (lastObjectEnd-firstObjectStart)*0.75
+}
+
+// GetSpeedAt returns track rate at given time, it differs from GetSpeed only with variable rate mods
+func (diff *Difficulty) GetSpeedAt(time float64) float64 {
+	if s, ok := diff.modSettings[rfType[TimeRampSettings]()].(TimeRampSettings); ok {
+		progress := mutils.Clamp((time-diff.rampStart)/max(1, diff.rampEnd-diff.rampStart), 0, 1)
+		return s.InitialRate + (s.FinalRate-s.InitialRate)*progress
+	}
+
+	if diff.adaptive != nil {
+		return diff.adaptive.GetRate()
+	}
+
+	return diff.Speed
+}
+
+// GetAdaptiveSpeed returns Adaptive Speed state, nil if the mod is not active
+func (diff *Difficulty) GetAdaptiveSpeed() *AdaptiveRate {
+	return diff.adaptive
+}
+
 func (diff *Difficulty) AdjustsPitch() bool {
 	return diff.adjustPitch
 }
@@ -390,7 +508,9 @@ func (diff *Difficulty) GetRadius() float32 {
 func (diff *Difficulty) GetScoreMultiplier() float64 {
 	baseMultiplier := (diff.Mods & (^(HalfTime | Daycore | DoubleTime | Nightcore | Flashlight))).GetScoreMultiplier()
 
-	if diff.Mods.Active(Lazer) {
+	if diff.Mods.Active(WindUp | WindDown | AdaptiveSpeed) {
+		// Handled by Modifier.GetScoreMultiplier
+	} else if diff.Mods.Active(Lazer) {
 		value := math.Floor(diff.Speed*10)/10 - 1
 
 		if diff.Speed >= 1 {
@@ -598,6 +718,11 @@ func (diff *Difficulty) Clone() *Difficulty {
 		diff2.modSettings[k] = v
 	}
 
+	// Adaptive Speed state belongs to a single player
+	if diff.adaptive != nil {
+		diff2.adaptive = NewAdaptiveRate(diff.adaptive.GetInitialRate())
+	}
+
 	return &diff2
 }
 
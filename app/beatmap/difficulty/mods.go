@@ -43,6 +43,15 @@ const (
 	DifficultyAdjust
 	Mirror
 	Traceable
+	Transform
+	Wiggle
+	SpinIn
+	Grow
+	Deflate
+	BarrelRoll
+	WindUp
+	WindDown
+	AdaptiveSpeed
 
 	// DifficultyAdjustMask is outdated, use GetDiffMaskedMods instead
 	DifficultyAdjustMask    = HardRock | Easy | DoubleTime | Nightcore | HalfTime | Daycore | Flashlight | Relax
@@ -91,7 +100,7 @@ var modsString = [...]string{
 	"K7",
 	"K8",
 	"FI",
-	"RN", // Random
+	"RD", // Random
 	"CN",
 	"TG",
 	"K9",
@@ -107,6 +116,15 @@ var modsString = [...]string{
 	"DA",
 	"MR",
 	"TC",
+	"TR",
+	"WG",
+	"SI",
+	"GR",
+	"DF",
+	"BR",
+	"WU",
+	"WD",
+	"AS",
 }
 
 var modsStringFull = [...]string{
@@ -147,6 +165,15 @@ var modsStringFull = [...]string{
 	"DifficultyAdjust",
 	"Mirror",
 	"Traceable",
+	"Transform",
+	"Wiggle",
+	"SpinIn",
+	"Grow",
+	"Deflate",
+	"BarrelRoll",
+	"WindUp",
+	"WindDown",
+	"AdaptiveSpeed",
 }
 
 func (mods Modifier) GetScoreMultiplier() float64 {
@@ -208,6 +235,10 @@ func (mods Modifier) GetScoreMultiplier() float64 {
 		multiplier *= 0.5
 	}
 
+	if mods&(WindUp|WindDown|AdaptiveSpeed) > 0 {
+		multiplier *= 0.5
+	}
+
 	return multiplier
 }
 
@@ -265,7 +296,16 @@ func (mods Modifier) StringFull2() (s []string) {
 	return
 }
 
+// Acronyms which were renamed, kept so older command lines still work
+var legacyAcronyms = map[string]string{
+	"RN": "RD", // Random
+}
+
 func ParseFromAcronym(mod string) (m Modifier) {
+	if renamed, ok := legacyAcronyms[mod]; ok {
+		mod = renamed
+	}
+
 	for index, availableMod := range modsString {
 		if availableMod == mod {
 			m = 1 << uint(index)
@@ -337,7 +377,7 @@ func (mods Modifier) Compatible() bool {
 		return true
 	}
 
-	if mods.Active(Target) ||
+	if (mods.Active(Target) && mods.Active(Lazer)) || // lazer's Target Practice works differently
 		(mods.Active(HardRock) && mods.Active(Easy)) ||
 		(mods.Active(HardRock) && mods.Active(Mirror)) ||
 		(mods.Active(Lazer) && mods.Active(ScoreV2)) ||
@@ -345,7 +385,13 @@ func (mods Modifier) Compatible() bool {
 		((mods.Active(Perfect) || mods.Active(SuddenDeath)) && mods.Active(NoFail)) ||
 		(mods.Active(Relax) && mods.Active(Relax2)) ||
 		((mods.Active(Relax) || mods.Active(Relax2)) && (mods.Active(SuddenDeath) || mods.Active(Perfect) || mods.Active(Autoplay) || mods.Active(NoFail))) ||
-		(mods.Active(Relax2) && mods.Active(SpunOut)) {
+		(mods.Active(Relax2) && mods.Active(SpunOut)) ||
+		(mods.Active(Transform) && mods.Active(Wiggle)) ||
+		(mods.Active(Grow) && mods.Active(Deflate)) ||
+		(mods.Active(SpinIn) && mods.Active(Grow|Deflate|Hidden|Traceable)) ||
+		(mods.Active(WindUp|WindDown|AdaptiveSpeed) && mods.Active(DoubleTime|Nightcore|HalfTime|Daycore)) ||
+		(mods.Active(WindUp) && mods.Active(WindDown|AdaptiveSpeed)) ||
+		(mods.Active(WindDown) && mods.Active(AdaptiveSpeed)) {
 		return false
 	}
 
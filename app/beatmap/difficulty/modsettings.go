@@ -1,6 +1,10 @@
 package difficulty
 
-import "reflect"
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+)
 
 var modConfigs map[Modifier]reflect.Type
 
@@ -19,6 +23,14 @@ func init() {
 		Flashlight:       rfType[FlashlightSettings](),
 		DifficultyAdjust: rfType[DiffAdjustSettings](),
 		Mirror:           rfType[MirrorSettings](),
+		Random:           rfType[RandomSettings](),
+		Wiggle:           rfType[WiggleSettings](),
+		Grow:             rfType[ScaleTweenSettings](),
+		Deflate:          rfType[ScaleTweenSettings](),
+		BarrelRoll:       rfType[BarrelRollSettings](),
+		WindUp:           rfType[TimeRampSettings](),
+		WindDown:         rfType[TimeRampSettings](),
+		AdaptiveSpeed:    rfType[AdaptiveSpeedSettings](),
 	}
 }
 
@@ -137,3 +149,120 @@ func NewMirrorSettings() MirrorSettings {
 func (s MirrorSettings) postLoad() MirrorSettings {
 	return s
 }
+
+type RandomSettings struct {
+	Seed           int64   `json:"seed"`
+	AngleSharpness float64 `json:"angle_sharpness"`
+}
+
+// NewRandomSettings picks a random seed like lazer does when it's not set
+func NewRandomSettings() RandomSettings {
+	return RandomSettings{
+		Seed:           rand.Int64N(math.MaxInt32),
+		AngleSharpness: 7,
+	}
+}
+
+func (s RandomSettings) postLoad() RandomSettings {
+	s.AngleSharpness = min(max(s.AngleSharpness, 1), 10)
+
+	return s
+}
+
+type WiggleSettings struct {
+	Strength float64 `json:"strength"`
+}
+
+func NewWiggleSettings() WiggleSettings {
+	return WiggleSettings{
+		Strength: 1,
+	}
+}
+
+func (s WiggleSettings) postLoad() WiggleSettings {
+	return s
+}
+
+// ScaleTweenSettings are used by Grow and Deflate, objects scale from StartScale to 1 during preempt
+type ScaleTweenSettings struct {
+	StartScale float64 `json:"start_scale"`
+}
+
+func NewScaleTweenSettings(grow bool) ScaleTweenSettings {
+	if grow {
+		return ScaleTweenSettings{StartScale: 0.5}
+	}
+
+	return ScaleTweenSettings{StartScale: 2}
+}
+
+func (s ScaleTweenSettings) postLoad() ScaleTweenSettings {
+	return s
+}
+
+const (
+	RotationClockwise        = 0
+	RotationCounterclockwise = 1
+)
+
+type BarrelRollSettings struct {
+	SpinSpeed float64 `json:"spin_speed"`
+	Direction int     `json:"direction"`
+}
+
+func NewBarrelRollSettings() BarrelRollSettings {
+	return BarrelRollSettings{
+		SpinSpeed: 0.5,
+		Direction: RotationClockwise,
+	}
+}
+
+func (s BarrelRollSettings) postLoad() BarrelRollSettings {
+	return s
+}
+
+// GetRotation returns playfield rotation in radians at given beatmap time, SpinSpeed is in rotations per minute
+func (s BarrelRollSettings) GetRotation(time float64) float64 {
+	rotation := 2 * math.Pi * time / 60000 * s.SpinSpeed
+
+	if s.Direction == RotationCounterclockwise {
+		return -rotation
+	}
+
+	return rotation
+}
+
+// TimeRampSettings are used by Wind Up and Wind Down, rate changes from InitialRate to FinalRate over the map
+type TimeRampSettings struct {
+	InitialRate float64 `json:"initial_rate"`
+	FinalRate   float64 `json:"final_rate"`
+	AdjustPitch bool    `json:"adjust_pitch"`
+}
+
+func NewTimeRampSettings(windUp bool) TimeRampSettings {
+	if windUp {
+		return TimeRampSettings{InitialRate: 1, FinalRate: 1.5, AdjustPitch: true}
+	}
+
+	return TimeRampSettings{InitialRate: 1, FinalRate: 0.75, AdjustPitch: true}
+}
+
+func (s TimeRampSettings) postLoad() TimeRampSettings {
+	return s
+}
+
+type AdaptiveSpeedSettings struct {
+	InitialRate float64 `json:"initial_rate"`
+	AdjustPitch bool    `json:"adjust_pitch"`
+}
+
+func NewAdaptiveSpeedSettings() AdaptiveSpeedSettings {
+	return AdaptiveSpeedSettings{
+		InitialRate: 1,
+		AdjustPitch: true,
+	}
+}
+
+func (s AdaptiveSpeedSettings) postLoad() AdaptiveSpeedSettings {
+	return s
+}
@@ -0,0 +1,159 @@
+package difficulty
+
+import (
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"math"
+	"slices"
+)
+
+const (
+	adaptiveMinRate = 0.5
+	adaptiveMaxRate = 2.0
+
+	// How many recent results affect the target rate
+	adaptiveRecentCount = 8
+
+	// Rate multiplier applied on miss
+	adaptiveMissPenalty = 0.95
+
+	// Max rate change from a single hit
+	adaptiveMinChange = 0.9
+	adaptiveMaxChange = 1.11
+
+	// Half-life of rate damping towards the target rate in milliseconds
+	adaptiveHalfTime = 50.0
+)
+
+// AdaptiveRate follows lazer's Adaptive Speed mod, rate changes depending on how early or late objects are hit.
+// Objects have to be registered with AddObject before results are fed with AddResult.
+type AdaptiveRate struct {
+	initialRate float64
+
+	rate       float64
+	targetRate float64
+	lastTime   float64
+
+	recentRates []float64
+
+	// End times of registered objects
+	objects  map[int64]float64
+	endTimes []float64
+	sorted   bool
+
+	rated map[int64]struct{}
+}
+
+func NewAdaptiveRate(initialRate float64) *AdaptiveRate {
+	adaptive := &AdaptiveRate{
+		initialRate: initialRate,
+	}
+
+	adaptive.Reset()
+
+	return adaptive
+}
+
+// Reset brings the rate back to initial rate and forgets all results and registered objects
+func (adaptive *AdaptiveRate) Reset() {
+	adaptive.rate = adaptive.initialRate
+	adaptive.targetRate = adaptive.initialRate
+	adaptive.lastTime = math.Inf(-1)
+
+	adaptive.recentRates = make([]float64, adaptiveRecentCount)
+	for i := range adaptive.recentRates {
+		adaptive.recentRates[i] = adaptive.initialRate
+	}
+
+	adaptive.rated = make(map[int64]struct{})
+
+	adaptive.objects = make(map[int64]float64)
+	adaptive.endTimes = adaptive.endTimes[:0]
+	adaptive.sorted = true
+}
+
+// AddObject registers an object which affects the rate
+func (adaptive *AdaptiveRate) AddObject(number int64, endTime float64) {
+	adaptive.objects[number] = endTime
+	adaptive.endTimes = append(adaptive.endTimes, endTime)
+	adaptive.sorted = false
+}
+
+// precedingEndTime returns the latest end time of registered objects that is earlier than endTime, like lazer's precedingEndTimes.
+// Returns false for the first object.
+func (adaptive *AdaptiveRate) precedingEndTime(endTime float64) (float64, bool) {
+	if !adaptive.sorted {
+		slices.Sort(adaptive.endTimes)
+		adaptive.endTimes = slices.Compact(adaptive.endTimes)
+		adaptive.sorted = true
+	}
+
+	index, _ := slices.BinarySearch(adaptive.endTimes, endTime)
+	if index == 0 {
+		return 0, false
+	}
+
+	return adaptive.endTimes[index-1], true
+}
+
+// AddResult updates target rate with the first result of the object. hit is false if the object was missed.
+func (adaptive *AdaptiveRate) AddResult(number int64, hit bool, time float64) {
+	endTime, ok := adaptive.objects[number]
+	if !ok {
+		return
+	}
+
+	// First object doesn't have a reference point
+	precedingEndTime, ok := adaptive.precedingEndTime(endTime)
+	if !ok {
+		return
+	}
+
+	if _, ok = adaptive.rated[number]; ok {
+		return
+	}
+
+	adaptive.rated[number] = struct{}{}
+
+	change := adaptiveMissPenalty
+
+	if hit {
+		expected := endTime - precedingEndTime
+		actual := time - precedingEndTime
+
+		change = 1.0
+		if actual > 0 && expected > 0 {
+			change = mutils.Clamp(expected/actual, adaptiveMinChange, adaptiveMaxChange)
+		}
+	}
+
+	copy(adaptive.recentRates, adaptive.recentRates[1:])
+	adaptive.recentRates[len(adaptive.recentRates)-1] = mutils.Clamp(adaptive.rate*change, adaptiveMinRate, adaptiveMaxRate)
+
+	sum := 0.0
+	for _, r := range adaptive.recentRates {
+		sum += r
+	}
+
+	adaptive.targetRate = sum / float64(len(adaptive.recentRates))
+}
+
+// Update eases the rate towards the target rate
+func (adaptive *AdaptiveRate) Update(time float64) {
+	if math.IsInf(adaptive.lastTime, -1) {
+		adaptive.lastTime = time
+		return
+	}
+
+	elapsed := math.Abs(time - adaptive.lastTime)
+	adaptive.lastTime = time
+
+	adaptive.rate = adaptive.targetRate + (adaptive.rate-adaptive.targetRate)*math.Pow(0.5, elapsed/adaptiveHalfTime)
+}
+
+func (adaptive *AdaptiveRate) GetRate() float64 {
+	return adaptive.rate
+}
+
+func (adaptive *AdaptiveRate) GetInitialRate() float64 {
+	return adaptive.initialRate
+}
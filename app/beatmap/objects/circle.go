@@ -63,6 +63,25 @@ func NewCircle(data []string) *Circle {
 	return circle
 }
 
+// NewTarget creates a circle that replaces original objects in Target Practice
+func NewTarget(pos vector.Vector2f, time float64, newCombo bool) *Circle {
+	circle := &Circle{
+		HitObject: &HitObject{
+			StartPosRaw:   pos,
+			EndPosRaw:     pos,
+			StartTime:     time,
+			EndTime:       time,
+			HitObjectID:   -1,
+			NewCombo:      newCombo,
+			StackIndexMap: make(map[int64]int64),
+		},
+	}
+
+	circle.textureName = defaultCircleName
+
+	return circle
+}
+
 func DummyCircle(pos vector.Vector2f, time float64) *Circle {
 	return DummyCircleInherit(pos, time, false, false, false)
 }
@@ -345,7 +364,7 @@ func (circle *Circle) Draw(time float64, color color2.Color, batch *batch.QuadBa
 }
 
 func (circle *Circle) DrawApproach(time float64, color color2.Color, batch *batch.QuadBatch) {
-	if circle.approachCircle == nil || circle.diff.Preempt > 15000 {
+	if circle.approachCircle == nil || circle.diff.Preempt > 15000 || HidesApproachCircles(circle.diff) {
 		return
 	}
 
@@ -363,3 +382,8 @@ func (circle *Circle) DrawApproach(time float64, color color2.Color, batch *batc
 func (circle *Circle) GetType() Type {
 	return CIRCLE
 }
+
+// GetSample returns hitsound flags of the circle
+func (circle *Circle) GetSample() int {
+	return circle.sample
+}
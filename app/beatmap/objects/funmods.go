@@ -0,0 +1,142 @@
+package objects
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/framework/math/animation/easing"
+	"github.com/wieku/danser-go/framework/math/dotnet"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+)
+
+// Time between wiggles in Wiggle mod
+const wiggleDuration = 100.0
+
+// GetFunModOffset returns how far lazer's Transform and Wiggle mods move the object from its position at given time.
+// Lazer moves hit areas along with objects, so it's used in judgements as well.
+func GetFunModOffset(hitObject IHitObject, time float64, diff *difficulty.Difficulty) vector.Vector2f {
+	if diff.CheckModActive(difficulty.Transform) {
+		return transformOffset(hitObject, time, diff)
+	}
+
+	if diff.CheckModActive(difficulty.Wiggle) {
+		if _, ok := hitObject.(*Spinner); !ok {
+			return wiggleOffset(hitObject, time, diff)
+		}
+	}
+
+	return vector.Vector2f{}
+}
+
+// transformOffset moves objects to their position from a point rotated around it, the angle increases with every object
+func transformOffset(hitObject IHitObject, time float64, diff *difficulty.Difficulty) vector.Vector2f {
+	theta := float64(hitObject.GetID()) * diff.TimeFadeIn / 1000
+	distance := (diff.Preempt - diff.TimeFadeIn) / 2
+
+	appearTime := hitObject.GetStartTime() - diff.Preempt - 1
+
+	progress := easing.InOutSine(mutils.Clamp((time-appearTime)/(diff.Preempt+1), 0, 1))
+
+	return vector.NewVec2dRad(theta, distance*(1-progress)).Copy32()
+}
+
+// wiggleOffset moves the object to a random point every 100ms, objects with duration keep wiggling until they end.
+// Wiggles are generated from the same seed lazer uses every time, so the result doesn't depend on update order.
+func wiggleOffset(hitObject IHitObject, time float64, diff *difficulty.Difficulty) vector.Vector2f {
+	startTime := hitObject.GetStartTime()
+	appearTime := startTime - diff.Preempt
+
+	if time < appearTime {
+		return vector.Vector2f{}
+	}
+
+	strength := 1.0
+	if s, ok := difficulty.GetModConfig[difficulty.WiggleSettings](diff); ok {
+		strength = s.Strength
+	}
+
+	preWiggles := int(diff.Preempt / wiggleDuration)
+	allWiggles := preWiggles + int((hitObject.GetEndTime()-startTime)/wiggleDuration)
+
+	random := dotnet.NewRandom(int32(startTime))
+
+	var previous, current vector.Vector2f
+	var currentStart float64
+
+	for i := 0; i < allWiggles; i++ {
+		wiggleStart := appearTime + float64(i)*wiggleDuration
+		if i >= preWiggles {
+			wiggleStart = startTime + float64(i-preWiggles)*wiggleDuration
+		}
+
+		if wiggleStart > time {
+			break
+		}
+
+		// Lazer rounds both to single precision
+		angle := float64(float32(random.NextDouble() * 2 * math.Pi))
+		distance := float64(float32(random.NextDouble() * strength * 7))
+
+		previous = current
+		current = vector.NewVec2dRad(angle, distance).Copy32()
+		currentStart = wiggleStart
+	}
+
+	return previous.Lerp(current, float32(mutils.Clamp((time-currentStart)/wiggleDuration, 0, 1)))
+}
+
+// GetFunModMatrix returns the transformation lazer's visual mods apply to the object in playfield space.
+// Returns false if the object is not transformed at given time.
+func GetFunModMatrix(hitObject IHitObject, time float64, diff *difficulty.Difficulty) (mgl32.Mat4, bool) {
+	offset := GetFunModOffset(hitObject, time, diff)
+
+	scaleX, scaleY := 1.0, 1.0
+	rotation := 0.0
+
+	_, isCircle := hitObject.(*Circle)
+	_, isSlider := hitObject.(*Slider)
+
+	if isCircle || isSlider {
+		progress := mutils.Clamp((time-(hitObject.GetStartTime()-diff.Preempt))/diff.Preempt, 0, 1)
+
+		if diff.CheckModActive(difficulty.SpinIn) {
+			eased := easing.InOutSine(progress)
+
+			if isCircle {
+				rotation = 2 * math.Pi * (1 - eased)
+				scaleX = mutils.Lerp(2.0, 1.0, eased)
+				scaleY = eased
+			} else {
+				scaleX, scaleY = eased, eased
+			}
+		}
+
+		if s, ok := difficulty.GetModConfig[difficulty.ScaleTweenSettings](diff); ok {
+			scaleX = mutils.Lerp(s.StartScale, 1.0, easing.OutSine(progress))
+			scaleY = scaleX
+		}
+	}
+
+	// Circles are rotated back, so they stay upright when the playfield rotates. Slider heads rotate with the slider.
+	if s, ok := difficulty.GetModConfig[difficulty.BarrelRollSettings](diff); ok && isCircle {
+		rotation -= s.GetRotation(time)
+	}
+
+	if offset == (vector.Vector2f{}) && scaleX == 1 && scaleY == 1 && rotation == 0 {
+		return mgl32.Ident4(), false
+	}
+
+	position := hitObject.GetStackedStartPositionMod(diff)
+	target := position.Add(offset)
+
+	return mgl32.Translate3D(target.X, target.Y, 0).
+		Mul4(mgl32.HomogRotate3DZ(float32(rotation))).
+		Mul4(mgl32.Scale3D(float32(scaleX), float32(scaleY), 1)).
+		Mul4(mgl32.Translate3D(-position.X, -position.Y, 0)), true
+}
+
+// HidesApproachCircles returns true if active mods hide approach circles
+func HidesApproachCircles(diff *difficulty.Difficulty) bool {
+	return diff.CheckModActive(difficulty.SpinIn | difficulty.Grow | difficulty.Deflate)
+}
@@ -27,6 +27,7 @@ type IHitObject interface {
 
 	GetID() int64
 	SetID(int64)
+	GetComboNumber() int64
 	SetComboNumber(cn int64)
 	GetComboSet() int64
 	SetComboSet(set int64)
@@ -134,6 +135,12 @@ func (hitObject *HitObject) GetStackedEndPositionMod(diff *difficulty.Difficulty
 	return ModifyPosition(hitObject, hitObject.GetEndPosition(), diff)
 }
 
+// Transform moves the object with given rigid transformation (rotation, reflection, translation)
+func (hitObject *HitObject) Transform(f func(vector.Vector2f) vector.Vector2f) {
+	hitObject.StartPosRaw = f(hitObject.StartPosRaw)
+	hitObject.EndPosRaw = f(hitObject.EndPosRaw)
+}
+
 func (hitObject *HitObject) GetID() int64 {
 	return hitObject.HitObjectID
 }
@@ -142,6 +149,10 @@ func (hitObject *HitObject) SetID(id int64) {
 	hitObject.HitObjectID = id
 }
 
+func (hitObject *HitObject) GetComboNumber() int64 {
+	return hitObject.ComboNumber
+}
+
 func (hitObject *HitObject) SetComboNumber(cn int64) {
 	hitObject.ComboNumber = cn
 }
@@ -55,6 +55,7 @@ type Slider struct {
 	*HitObject
 
 	multiCurve  *curves.MultiCurve
+	curveDefs   []curves.CurveDef
 	scorePath   []PathLine
 	Timings     *Timings
 	TPoint      TimingPoint
@@ -251,6 +252,8 @@ func (slider *Slider) parseCurve(curveData string) *curves.MultiCurve {
 		}
 	}
 
+	slider.curveDefs = defs
+
 	return curves.NewMultiCurveT(defs, slider.pixelLength)
 }
 
@@ -1210,6 +1213,57 @@ func (slider *Slider) DrawApproach(time float64, color color2.Color, batch *batc
 	slider.startCircle.DrawApproach(time, color, batch)
 }
 
+// GetBaseSample returns hitsound flags applied to the whole slider
+func (slider *Slider) GetBaseSample() int {
+	return slider.baseSample
+}
+
+// GetEdgeSamples returns hitsound flags, sample sets and addition sets of slider's head, repeats and tail
+func (slider *Slider) GetEdgeSamples() (samples, sampleSets, additionSets []int) {
+	return slider.samples, slider.sampleSets, slider.additionSets
+}
+
+// GetCurveDefs returns curve segments as they were defined in the .osu file, first point of the first segment is slider's start position
+func (slider *Slider) GetCurveDefs() []curves.CurveDef {
+	return slider.curveDefs
+}
+
+// GetPixelLength returns slider's length as defined in the .osu file
+func (slider *Slider) GetPixelLength() float64 {
+	return slider.pixelLength
+}
+
+// GetCurve returns slider's path in playfield coordinates
+func (slider *Slider) GetCurve() *curves.MultiCurve {
+	return slider.multiCurve
+}
+
+// Transform moves the slider with given rigid transformation (rotation, reflection, translation).
+// Path length stays the same, so timing and score points don't have to be recalculated.
+func (slider *Slider) Transform(f func(vector.Vector2f) vector.Vector2f) {
+	slider.HitObject.Transform(f)
+	slider.Pos = f(slider.Pos)
+
+	for i := range slider.curveDefs {
+		for j := range slider.curveDefs[i].Points {
+			slider.curveDefs[i].Points[j] = f(slider.curveDefs[i].Points[j])
+		}
+	}
+
+	slider.multiCurve = curves.NewMultiCurveT(slider.curveDefs, slider.pixelLength)
+
+	for i := range slider.scorePath {
+		slider.scorePath[i].Line.Point1 = f(slider.scorePath[i].Line.Point1)
+		slider.scorePath[i].Line.Point2 = f(slider.scorePath[i].Line.Point2)
+	}
+
+	for _, points := range [][]TickPoint{slider.TickPoints, slider.TickReverse, slider.ScorePoints} {
+		for i := range points {
+			points[i].Pos = f(points[i].Pos)
+		}
+	}
+}
+
 func (slider *Slider) GetType() Type {
 	return SLIDER
 }
@@ -92,6 +92,11 @@ func NewDummySpinner(startTime, endTime float64) *Spinner {
 	}
 }
 
+// GetSample returns hitsound flags of the spinner
+func (spinner *Spinner) GetSample() int {
+	return spinner.sample
+}
+
 func (spinner *Spinner) GetPosition() vector.Vector2f {
 	return spinner.pos
 }
@@ -48,6 +48,11 @@ func (t TimingPoint) GetBaseBPM() float64 {
 	return 60000 / t.beatLengthBase
 }
 
+// GetRawBeatLength returns beat length as defined in the .osu file, negative values are slider velocity multipliers of inherited points
+func (t TimingPoint) GetRawBeatLength() float64 {
+	return t.beatLength
+}
+
 func (t TimingPoint) GetBeatLength() float64 {
 	return t.beatLengthBase * t.GetRatio()
 }
@@ -170,6 +175,36 @@ func (tim *Timings) GetTickDistance(point TimingPoint) float64 {
 	return tim.GetScoringDistance() / point.GetRatio()
 }
 
+// ChangeRate rescales timing points as if music was played at given speed
+func (tim *Timings) ChangeRate(speed float64) {
+	scale := func(points []TimingPoint) {
+		for i := range points {
+			points[i].Time /= speed
+			points[i].beatLengthBase /= speed
+
+			// Negative beat lengths are slider velocity multipliers
+			if points[i].beatLength > 0 {
+				points[i].beatLength /= speed
+			}
+		}
+	}
+
+	scale(tim.points)
+	scale(tim.originalPoints)
+
+	tim.Current = tim.GetPointAt(tim.Current.Time / speed)
+}
+
+// GetPoints returns all timing points sorted by time
+func (tim *Timings) GetPoints() []TimingPoint {
+	return tim.points
+}
+
+// GetOriginalPoints returns uninherited timing points sorted by time
+func (tim *Timings) GetOriginalPoints() []TimingPoint {
+	return tim.originalPoints
+}
+
 func (tim *Timings) HasPoints() bool {
 	return len(tim.points) > 0
 }
@@ -3,6 +3,7 @@ package beatmap
 import (
 	"cmp"
 	"errors"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/app/beatmap/objects"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/skin"
@@ -46,6 +47,17 @@ func parseGeneral(line []string, beatMap *BeatMap) bool {
 	return false
 }
 
+// parseGeneralExtra keeps [General] settings not needed for playback, so they can be saved back by the beatmap writer
+func parseGeneralExtra(line []string, beatMap *BeatMap) {
+	switch line[0] {
+	case "AudioFilename", "PreviewTime", "SampleSet", "StackLeniency", "Mode":
+	case "AudioLeadIn":
+		beatMap.AudioLeadIn, _ = strconv.ParseInt(line[1], 10, 64)
+	default:
+		beatMap.GeneralExtra = append(beatMap.GeneralExtra, line[0]+": "+line[1])
+	}
+}
+
 func parseMetadata(line []string, beatMap *BeatMap) {
 	switch line[0] {
 	case "Title":
@@ -102,11 +114,41 @@ func parseEvents(line []string, beatMap *BeatMap) {
 	switch line[0] {
 	case "Background", "0":
 		beatMap.Bg = strings.Replace(line[2], "\"", "", -1)
+	case "Video", "1":
+		if len(line) > 2 {
+			beatMap.Video = strings.Replace(line[2], "\"", "", -1)
+			beatMap.VideoOffset, _ = strconv.ParseFloat(line[1], 64)
+		}
 	case "Break", "2":
 		beatMap.Pauses = append(beatMap.Pauses, NewPause(line))
 	}
 }
 
+// parseStoryboardEvent keeps storyboard lines as they are, background, video and breaks are parsed by parseEvents
+func parseStoryboardEvent(line string, arr []string, beatMap *BeatMap) {
+	if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "_") {
+		switch arr[0] {
+		case "Background", "0", "Video", "1", "Break", "2":
+			return
+		}
+	}
+
+	beatMap.StoryboardEvents = append(beatMap.StoryboardEvents, line)
+}
+
+func parseColor(line []string, beatMap *BeatMap) {
+	clr := skin.ParseColor(line[1], line[0])
+
+	switch {
+	case strings.HasPrefix(line[0], "Combo"):
+		beatMap.ComboColors = append(beatMap.ComboColors, clr)
+	case line[0] == "SliderBorder":
+		beatMap.SliderBorder = &clr
+	case line[0] == "SliderTrackOverride":
+		beatMap.SliderTrackOverride = &clr
+	}
+}
+
 func parseHitObjects(line []string, beatMap *BeatMap) {
 	obj := objects.CreateObject(line)
 
@@ -317,6 +359,10 @@ func ParseObjects(beatMap *BeatMap, diffCalcOnly, parseColors bool) {
 
 	var currentSection string
 
+	beatMap.ComboColors = beatMap.ComboColors[:0]
+	beatMap.GeneralExtra = beatMap.GeneralExtra[:0]
+	beatMap.StoryboardEvents = beatMap.StoryboardEvents[:0]
+
 	for scanner.Scan() {
 		line := scanner.Text()
 
@@ -332,9 +378,19 @@ func ParseObjects(beatMap *BeatMap, diffCalcOnly, parseColors bool) {
 		}
 
 		switch currentSection {
+		case "General":
+			if arr := tokenizeN(line, ":", 2); len(arr) > 1 {
+				parseGeneralExtra(arr, beatMap)
+			}
+		case "Events":
+			if arr := tokenize(line, ","); len(arr) > 1 {
+				parseStoryboardEvent(line, arr, beatMap)
+			}
 		case "Colours": //nolint:misspell
-			if parseColors {
-				if arr := tokenize(line, ":"); arr != nil {
+			if arr := tokenize(line, ":"); arr != nil {
+				parseColor(arr, beatMap)
+
+				if parseColors {
 					skin.AddBeatmapColor(arr)
 				}
 			}
@@ -345,14 +401,28 @@ func ParseObjects(beatMap *BeatMap, diffCalcOnly, parseColors bool) {
 		}
 	}
 
-	slices.SortStableFunc(beatMap.HitObjects, func(a, b objects.IHitObject) int {
-		return cmp.Compare(a.GetStartTime(), b.GetStartTime())
-	})
-
 	if parseColors {
 		skin.FinishBeatmapColors()
 	}
 
+	FinalizeObjects(beatMap, diffCalcOnly)
+
+	if beatMap.Diff.CheckModActive(difficulty.Target) {
+		ConvertToTargets(beatMap, diffCalcOnly)
+	}
+}
+
+// FinalizeObjects sorts hit objects, assigns combos and calculates their timings and stacking.
+// It's called by ParseObjects, objects added to the beatmap manually have to be finalized with it too.
+func FinalizeObjects(beatMap *BeatMap, diffCalcOnly bool) {
+	finalizeObjects(beatMap, diffCalcOnly, true)
+}
+
+func finalizeObjects(beatMap *BeatMap, diffCalcOnly, randomize bool) {
+	slices.SortStableFunc(beatMap.HitObjects, func(a, b objects.IHitObject) int {
+		return cmp.Compare(a.GetStartTime(), b.GetStartTime())
+	})
+
 	num := 0
 	comboNumber := 1
 	comboSet := 0
@@ -392,4 +462,17 @@ func ParseObjects(beatMap *BeatMap, diffCalcOnly, parseColors bool) {
 	if settings.Objects.StackEnabled || settings.KNOCKOUT || settings.PLAY || diffCalcOnly {
 		beatMap.CalculateStackLeniency(beatMap.Diff)
 	}
+
+	if randomize {
+		ApplyRandom(beatMap)
+	}
+
+	if len(beatMap.HitObjects) > 0 {
+		lastEnd := 0.0
+		for _, obj := range beatMap.HitObjects {
+			lastEnd = max(lastEnd, obj.GetEndTime())
+		}
+
+		beatMap.Diff.SetRampRange(beatMap.HitObjects[0].GetStartTime(), lastEnd)
+	}
 }
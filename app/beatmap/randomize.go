@@ -0,0 +1,601 @@
+package beatmap
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/math/dotnet"
+	"github.com/wieku/danser-go/framework/math/math32"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+)
+
+// Port of lazer's OsuModRandom and OsuHitObjectGenerationUtils. Lazer does most of the math in single precision, so it's kept here as well.
+
+const (
+	playfieldWidth  = float32(512)
+	playfieldHeight = float32(384)
+
+	borderDistanceX = playfieldWidth * 0.1
+	borderDistanceY = playfieldHeight * 0.1
+
+	// Number of previous hit circles to be shifted together with the current one when it's moved back into the playfield
+	precedingObjectsToShift = 10
+
+	angleSharpnessMax     = 10
+	angleSharpnessDefault = 7
+)
+
+var playfieldCentre = vector.NewVec2f(playfieldWidth/2, playfieldHeight/2)
+
+// Lazer uses osuTK's LengthFast here, which is not exactly 640
+var playfieldDiagonal = 1 / inverseSqrtFast(playfieldWidth*playfieldWidth+playfieldHeight*playfieldHeight)
+
+func inverseSqrtFast(x float32) float32 {
+	xHalf := 0.5 * x
+	i := int32(math.Float32bits(x))
+	i = 0x5f375a86 - (i >> 1)
+	x = math.Float32frombits(uint32(i))
+
+	return x * (1.5 - xHalf*x*x)
+}
+
+type positionInfo struct {
+	object objects.IHitObject
+
+	relativeAngle        float32
+	distanceFromPrevious float32
+	rotation             float32
+}
+
+type workingObject struct {
+	info *positionInfo
+
+	rotationOriginal    float32
+	positionModified    vector.Vector2f
+	endPositionModified vector.Vector2f
+}
+
+type randomizer struct {
+	beatMap *BeatMap
+	random  *dotnet.Random
+	radius  float32
+
+	angleSharpness float32
+}
+
+// ApplyRandom repositions objects like lazer's Random mod does, with beatmap's mod settings. Like in lazer, it has to run after
+// stacking is calculated. It's called by FinalizeObjects, it has to be called manually only if the mod was added to a finalized beatmap.
+func ApplyRandom(beatMap *BeatMap) {
+	diff := beatMap.Diff
+
+	config, ok := difficulty.GetModConfig[difficulty.RandomSettings](diff)
+	if !ok || len(beatMap.HitObjects) == 0 {
+		return
+	}
+
+	r := &randomizer{
+		beatMap:        beatMap,
+		random:         dotnet.NewRandom(int32(config.Seed)),
+		radius:         float32(diff.CircleRadiusL),
+		angleSharpness: float32(config.AngleSharpness),
+	}
+
+	// Lazer flips objects with Hard Rock and Mirror before Random, while danser does it when positions are requested
+	flip := getFlip(diff)
+
+	r.transformAll(flip)
+	r.randomize()
+	r.transformAll(flip)
+}
+
+func getFlip(diff *difficulty.Difficulty) func(vector.Vector2f) vector.Vector2f {
+	mS, mOk := difficulty.GetModConfig[difficulty.MirrorSettings](diff)
+
+	vFlip := diff.CheckModActive(difficulty.HardRock) != (mOk && (mS.FlipMode+1)&2 == 2)
+	hFlip := mOk && (mS.FlipMode+1)&1 == 1
+
+	return func(pos vector.Vector2f) vector.Vector2f {
+		if hFlip {
+			pos.X = playfieldWidth - pos.X
+		}
+
+		if vFlip {
+			pos.Y = playfieldHeight - pos.Y
+		}
+
+		return pos
+	}
+}
+
+func (r *randomizer) transformAll(f func(vector.Vector2f) vector.Vector2f) {
+	for _, obj := range r.beatMap.HitObjects {
+		switch o := obj.(type) {
+		case *objects.Circle:
+			o.Transform(f)
+		case *objects.Slider:
+			o.Transform(f)
+		}
+	}
+}
+
+func (r *randomizer) randomize() {
+	infos := generatePositionInfos(r.beatMap.HitObjects)
+
+	// Offsets the angles of all hit objects in a "section" by the same amount
+	var sectionOffset float32
+
+	// Whether the angles are positive or negative (clockwise or counter-clockwise flow)
+	flowDirection := false
+
+	for i, info := range infos {
+		if r.shouldStartNewSection(infos, i) {
+			sectionOffset = r.getRandomOffset(0.0008)
+			flowDirection = !flowDirection
+		}
+
+		if slider, ok := info.object.(*objects.Slider); ok && r.random.NextDouble() < 0.5 {
+			flipSliderInPlaceHorizontally(slider)
+		}
+
+		if i == 0 {
+			info.distanceFromPrevious = float32(r.random.NextDouble() * float64(playfieldHeight) / 2)
+			info.relativeAngle = float32(r.random.NextDouble()*2*math.Pi - math.Pi)
+
+			continue
+		}
+
+		// Offsets only the angle of the current hit object if a flow change occurs
+		var flowChangeOffset float32
+
+		// Offsets only the angle of the current hit object
+		oneTimeOffset := r.getRandomOffset(0.002)
+
+		if r.shouldApplyFlowChange(infos, i) {
+			flowChangeOffset = r.getRandomOffset(0.002)
+			flowDirection = !flowDirection
+		}
+
+		totalOffset := (sectionOffset+oneTimeOffset)*info.distanceFromPrevious + // mainly affects patterns with large spacing
+			flowChangeOffset*(playfieldDiagonal-info.distanceFromPrevious) // mainly affects streams
+
+		info.relativeAngle = r.getRelativeTargetAngle(info.distanceFromPrevious, totalOffset, flowDirection)
+	}
+
+	r.repositionObjects(infos)
+}
+
+func (r *randomizer) getRandomOffset(stdDev float32) float32 {
+	// Range: [0.5, 2], higher angle sharpness gives lower multiplier
+	customMultiplier := (1.5*angleSharpnessMax - r.angleSharpness) / (1.5*angleSharpnessMax - angleSharpnessDefault)
+
+	return r.randomGaussian(0, stdDev*customMultiplier)
+}
+
+func (r *randomizer) randomGaussian(mean, stdDev float32) float32 {
+	// x1 must not be 0 since log(0) is undefined
+	x1 := 1 - r.random.NextDouble()
+	x2 := 1 - r.random.NextDouble()
+
+	stdNormal := math.Sqrt(-2*math.Log(x1)) * math.Sin(2*math.Pi*x2)
+
+	return mean + stdDev*float32(stdNormal)
+}
+
+func (r *randomizer) getRelativeTargetAngle(targetDistance, offset float32, flowDirection bool) float32 {
+	// Range: [0.1, 1]
+	angleSharpness := r.angleSharpness / angleSharpnessMax
+	// Range: [0, 0.9]
+	angleWideness := 1 - angleSharpness
+
+	// Range: [-60, 30]
+	customOffsetX := angleSharpness*100 - 70
+	// Range: [-0.075, 0.15]
+	customOffsetY := angleWideness*0.25 - 0.075
+
+	targetDistance += customOffsetX
+
+	angle := float32(2.16/(1+200*math.Exp(0.036*(float64(targetDistance)-310))) + 0.5)
+	angle += offset + customOffsetY
+
+	relativeAngle := math32.Pi - angle
+
+	if flowDirection {
+		return -relativeAngle
+	}
+
+	return relativeAngle
+}
+
+// previousObjectStartedCombo excludes new combo spam and 1-2 combos
+func previousObjectStartedCombo(infos []*positionInfo, i int) bool {
+	return infos[max(0, i-2)].object.GetComboNumber() > 2 && infos[i-1].object.IsNewCombo()
+}
+
+func (r *randomizer) shouldApplyFlowChange(infos []*positionInfo, i int) bool {
+	return previousObjectStartedCombo(infos, i) && r.random.NextDouble() < float64(float32(0.6))
+}
+
+func (r *randomizer) shouldStartNewSection(infos []*positionInfo, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	previous := infos[i-1].object
+
+	return (previousObjectStartedCombo(infos, i) && r.random.NextDouble() < float64(float32(0.6))) ||
+		r.isObjectOnBeat(previous, true) ||
+		(r.isObjectOnBeat(previous, false) && r.random.NextDouble() < float64(float32(0.4)))
+}
+
+func (r *randomizer) isObjectOnBeat(object objects.IHitObject, downbeatsOnly bool) bool {
+	point := r.beatMap.Timings.GetOriginalPointAt(object.GetStartTime())
+
+	timeSincePoint := object.GetStartTime() - point.Time
+
+	beatLength := point.GetBaseBeatLength()
+	if downbeatsOnly {
+		beatLength *= float64(point.Signature)
+	}
+
+	// Ensure within 1ms of expected location
+	return math.Mod(math.Abs(timeSincePoint+1), beatLength) < 2
+}
+
+func generatePositionInfos(hitObjects []objects.IHitObject) []*positionInfo {
+	infos := make([]*positionInfo, 0, len(hitObjects))
+
+	previousPosition := playfieldCentre
+	var previousAngle float32
+
+	for _, obj := range hitObjects {
+		relativePosition := obj.GetStartPosition().Sub(previousPosition)
+		absoluteAngle := math32.Atan2(relativePosition.Y, relativePosition.X)
+
+		info := &positionInfo{
+			object:               obj,
+			relativeAngle:        absoluteAngle - previousAngle,
+			distanceFromPrevious: relativePosition.Len(),
+		}
+
+		if slider, ok := obj.(*objects.Slider); ok {
+			absoluteRotation := getSliderRotation(slider)
+			info.rotation = absoluteRotation - absoluteAngle
+			absoluteAngle = absoluteRotation
+		}
+
+		infos = append(infos, info)
+
+		previousPosition = obj.GetEndPosition()
+		previousAngle = absoluteAngle
+	}
+
+	return infos
+}
+
+func (r *randomizer) repositionObjects(infos []*positionInfo) {
+	working := make([]*workingObject, len(infos))
+
+	for i, info := range infos {
+		w := &workingObject{
+			info:                info,
+			positionModified:    info.object.GetStartPosition(),
+			endPositionModified: info.object.GetEndPosition(),
+		}
+
+		if slider, ok := info.object.(*objects.Slider); ok {
+			w.rotationOriginal = getSliderRotation(slider)
+		}
+
+		working[i] = w
+	}
+
+	var previous *workingObject
+
+	for i, current := range working {
+		if _, ok := current.info.object.(*objects.Spinner); ok {
+			previous = current
+			continue
+		}
+
+		var beforePrevious *workingObject
+		if i > 1 {
+			beforePrevious = working[i-2]
+		}
+
+		computeModifiedPosition(current, previous, beforePrevious)
+
+		// Move hit objects back into the playfield if they are outside of it
+		var shift vector.Vector2f
+
+		switch o := current.info.object.(type) {
+		case *objects.Circle:
+			shift = r.clampCircleToPlayfield(current, o)
+		case *objects.Slider:
+			shift = r.clampSliderToPlayfield(current, o)
+		}
+
+		if shift != (vector.Vector2f{}) {
+			toBeMoved := make([]*objects.Circle, 0, precedingObjectsToShift)
+
+			for j := i - 1; j >= i-precedingObjectsToShift && j >= 0; j-- {
+				// Only hit circles are shifted
+				circle, ok := working[j].info.object.(*objects.Circle)
+				if !ok {
+					break
+				}
+
+				toBeMoved = append(toBeMoved, circle)
+			}
+
+			r.applyDecreasingShift(toBeMoved, shift)
+		}
+
+		previous = current
+	}
+}
+
+func computeModifiedPosition(current, previous, beforePrevious *workingObject) {
+	var previousAbsoluteAngle float32
+
+	if previous != nil {
+		if slider, ok := previous.info.object.(*objects.Slider); ok {
+			previousAbsoluteAngle = getSliderRotation(slider)
+		} else {
+			earliestPosition := playfieldCentre
+			if beforePrevious != nil {
+				earliestPosition = beforePrevious.info.object.GetEndPosition()
+			}
+
+			relativePosition := previous.info.object.GetStartPosition().Sub(earliestPosition)
+			previousAbsoluteAngle = math32.Atan2(relativePosition.Y, relativePosition.X)
+		}
+	}
+
+	absoluteAngle := previousAbsoluteAngle + current.info.relativeAngle
+
+	posRelativeToPrev := vector.NewVec2f(
+		current.info.distanceFromPrevious*math32.Cos(absoluteAngle),
+		current.info.distanceFromPrevious*math32.Sin(absoluteAngle),
+	)
+
+	lastEndPosition := playfieldCentre
+	if previous != nil {
+		lastEndPosition = previous.endPositionModified
+	}
+
+	posRelativeToPrev = rotateAwayFromEdge(lastEndPosition, posRelativeToPrev)
+
+	current.positionModified = lastEndPosition.Add(posRelativeToPrev)
+
+	slider, ok := current.info.object.(*objects.Slider)
+	if !ok {
+		return
+	}
+
+	absoluteAngle = math32.Atan2(posRelativeToPrev.Y, posRelativeToPrev.X)
+
+	centreOfMassOriginal := calculateCentreOfMass(slider)
+	centreOfMassModified := rotateVector(centreOfMassOriginal, current.info.rotation+absoluteAngle-getSliderRotation(slider))
+	centreOfMassModified = rotateAwayFromEdge(current.positionModified, centreOfMassModified)
+
+	relativeRotation := math32.Atan2(centreOfMassModified.Y, centreOfMassModified.X) - math32.Atan2(centreOfMassOriginal.Y, centreOfMassOriginal.X)
+	if math32.Abs(relativeRotation) > 1e-3 {
+		rotateSlider(slider, relativeRotation)
+	}
+}
+
+func (r *randomizer) clampCircleToPlayfield(w *workingObject, circle *objects.Circle) vector.Vector2f {
+	previousPosition := w.positionModified
+
+	w.positionModified = clampToPlayfieldWithPadding(w.positionModified, r.radius)
+	w.endPositionModified = w.positionModified
+
+	setPosition(circle, w.positionModified)
+
+	return w.positionModified.Sub(previousPosition)
+}
+
+func (r *randomizer) clampSliderToPlayfield(w *workingObject, slider *objects.Slider) vector.Vector2f {
+	left, top, right, bottom := r.calculatePossibleMovementBounds(slider)
+
+	// The rotation applied in computeModifiedPosition might make it impossible to fit the slider into the playfield.
+	// In this case, rotation is limited to either 0 or 180 degrees.
+	if right < left || bottom < top {
+		currentRotation := getSliderRotation(slider)
+		diff1 := getAngleDifference(w.rotationOriginal, currentRotation)
+		diff2 := getAngleDifference(w.rotationOriginal+math32.Pi, currentRotation)
+
+		if diff1 < diff2 {
+			rotateSlider(slider, w.rotationOriginal-getSliderRotation(slider))
+		} else {
+			rotateSlider(slider, w.rotationOriginal+math32.Pi-getSliderRotation(slider))
+		}
+
+		left, top, right, bottom = r.calculatePossibleMovementBounds(slider)
+	}
+
+	previousPosition := w.positionModified
+
+	// If the slider is larger than the playfield, at least make sure that the head circle is inside the playfield
+	var newX, newY float32
+
+	if right < left {
+		newX = mutils.Clamp(left, 0, playfieldWidth)
+	} else {
+		newX = mutils.Clamp(previousPosition.X, left, right)
+	}
+
+	if bottom < top {
+		newY = mutils.Clamp(top, 0, playfieldHeight)
+	} else {
+		newY = mutils.Clamp(previousPosition.Y, top, bottom)
+	}
+
+	w.positionModified = vector.NewVec2f(newX, newY)
+
+	setPosition(slider, w.positionModified)
+
+	w.endPositionModified = slider.GetEndPosition()
+
+	return w.positionModified.Sub(previousPosition)
+}
+
+// calculatePossibleMovementBounds returns the range of positions the slider can be moved to while staying in the playfield
+func (r *randomizer) calculatePossibleMovementBounds(slider *objects.Slider) (left, top, right, bottom float32) {
+	minX, minY := math32.Inf(1), math32.Inf(1)
+	maxX, maxY := math32.Inf(-1), math32.Inf(-1)
+
+	start := slider.GetStartPosition()
+
+	for _, p := range slider.GetCurve().GetPointsLazer() {
+		pos := p.Sub(start)
+
+		minX = min(minX, pos.X)
+		maxX = max(maxX, pos.X)
+
+		minY = min(minY, pos.Y)
+		maxY = max(maxY, pos.Y)
+	}
+
+	minX -= r.radius
+	minY -= r.radius
+
+	maxX += r.radius
+	maxY += r.radius
+
+	return -minX, -minY, playfieldWidth - maxX, playfieldHeight - maxY
+}
+
+func (r *randomizer) applyDecreasingShift(circles []*objects.Circle, shift vector.Vector2f) {
+	for i, circle := range circles {
+		// The first object is shifted by a vector slightly smaller than shift, the last one by a vector slightly larger than zero
+		position := circle.GetStartPosition().Add(shift.Scl(float32(len(circles)-i) / float32(len(circles)+1)))
+
+		setPosition(circle, clampToPlayfieldWithPadding(position, r.radius))
+	}
+}
+
+func setPosition(obj objects.IHitObject, position vector.Vector2f) {
+	delta := position.Sub(obj.GetStartPosition())
+
+	translate := func(p vector.Vector2f) vector.Vector2f {
+		return p.Add(delta)
+	}
+
+	switch o := obj.(type) {
+	case *objects.Circle:
+		o.Transform(translate)
+	case *objects.Slider:
+		o.Transform(translate)
+	}
+}
+
+func clampToPlayfieldWithPadding(position vector.Vector2f, padding float32) vector.Vector2f {
+	return vector.NewVec2f(
+		mutils.Clamp(position.X, padding, playfieldWidth-padding),
+		mutils.Clamp(position.Y, padding, playfieldHeight-padding),
+	)
+}
+
+// rotateAwayFromEdge rotates the vector towards playfield centre if the previous object is close to playfield's edge
+func rotateAwayFromEdge(prevObjectPos, posRelativeToPrev vector.Vector2f) vector.Vector2f {
+	const rotationRatio = 0.5
+
+	var relativeRotationDistance float32
+
+	if prevObjectPos.X < playfieldCentre.X {
+		relativeRotationDistance = max((borderDistanceX-prevObjectPos.X)/borderDistanceX, relativeRotationDistance)
+	} else {
+		relativeRotationDistance = max((prevObjectPos.X-(playfieldWidth-borderDistanceX))/borderDistanceX, relativeRotationDistance)
+	}
+
+	if prevObjectPos.Y < playfieldCentre.Y {
+		relativeRotationDistance = max((borderDistanceY-prevObjectPos.Y)/borderDistanceY, relativeRotationDistance)
+	} else {
+		relativeRotationDistance = max((prevObjectPos.Y-(playfieldHeight-borderDistanceY))/borderDistanceY, relativeRotationDistance)
+	}
+
+	return rotateVectorTowardsVector(posRelativeToPrev, playfieldCentre.Sub(prevObjectPos), min(1, relativeRotationDistance*rotationRatio))
+}
+
+func rotateVectorTowardsVector(initial, destination vector.Vector2f, rotationRatio float32) vector.Vector2f {
+	initialAngle := math32.Atan2(initial.Y, initial.X)
+	destAngle := math32.Atan2(destination.Y, destination.X)
+
+	diff := destAngle - initialAngle
+
+	for diff < -math32.Pi {
+		diff += 2 * math32.Pi
+	}
+
+	for diff > math32.Pi {
+		diff -= 2 * math32.Pi
+	}
+
+	finalAngle := initialAngle + rotationRatio*diff
+
+	return vector.NewVec2f(
+		initial.Len()*math32.Cos(finalAngle),
+		initial.Len()*math32.Sin(finalAngle),
+	)
+}
+
+func rotateVector(v vector.Vector2f, rotation float32) vector.Vector2f {
+	angle := math32.Atan2(v.Y, v.X) + rotation
+	length := v.Len()
+
+	return vector.NewVec2f(length*math32.Cos(angle), length*math32.Sin(angle))
+}
+
+func rotateSlider(slider *objects.Slider, rotation float32) {
+	start := slider.GetStartPosition()
+
+	slider.Transform(func(p vector.Vector2f) vector.Vector2f {
+		return rotateVector(p.Sub(start), rotation).Add(start)
+	})
+}
+
+func flipSliderInPlaceHorizontally(slider *objects.Slider) {
+	start := slider.GetStartPosition()
+
+	slider.Transform(func(p vector.Vector2f) vector.Vector2f {
+		return vector.NewVec2f(2*start.X-p.X, p.Y)
+	})
+}
+
+// getSliderRotation returns the angle from slider's head to the end of its path
+func getSliderRotation(slider *objects.Slider) float32 {
+	endPosition := slider.GetCurve().PointAtLazer(1).Sub(slider.GetStartPosition())
+
+	return math32.Atan2(endPosition.Y, endPosition.X)
+}
+
+func calculateCentreOfMass(slider *objects.Slider) vector.Vector2f {
+	const sampleStep = 50.0
+
+	curve := slider.GetCurve()
+	start := slider.GetStartPosition()
+
+	// Just sample the start and end positions if the slider is too short
+	pathDistance := curve.GetLengthLazer()
+	if pathDistance <= sampleStep {
+		return curve.PointAtLazer(1).Sub(start).Scl(0.5)
+	}
+
+	var sum vector.Vector2f
+	count := 0
+
+	for d := 0.0; d < pathDistance; d += sampleStep {
+		sum = sum.Add(curve.PointAtLazer(d / pathDistance).Sub(start))
+		count++
+	}
+
+	return sum.Scl(1 / float32(count))
+}
+
+func getAngleDifference(angle1, angle2 float32) float32 {
+	diff := math32.Mod(math32.Abs(angle1-angle2), math32.Pi*2)
+
+	return min(diff, math32.Pi*2-diff)
+}
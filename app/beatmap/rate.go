@@ -0,0 +1,60 @@
+package beatmap
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"math"
+)
+
+// ChangeRate rescales all times in the beatmap as if it was played at given speed, so it can be saved as a separate difficulty.
+// AR and OD are adjusted so the map plays the same as it would with a speed mod.
+// Timing points and hit objects have to be parsed beforehand. Objects' internal state is not updated, so the beatmap shouldn't be played afterwards.
+func (beatMap *BeatMap) ChangeRate(speed float64) {
+	scale := func(time float64) float64 {
+		return math.Round(time / speed)
+	}
+
+	beatMap.Timings.ChangeRate(speed)
+
+	for _, obj := range beatMap.HitObjects {
+		var hitObject *objects.HitObject
+
+		switch o := obj.(type) {
+		case *objects.Circle:
+			hitObject = o.HitObject
+		case *objects.Slider:
+			hitObject = o.HitObject
+		case *objects.Spinner:
+			hitObject = o.HitObject
+		default:
+			continue
+		}
+
+		hitObject.StartTime = scale(hitObject.StartTime)
+		hitObject.EndTime = scale(hitObject.EndTime)
+	}
+
+	for _, pause := range beatMap.Pauses {
+		pause.StartTime = scale(pause.StartTime)
+		pause.EndTime = scale(pause.EndTime)
+	}
+
+	beatMap.VideoOffset = scale(beatMap.VideoOffset)
+
+	beatMap.AudioLeadIn = int64(scale(float64(beatMap.AudioLeadIn)))
+
+	if beatMap.PreviewTime > 0 {
+		beatMap.PreviewTime = int64(scale(float64(beatMap.PreviewTime)))
+	}
+
+	beatMap.Length = int(scale(float64(beatMap.Length)))
+
+	beatMap.MinBPM *= speed
+	beatMap.MaxBPM *= speed
+
+	preempt := difficulty.DifficultyRate(beatMap.Diff.GetBaseAR(), 1800, 1200, 450) / speed
+	hit300 := difficulty.DifficultyRate(beatMap.Diff.GetBaseOD(), 80, 50, 20) / speed
+
+	beatMap.Diff.SetAR(math.Round(difficulty.DiffFromRate(preempt, 1800, 1200, 450)*100) / 100)
+	beatMap.Diff.SetOD(math.Round((80-hit300)/6*100) / 100)
+}
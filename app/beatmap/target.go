@@ -0,0 +1,100 @@
+package beatmap
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Beats closer than that to the next uninherited timing point belong to the next one
+const targetBeatLeniency = 1.0
+
+// ConvertToTargets replaces objects with Target Practice targets like stable does. A target is placed on every beat
+// between the start of the first and the end of the last object, except in breaks. Every bar starts a new combo.
+// Targets are placed where the original objects are at that time, or at the start of the next object if there's none.
+// Beatmap has to be finalized before the conversion, it's finalized again afterwards. Random mod is not applied again,
+// targets take already randomized positions of the original objects.
+func ConvertToTargets(beatMap *BeatMap, diffCalcOnly bool) {
+	if len(beatMap.HitObjects) == 0 {
+		return
+	}
+
+	original := beatMap.HitObjects
+
+	startTime := original[0].GetStartTime()
+	endTime := 0.0
+
+	for _, obj := range original {
+		endTime = max(endTime, obj.GetEndTime())
+	}
+
+	points := beatMap.Timings.GetOriginalPoints()
+	if len(points) == 0 {
+		points = []objects.TimingPoint{beatMap.Timings.GetDefault()}
+	}
+
+	targets := make([]objects.IHitObject, 0)
+
+	for i, point := range points {
+		beatLength := point.GetBaseBeatLength()
+		if beatLength <= 0 || math.IsNaN(beatLength) {
+			continue
+		}
+
+		sectionEnd := endTime
+		if i < len(points)-1 {
+			sectionEnd = min(sectionEnd, points[i+1].Time-targetBeatLeniency)
+		}
+
+		signature := max(1, point.Signature)
+
+		beat := 0
+		if point.Time < startTime {
+			beat = int(math.Ceil((startTime - point.Time - targetBeatLeniency) / beatLength))
+		}
+
+		for ; ; beat++ {
+			time := math.Floor(point.Time + float64(beat)*beatLength)
+
+			if time > sectionEnd+targetBeatLeniency {
+				break
+			}
+
+			if isInBreak(beatMap, time) {
+				continue
+			}
+
+			targets = append(targets, objects.NewTarget(targetPosition(original, time), time, beat%signature == 0 || len(targets) == 0))
+		}
+	}
+
+	beatMap.HitObjects = targets
+
+	finalizeObjects(beatMap, diffCalcOnly, false)
+}
+
+func isInBreak(beatMap *BeatMap, time float64) bool {
+	return slices.ContainsFunc(beatMap.Pauses, func(pause *Pause) bool {
+		return time > pause.GetStartTime() && time < pause.GetEndTime()
+	})
+}
+
+func targetPosition(original []objects.IHitObject, time float64) vector.Vector2f {
+	next := sort.Search(len(original), func(i int) bool {
+		return original[i].GetStartTime() > time
+	})
+
+	if next > 0 {
+		if current := original[next-1]; time <= current.GetEndTime() {
+			return current.GetPositionAt(time)
+		}
+	}
+
+	if next < len(original) {
+		return original[next].GetStartPosition()
+	}
+
+	return original[len(original)-1].GetEndPosition()
+}
@@ -0,0 +1,296 @@
+package beatmap
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/wieku/danser-go/app/audio"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"github.com/wieku/danser-go/framework/math/curves"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const writerVersion = 14
+
+// SaveBeatMap writes the beatmap to given path as an osu file format v14 file.
+// Timing points and hit objects have to be parsed beforehand. Difficulty is saved without mods and custom overrides.
+func SaveBeatMap(beatMap *BeatMap, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return EncodeBeatMap(beatMap, file)
+}
+
+// EncodeBeatMap writes the beatmap in osu file format v14
+func EncodeBeatMap(beatMap *BeatMap, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "osu file format v%d\n", writerVersion)
+
+	writeGeneral(bw, beatMap)
+	writeMetadata(bw, beatMap)
+	writeDifficulty(bw, beatMap)
+	writeEvents(bw, beatMap)
+	writeTimingPoints(bw, beatMap)
+	writeColors(bw, beatMap)
+	writeHitObjects(bw, beatMap)
+
+	return bw.Flush()
+}
+
+func writeSection(w *bufio.Writer, name string) {
+	fmt.Fprintf(w, "\n[%s]\n", name)
+}
+
+func writeGeneral(w *bufio.Writer, beatMap *BeatMap) {
+	writeSection(w, "General")
+
+	sampleSet := "Normal"
+
+	switch beatMap.Timings.BaseSet {
+	case 2:
+		sampleSet = "Soft"
+	case 3:
+		sampleSet = "Drum"
+	}
+
+	fmt.Fprintf(w, "AudioFilename: %s\n", beatMap.Audio)
+	fmt.Fprintf(w, "AudioLeadIn: %d\n", beatMap.AudioLeadIn)
+	fmt.Fprintf(w, "PreviewTime: %d\n", beatMap.PreviewTime)
+	fmt.Fprintf(w, "SampleSet: %s\n", sampleSet)
+	fmt.Fprintf(w, "StackLeniency: %s\n", formatFloat(beatMap.StackLeniency))
+	fmt.Fprintf(w, "Mode: %d\n", beatMap.Mode)
+
+	for _, line := range beatMap.GeneralExtra {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func writeMetadata(w *bufio.Writer, beatMap *BeatMap) {
+	writeSection(w, "Metadata")
+
+	fmt.Fprintf(w, "Title:%s\n", beatMap.Name)
+	fmt.Fprintf(w, "TitleUnicode:%s\n", beatMap.NameUnicode)
+	fmt.Fprintf(w, "Artist:%s\n", beatMap.Artist)
+	fmt.Fprintf(w, "ArtistUnicode:%s\n", beatMap.ArtistUnicode)
+	fmt.Fprintf(w, "Creator:%s\n", beatMap.Creator)
+	fmt.Fprintf(w, "Version:%s\n", beatMap.Difficulty)
+	fmt.Fprintf(w, "Source:%s\n", beatMap.Source)
+	fmt.Fprintf(w, "Tags:%s\n", beatMap.Tags)
+	fmt.Fprintf(w, "BeatmapID:%d\n", beatMap.ID)
+	fmt.Fprintf(w, "BeatmapSetID:%d\n", beatMap.SetID)
+}
+
+func writeDifficulty(w *bufio.Writer, beatMap *BeatMap) {
+	writeSection(w, "Difficulty")
+
+	fmt.Fprintf(w, "HPDrainRate:%s\n", formatFloat(beatMap.Diff.GetBaseHP()))
+	fmt.Fprintf(w, "CircleSize:%s\n", formatFloat(beatMap.Diff.GetBaseCS()))
+	fmt.Fprintf(w, "OverallDifficulty:%s\n", formatFloat(beatMap.Diff.GetBaseOD()))
+	fmt.Fprintf(w, "ApproachRate:%s\n", formatFloat(beatMap.Diff.GetBaseAR()))
+	fmt.Fprintf(w, "SliderMultiplier:%s\n", formatFloat(beatMap.SliderMultiplier))
+	fmt.Fprintf(w, "SliderTickRate:%s\n", formatFloat(beatMap.Timings.TickRate))
+}
+
+func writeEvents(w *bufio.Writer, beatMap *BeatMap) {
+	writeSection(w, "Events")
+
+	if beatMap.Bg != "" {
+		fmt.Fprintf(w, "0,0,\"%s\",0,0\n", beatMap.Bg)
+	}
+
+	if beatMap.Video != "" {
+		fmt.Fprintf(w, "1,%s,\"%s\"\n", formatFloat(beatMap.VideoOffset), beatMap.Video)
+	}
+
+	for _, pause := range beatMap.Pauses {
+		fmt.Fprintf(w, "2,%s,%s\n", formatFloat(pause.StartTime), formatFloat(pause.EndTime))
+	}
+
+	for _, line := range beatMap.StoryboardEvents {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func writeTimingPoints(w *bufio.Writer, beatMap *BeatMap) {
+	writeSection(w, "TimingPoints")
+
+	for _, point := range beatMap.Timings.GetPoints() {
+		uninherited := 1
+		if point.Inherited {
+			uninherited = 0
+		}
+
+		effects := 0
+
+		if point.Kiai {
+			effects |= 1
+		}
+
+		if point.OmitFirstBarLine {
+			effects |= 8
+		}
+
+		fmt.Fprintf(w, "%s,%s,%d,%d,%d,%d,%d,%d\n",
+			formatFloat(point.Time),
+			formatFloat(point.GetRawBeatLength()),
+			point.Signature,
+			point.SampleSet,
+			point.SampleIndex,
+			int(math.Round(point.SampleVolume*100)),
+			uninherited,
+			effects,
+		)
+	}
+}
+
+func writeColors(w *bufio.Writer, beatMap *BeatMap) {
+	if len(beatMap.ComboColors) == 0 && beatMap.SliderBorder == nil && beatMap.SliderTrackOverride == nil {
+		return
+	}
+
+	writeSection(w, "Colours") //nolint:misspell
+
+	for i, clr := range beatMap.ComboColors {
+		fmt.Fprintf(w, "Combo%d : %s\n", i+1, formatColor(clr))
+	}
+
+	if beatMap.SliderTrackOverride != nil {
+		fmt.Fprintf(w, "SliderTrackOverride : %s\n", formatColor(*beatMap.SliderTrackOverride))
+	}
+
+	if beatMap.SliderBorder != nil {
+		fmt.Fprintf(w, "SliderBorder : %s\n", formatColor(*beatMap.SliderBorder))
+	}
+}
+
+func writeHitObjects(w *bufio.Writer, beatMap *BeatMap) {
+	writeSection(w, "HitObjects")
+
+	for _, obj := range beatMap.HitObjects {
+		var line string
+
+		switch o := obj.(type) {
+		case *objects.Circle:
+			line = fmt.Sprintf("%s,%d,%s", formatCommon(obj, objects.CIRCLE), o.GetSample(), formatHitSound(o.BasicHitSound))
+		case *objects.Slider:
+			line = formatSlider(o)
+		case *objects.Spinner:
+			line = fmt.Sprintf("%s,%d,%s,%s", formatCommon(obj, objects.SPINNER), o.GetSample(), formatFloat(o.GetEndTime()), formatHitSound(o.BasicHitSound))
+		default:
+			continue
+		}
+
+		fmt.Fprintln(w, line)
+	}
+}
+
+// formatCommon returns position, time and type of the object
+func formatCommon(obj objects.IHitObject, objType objects.Type) string {
+	if obj.IsNewCombo() {
+		objType |= objects.NEWCOMBO
+	}
+
+	objType |= objects.Type(obj.GetColorOffset()&7) << 4
+
+	pos := obj.GetStartPosition()
+
+	return fmt.Sprintf("%s,%s,%s,%d", formatFloat32(pos.X), formatFloat32(pos.Y), formatFloat(obj.GetStartTime()), objType)
+}
+
+func formatSlider(slider *objects.Slider) string {
+	samples, sampleSets, additionSets := slider.GetEdgeSamples()
+
+	edgeSounds := make([]string, len(samples))
+	edgeSets := make([]string, len(samples))
+
+	for i := range samples {
+		edgeSounds[i] = strconv.Itoa(samples[i])
+		edgeSets[i] = fmt.Sprintf("%d:%d", sampleSets[i], additionSets[i])
+	}
+
+	return fmt.Sprintf("%s,%d,%s,%d,%s,%s,%s,%s",
+		formatCommon(slider, objects.SLIDER),
+		slider.GetBaseSample(),
+		formatCurve(slider.GetCurveDefs()),
+		slider.RepeatCount,
+		formatFloat(slider.GetPixelLength()),
+		strings.Join(edgeSounds, "|"),
+		strings.Join(edgeSets, "|"),
+		formatHitSound(slider.BasicHitSound),
+	)
+}
+
+// formatCurve writes curve segments in a way parser understands them. Type of the next segment is placed before the point shared by both segments.
+func formatCurve(defs []curves.CurveDef) string {
+	var tokens []string
+
+	for i, def := range defs {
+		if i == 0 {
+			tokens = append(tokens, curveTypeToken(def.CurveType))
+
+			// Parser skips the first point if it's the same as start position, so it has to be doubled
+			if len(def.Points) > 1 && def.Points[1] == def.Points[0] {
+				tokens = append(tokens, formatVector(def.Points[0]))
+			}
+		}
+
+		// First point is slider's start position or the point shared with previous segment
+		for j := 1; j < len(def.Points); j++ {
+			if j == len(def.Points)-1 && i+1 < len(defs) {
+				tokens = append(tokens, curveTypeToken(defs[i+1].CurveType))
+			}
+
+			tokens = append(tokens, formatVector(def.Points[j]))
+		}
+	}
+
+	return strings.Join(tokens, "|")
+}
+
+func curveTypeToken(cType curves.CType) string {
+	switch cType {
+	case curves.CCirArc:
+		return "P"
+	case curves.CLine:
+		return "L"
+	case curves.CCatmull:
+		return "C"
+	default:
+		return "B"
+	}
+}
+
+func formatHitSound(info audio.HitSoundInfo) string {
+	return fmt.Sprintf("%d:%d:%d:%d:", info.SampleSet, info.AdditionSet, info.CustomIndex, int(math.Round(info.CustomVolume*100)))
+}
+
+func formatVector(vec vector.Vector2f) string {
+	return formatFloat32(vec.X) + ":" + formatFloat32(vec.Y)
+}
+
+func formatFloat32(value float32) string {
+	return strconv.FormatFloat(float64(value), 'f', -1, 32)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatColor(clr color2.Color) string {
+	return fmt.Sprintf("%d,%d,%d", int(math.Round(float64(clr.R)*255)), int(math.Round(float64(clr.G)*255)), int(math.Round(float64(clr.B)*255)))
+}
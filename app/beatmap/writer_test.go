@@ -0,0 +1,140 @@
+package beatmap
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/env"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Number of points along the slider compared between parsed and reparsed beatmap
+const sliderSamplePoints = 16
+
+func TestMain(m *testing.M) {
+	env.Init("danser")
+
+	songsDir, err := os.MkdirTemp("", "danser-writer")
+	if err != nil {
+		panic(err)
+	}
+
+	settings.General.OsuSongsDir = songsDir
+
+	code := m.Run()
+
+	_ = os.RemoveAll(songsDir)
+
+	os.Exit(code)
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "roundtrip.osu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalPath := filepath.Join(settings.General.GetSongsDir(), "original.osu")
+
+	if err = os.WriteFile(originalPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	original := loadTestMap(t, originalPath)
+
+	encodedPath := filepath.Join(settings.General.GetSongsDir(), "encoded.osu")
+
+	if err = SaveBeatMap(original, encodedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	reparsed := loadTestMap(t, encodedPath)
+
+	if len(reparsed.HitObjects) != len(original.HitObjects) {
+		t.Fatalf("expected %d objects, got %d", len(original.HitObjects), len(reparsed.HitObjects))
+	}
+
+	for i, obj := range original.HitObjects {
+		if expected, actual := describeObject(obj), describeObject(reparsed.HitObjects[i]); expected != actual {
+			t.Errorf("object %d differs:\nexpected: %s\nactual:   %s", i, expected, actual)
+		}
+	}
+
+	if len(reparsed.Pauses) != len(original.Pauses) {
+		t.Errorf("expected %d breaks, got %d", len(original.Pauses), len(reparsed.Pauses))
+	}
+
+	if len(reparsed.ComboColors) != len(original.ComboColors) {
+		t.Errorf("expected %d combo colors, got %d", len(original.ComboColors), len(reparsed.ComboColors))
+	}
+
+	if reparsed.AudioLeadIn != original.AudioLeadIn {
+		t.Errorf("expected audio lead-in %d, got %d", original.AudioLeadIn, reparsed.AudioLeadIn)
+	}
+
+	if !slices.Equal(reparsed.GeneralExtra, original.GeneralExtra) {
+		t.Errorf("expected general settings %q, got %q", original.GeneralExtra, reparsed.GeneralExtra)
+	}
+
+	if len(original.StoryboardEvents) == 0 || !slices.Equal(reparsed.StoryboardEvents, original.StoryboardEvents) {
+		t.Errorf("expected storyboard events %q, got %q", original.StoryboardEvents, reparsed.StoryboardEvents)
+	}
+
+	if expected, actual := describeDifficulty(original), describeDifficulty(reparsed); expected != actual {
+		t.Errorf("expected difficulty %s, got %s", expected, actual)
+	}
+}
+
+func loadTestMap(t *testing.T, path string) *BeatMap {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	beatMap := ParseBeatMapFile(file)
+	if beatMap == nil {
+		t.Fatalf("failed to parse %s", path)
+	}
+
+	ParseTimingPointsAndPauses(beatMap)
+	ParseObjects(beatMap, false, false)
+
+	return beatMap
+}
+
+func describeDifficulty(beatMap *BeatMap) string {
+	return fmt.Sprintf("HP%v CS%v OD%v AR%v", beatMap.Diff.GetBaseHP(), beatMap.Diff.GetBaseCS(), beatMap.Diff.GetBaseOD(), beatMap.Diff.GetBaseAR())
+}
+
+// describeObject returns everything writer has to preserve about the object
+func describeObject(obj objects.IHitObject) string {
+	desc := fmt.Sprintf("%T time=%v-%v pos=%v-%v newCombo=%t colorOffset=%d combo=%d/%d",
+		obj, obj.GetStartTime(), obj.GetEndTime(), obj.GetStartPosition(), obj.GetEndPosition(),
+		obj.IsNewCombo(), obj.GetColorOffset(), obj.GetComboSet(), obj.GetComboNumber())
+
+	switch o := obj.(type) {
+	case *objects.Circle:
+		desc += fmt.Sprintf(" sample=%d hitSound=%+v", o.GetSample(), o.BasicHitSound)
+	case *objects.Spinner:
+		desc += fmt.Sprintf(" sample=%d hitSound=%+v", o.GetSample(), o.BasicHitSound)
+	case *objects.Slider:
+		samples, sampleSets, additionSets := o.GetEdgeSamples()
+
+		desc += fmt.Sprintf(" sample=%d hitSound=%+v curve=%v repeats=%d length=%v edges=%v/%v/%v",
+			o.GetBaseSample(), o.BasicHitSound, o.GetCurveDefs(), o.RepeatCount, o.GetPixelLength(), samples, sampleSets, additionSets)
+
+		for i := 0; i <= sliderSamplePoints; i++ {
+			time := o.GetStartTime() + o.GetDuration()*float64(i)/sliderSamplePoints
+			desc += fmt.Sprintf(" %v", o.GetPositionAt(time))
+		}
+	}
+
+	return desc
+}
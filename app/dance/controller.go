@@ -8,6 +8,8 @@ import (
 	"github.com/wieku/danser-go/app/dance/spinners"
 	"github.com/wieku/danser-go/app/graphics"
 	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"math"
 	"sort"
 	"strings"
 )
@@ -39,6 +41,8 @@ func (controller *GenericController) InitCursors() {
 
 	counter := make(map[string]int)
 
+	sections := controller.resolveSections()
+
 	// Mover initialization
 	for i := range controller.cursors {
 		controller.cursors[i] = graphics.NewCursor()
@@ -52,6 +56,10 @@ func (controller *GenericController) InitCursors() {
 
 		controller.schedulers[i] = schedulers.NewGenericScheduler(moverCtor, i, counter[mName])
 
+		if gScheduler, ok := controller.schedulers[i].(*schedulers.GenericScheduler); ok && len(sections) > 0 {
+			gScheduler.SetSections(sections, settings.CursorDance.SectionCrossfade)
+		}
+
 		counter[mName]++
 	}
 
@@ -166,6 +174,58 @@ func (controller *GenericController) InitCursors() {
 	}
 }
 
+// resolveSections converts mover sections from settings to time ranges of the current map
+func (controller *GenericController) resolveSections() (sections []*schedulers.Section) {
+	hitObjects := controller.bMap.HitObjects
+
+	for _, config := range settings.CursorDance.Sections {
+		moverCtor, _ := movers.GetMoverCtorByName(config.Mover)
+
+		newSection := func(start, end float64) *schedulers.Section {
+			return &schedulers.Section{
+				Start:             start,
+				End:               end,
+				Mover:             moverCtor,
+				MoverID:           config.MoverSettings,
+				SliderDance:       config.SliderDance,
+				RandomSliderDance: config.RandomSliderDance,
+				Spinner:           spinners.GetMoverCtorByName(config.Spinner),
+			}
+		}
+
+		switch config.Type {
+		case "kiai":
+			kiaiStart := math.NaN()
+
+			for _, point := range controller.bMap.Timings.GetPoints() {
+				if point.Kiai && math.IsNaN(kiaiStart) {
+					kiaiStart = point.Time
+				} else if !point.Kiai && !math.IsNaN(kiaiStart) {
+					sections = append(sections, newSection(kiaiStart, point.Time-1))
+					kiaiStart = math.NaN()
+				}
+			}
+
+			if !math.IsNaN(kiaiStart) {
+				sections = append(sections, newSection(kiaiStart, math.Inf(1)))
+			}
+		case "objects":
+			if len(hitObjects) == 0 {
+				continue
+			}
+
+			first := mutils.Clamp(int(config.Start), 0, len(hitObjects)-1)
+			last := mutils.Clamp(int(config.End), first, len(hitObjects)-1)
+
+			sections = append(sections, newSection(hitObjects[first].GetStartTime(), hitObjects[last].GetEndTime()))
+		default:
+			sections = append(sections, newSection(config.Start, config.End))
+		}
+	}
+
+	return
+}
+
 func (controller *GenericController) Update(time float64, delta float64) {
 	for i := range controller.cursors {
 		controller.schedulers[i].Update(time)
@@ -0,0 +1,148 @@
+package input
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math/rand"
+	"time"
+)
+
+// Jumps done faster than this (in real time) get proportionally bigger aim error
+const aimReferenceSpacing = 150.0
+
+type aimPoint struct {
+	time   float64
+	offset vector.Vector2f
+}
+
+// Humanizer generates timing and aim errors for objects, so NaturalInputProcessor doesn't hit everything perfectly
+type Humanizer struct {
+	offsets map[objects.IHitObject]float64
+	missed  map[objects.IHitObject]bool
+
+	aimPoints []aimPoint
+	aimIndex  int
+
+	lastBase   vector.Vector2f
+	lastOutput vector.Vector2f
+}
+
+// NewHumanizer generates errors for given objects using settings.CursorDance.Humanize. Index makes tag cursors differ when the seed is fixed.
+func NewHumanizer(objs []objects.IHitObject, diff *difficulty.Difficulty, speed float64, index int) *Humanizer {
+	config := settings.CursorDance.Humanize
+
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	random := rand.New(rand.NewSource(seed + int64(index)))
+
+	humanizer := &Humanizer{
+		offsets: make(map[objects.IHitObject]float64),
+		missed:  make(map[objects.IHitObject]bool),
+	}
+
+	// UR is 10 times the standard deviation of hit errors in real time
+	deviation := config.UnstableRate / 10 * speed
+	bias := config.HitOffset * speed
+
+	var previous objects.IHitObject
+
+	for _, o := range objs {
+		if !isHumanized(o) {
+			continue
+		}
+
+		humanizer.offsets[o] = random.NormFloat64()*deviation + bias
+		humanizer.missed[o] = random.Float64() < config.MissRate
+
+		errorDeviation := 0.0
+
+		if previous != nil {
+			distance := float64(o.GetStackedStartPositionMod(diff).Dst(previous.GetStackedEndPositionMod(diff)))
+			spacing := max(o.GetStartTime()-previous.GetEndTime(), 1) / speed
+
+			errorDeviation = config.AimJitter * distance * max(1, aimReferenceSpacing/spacing)
+		}
+
+		humanizer.aimPoints = append(humanizer.aimPoints, aimPoint{
+			time:   o.GetStartTime(),
+			offset: vector.NewVec2f(float32(random.NormFloat64()*errorDeviation), float32(random.NormFloat64()*errorDeviation)),
+		})
+
+		previous = o
+	}
+
+	return humanizer
+}
+
+// isHumanized returns true if the object needs to be aimed at and clicked
+func isHumanized(o objects.IHitObject) bool {
+	if c, ok := o.(*objects.Circle); ok {
+		return !c.SliderPoint || c.SliderPointStart
+	}
+
+	_, ok := o.(*objects.Slider)
+
+	return ok
+}
+
+// GetTimes returns times at which the key should be pressed and released for the object
+func (humanizer *Humanizer) GetTimes(o objects.IHitObject, startTime, endTime float64) (float64, float64) {
+	offset, ok := humanizer.offsets[o]
+	if !ok {
+		return startTime, endTime
+	}
+
+	if endTime == startTime {
+		endTime += offset
+	}
+
+	startTime += offset
+
+	return startTime, max(endTime, startTime)
+}
+
+// IsMissed returns true if the object shouldn't be clicked at all
+func (humanizer *Humanizer) IsMissed(o objects.IHitObject) bool {
+	return humanizer.missed[o]
+}
+
+// ApplyAim moves the position set by the mover by the aim error, which changes smoothly from one object to the next
+func (humanizer *Humanizer) ApplyAim(position vector.Vector2f, time float64) vector.Vector2f {
+	if len(humanizer.aimPoints) == 0 {
+		return position
+	}
+
+	// Mover didn't set a new position since the last update, don't add the error twice
+	if position == humanizer.lastOutput {
+		position = humanizer.lastBase
+	}
+
+	for humanizer.aimIndex < len(humanizer.aimPoints)-1 && humanizer.aimPoints[humanizer.aimIndex+1].time <= time {
+		humanizer.aimIndex++
+	}
+
+	var aimError vector.Vector2f
+
+	current := humanizer.aimPoints[humanizer.aimIndex]
+
+	if time <= current.time || humanizer.aimIndex == len(humanizer.aimPoints)-1 {
+		aimError = current.offset
+	} else {
+		next := humanizer.aimPoints[humanizer.aimIndex+1]
+
+		progress := float32(mutils.Clamp((time-current.time)/max(next.time-current.time, 1), 0, 1))
+
+		aimError = current.offset.Lerp(next.offset, progress)
+	}
+
+	humanizer.lastBase = position
+	humanizer.lastOutput = position.Add(aimError)
+
+	return humanizer.lastOutput
+}
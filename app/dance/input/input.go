@@ -1,6 +1,7 @@
 package input
 
 import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/app/beatmap/objects"
 	"github.com/wieku/danser-go/app/dance/movers"
 	"github.com/wieku/danser-go/app/graphics"
@@ -21,6 +22,8 @@ type NaturalInputProcessor struct {
 	releaseRightAt float64
 	mover          movers.MultiPointMover
 	speed          float64
+
+	humanizer *Humanizer
 }
 
 func NewNaturalInputProcessor(objs []objects.IHitObject, cursor *graphics.Cursor, mover movers.MultiPointMover, speed float64) *NaturalInputProcessor {
@@ -38,6 +41,14 @@ func NewNaturalInputProcessor(objs []objects.IHitObject, cursor *graphics.Cursor
 	return processor
 }
 
+// NewHumanizedInputProcessor creates NaturalInputProcessor that makes timing and aim errors configured in settings.CursorDance.Humanize
+func NewHumanizedInputProcessor(objs []objects.IHitObject, cursor *graphics.Cursor, mover movers.MultiPointMover, diff *difficulty.Difficulty, index int) *NaturalInputProcessor {
+	processor := NewNaturalInputProcessor(objs, cursor, mover, diff.GetSpeed())
+	processor.humanizer = NewHumanizer(objs, diff, diff.GetSpeed(), index)
+
+	return processor
+}
+
 func (processor *NaturalInputProcessor) Update(time float64) {
 	if len(processor.queue) > 0 {
 		for i := 0; i < len(processor.queue); i++ {
@@ -51,10 +62,21 @@ func (processor *NaturalInputProcessor) Update(time float64) {
 			gStartTime := processor.mover.GetObjectsStartTime(g)
 			gEndTime := processor.mover.GetObjectsEndTime(g)
 
+			if processor.humanizer != nil {
+				gStartTime, gEndTime = processor.humanizer.GetTimes(g, gStartTime, gEndTime)
+			}
+
 			if gStartTime > time {
 				break
 			}
 
+			if processor.humanizer != nil && processor.humanizer.IsMissed(g) && processor.lastTime < gStartTime {
+				processor.queue = append(processor.queue[:i], processor.queue[i+1:]...)
+				i--
+
+				continue
+			}
+
 			if processor.lastTime < gStartTime && time >= gStartTime {
 				startTime := gStartTime
 				endTime := gEndTime
@@ -119,5 +141,9 @@ func (processor *NaturalInputProcessor) Update(time float64) {
 	processor.cursor.LeftKey = time < processor.releaseLeftAt
 	processor.cursor.RightKey = time < processor.releaseRightAt
 
+	if processor.humanizer != nil {
+		processor.cursor.SetPos(processor.humanizer.ApplyAim(processor.cursor.RawPosition, time))
+	}
+
 	processor.lastTime = time
 }
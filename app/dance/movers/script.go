@@ -0,0 +1,173 @@
+package movers
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/env"
+	"github.com/wieku/danser-go/framework/math/expression"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// scriptInputs are variables available to mover scripts. Angles are in radians, slider angles are NaN if the object is not a slider.
+var scriptInputs = []string{
+	"t",         // progress of the movement from 0 to 1
+	"time",      // current time in milliseconds
+	"startTime", // end time of the previous object
+	"endTime",   // start time of the next object
+	"duration",
+	"startX", "startY", // end position of the previous object
+	"endX", "endY", // start position of the next object
+	"distance",
+	"angle",                    // direction from start to end
+	"startAngle",               // direction in which previous slider ends
+	"endAngle",                 // direction from which next slider starts
+	"startSlider", "endSlider", // 1 if the object is a slider
+	"index", // number of the movement, starting from 0
+	"id",    // index of used Script settings
+	"radius", "ar", "od", "cs", "speed", "preempt",
+	"width", "height", // playfield size
+}
+
+// ScriptMover moves the cursor along a path computed by a user script, see expression package for the syntax.
+// Script has to assign x and y variables. If the script fails to load, the cursor moves in a straight line.
+type ScriptMover struct {
+	*basicMover
+
+	program *expression.Program
+
+	startPos vector.Vector2f
+	endPos   vector.Vector2f
+
+	index int
+}
+
+func NewScriptMover() MultiPointMover {
+	return &ScriptMover{basicMover: &basicMover{}}
+}
+
+func (mover *ScriptMover) Reset(diff *difficulty.Difficulty, id int) {
+	mover.basicMover.Reset(diff, id)
+
+	mover.index = 0
+	mover.program = nil
+
+	config := settings.CursorDance.MoverSettings.Script[id%len(settings.CursorDance.MoverSettings.Script)]
+
+	if config.File == "" {
+		log.Println("ScriptMover: Script file is not set, using linear movement")
+		return
+	}
+
+	path := config.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(env.DataDir(), path)
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		log.Println("ScriptMover: Failed to read the script, using linear movement:", err)
+		return
+	}
+
+	program, err := expression.Compile(string(source), scriptInputs...)
+	if err != nil {
+		log.Println("ScriptMover: Failed to compile", path+", using linear movement:", err)
+		return
+	}
+
+	if !program.Has("x") || !program.Has("y") {
+		log.Println("ScriptMover: Script", path, "doesn't assign x and y, using linear movement")
+		return
+	}
+
+	program.Set("id", float64(id))
+	program.Set("radius", diff.CircleRadius)
+	program.Set("ar", diff.ARReal)
+	program.Set("od", diff.GetOD())
+	program.Set("cs", diff.GetCS())
+	program.Set("speed", diff.GetSpeed())
+	program.Set("preempt", diff.Preempt)
+	program.Set("width", 512)
+	program.Set("height", 384)
+
+	mover.program = program
+}
+
+func (mover *ScriptMover) SetObjects(objs []objects.IHitObject) int {
+	start, end := objs[0], objs[1]
+
+	mover.startTime = start.GetEndTime()
+	mover.endTime = end.GetStartTime()
+
+	mover.startPos = start.GetStackedEndPositionMod(mover.diff)
+	mover.endPos = end.GetStackedStartPositionMod(mover.diff)
+
+	if mover.program != nil {
+		startAngle, endAngle := math.NaN(), math.NaN()
+		startSlider, endSlider := 0.0, 0.0
+
+		if s, ok := start.(objects.ILongObject); ok {
+			startAngle = float64(s.GetEndAngleMod(mover.diff))
+		}
+
+		if s, ok := end.(objects.ILongObject); ok {
+			endAngle = float64(s.GetStartAngleMod(mover.diff))
+		}
+
+		if _, ok := start.(*objects.Slider); ok {
+			startSlider = 1
+		}
+
+		if _, ok := end.(*objects.Slider); ok {
+			endSlider = 1
+		}
+
+		p := mover.program
+
+		p.Set("startTime", mover.startTime)
+		p.Set("endTime", mover.endTime)
+		p.Set("duration", mover.endTime-mover.startTime)
+		p.Set("startX", float64(mover.startPos.X))
+		p.Set("startY", float64(mover.startPos.Y))
+		p.Set("endX", float64(mover.endPos.X))
+		p.Set("endY", float64(mover.endPos.Y))
+		p.Set("distance", float64(mover.startPos.Dst(mover.endPos)))
+		p.Set("angle", float64(mover.endPos.AngleRV(mover.startPos)))
+		p.Set("startAngle", startAngle)
+		p.Set("endAngle", endAngle)
+		p.Set("startSlider", startSlider)
+		p.Set("endSlider", endSlider)
+		p.Set("index", float64(mover.index))
+	}
+
+	mover.index++
+
+	return 2
+}
+
+func (mover *ScriptMover) Update(time float64) vector.Vector2f {
+	t := mutils.Clamp((time-mover.startTime)/(mover.endTime-mover.startTime), 0, 1)
+
+	if mover.program == nil {
+		return mover.startPos.Lerp(mover.endPos, float32(t))
+	}
+
+	mover.program.Set("t", t)
+	mover.program.Set("time", time)
+	mover.program.Run()
+
+	x, y := mover.program.Get("x"), mover.program.Get("y")
+
+	// Broken script shouldn't make the cursor disappear
+	if math.IsNaN(x+y) || math.IsInf(x+y, 0) {
+		return mover.startPos.Lerp(mover.endPos, float32(t))
+	}
+
+	return vector.NewVec2d(x, y).Copy32()
+}
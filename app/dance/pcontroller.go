@@ -40,6 +40,9 @@ type PlayerController struct {
 
 	quickRestart     bool
 	quickRestartTime float64
+
+	recorder    *ReplayRecorder
+	replaySaved bool
 }
 
 func NewPlayerController() Controller {
@@ -58,6 +61,10 @@ func (controller *PlayerController) InitCursors() {
 	controller.window = glfw.GetCurrentContext()
 	controller.ruleset = osu.NewOsuRuleset(controller.bMap, controller.cursors, []*difficulty.Difficulty{controller.bMap.Diff.Clone()})
 
+	if settings.Gameplay.SavePlayReplays {
+		controller.recorder = NewReplayRecorder(controller.bMap, controller.cursors[0])
+	}
+
 	if !controller.bMap.Diff.CheckModActive(difficulty.Relax) {
 		input2.RegisterListener(controller.KeyEvent)
 	} else {
@@ -165,16 +172,36 @@ func (controller *PlayerController) Update(time float64, delta float64) {
 		controller.cursors[0].IsReplayFrame = false
 	}
 
+	if controller.recorder != nil && !controller.replaySaved {
+		controller.recorder.Update(int64(time))
+	}
+
 	controller.ruleset.UpdateClickFor(controller.cursors[0], int64(time))
 	controller.ruleset.UpdateNormalFor(controller.cursors[0], int64(time), false)
 	controller.ruleset.UpdatePostFor(controller.cursors[0], int64(time), false)
 	controller.ruleset.Update(int64(time))
 
+	if controller.recorder != nil && !controller.replaySaved && (controller.ruleset.IsEnded() || controller.ruleset.IsFailed(controller.cursors[0])) {
+		controller.saveReplay()
+	}
+
 	controller.lastTime = time
 
 	controller.cursors[0].Update(delta)
 }
 
+func (controller *PlayerController) saveReplay() {
+	controller.replaySaved = true
+
+	path, err := controller.recorder.Save(controller.ruleset.GetScore(controller.cursors[0]), controller.ruleset.GetPlayerDifficulty(controller.cursors[0]), settings.Gameplay.GetPlayReplaysDir(), controller.recorder.ReplayName())
+	if err != nil {
+		log.Println("Failed to save replay:", err)
+		return
+	}
+
+	log.Println("Replay saved to:", path)
+}
+
 func (controller *PlayerController) GetRuleset() *osu.OsuRuleSet {
 	return controller.ruleset
 }
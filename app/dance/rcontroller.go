@@ -9,7 +9,9 @@ import (
 	"github.com/wieku/danser-go/app/dance/schedulers"
 	"github.com/wieku/danser-go/app/dance/spinners"
 	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets"
 	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/taiko"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/framework/env"
 	"github.com/wieku/danser-go/framework/files"
@@ -20,6 +22,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -39,6 +42,7 @@ type RpData struct {
 	Grade     osu.Grade
 	scoreID   int64
 	ScoreTime time.Time
+	Team      *settings.KnockoutTeam
 }
 
 type subControl struct {
@@ -66,8 +70,10 @@ type ReplayController struct {
 	replays     []RpData
 	cursors     []*graphics.Cursor
 	controllers []*subControl
-	ruleset     *osu.OsuRuleSet
+	ruleset     rulesets.Ruleset
 	lastTime    float64
+
+	teams map[*rplpa.Replay]*settings.KnockoutTeam
 }
 
 func NewReplayController() Controller {
@@ -76,6 +82,21 @@ func NewReplayController() Controller {
 	return &ReplayController{lastTime: -200}
 }
 
+// NewReplayControllerWithReplays creates a controller that plays given replays on the beatmap instead of looking for replay files.
+// SetBeatMap must not be called on it.
+func NewReplayControllerWithReplays(beatMap *beatmap.BeatMap, replays []*rplpa.Replay) *ReplayController {
+	controller := &ReplayController{
+		bMap:     beatMap,
+		lastTime: -200,
+	}
+
+	controller.addReplays(replays, false)
+
+	settings.PLAYERS = len(controller.replays)
+
+	return controller
+}
+
 func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 	controller.bMap = beatMap
 
@@ -115,6 +136,38 @@ func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 		}
 	}
 
+	if !localReplay {
+		candidates = controller.filterTargetPractice(candidates)
+		candidates = controller.filterRandom(candidates)
+	}
+
+	controller.addReplays(candidates, localReplay)
+
+	if beatMap.Mode != rulesets.ModeOsu {
+		// cursor dance can't play other modes
+		if len(controller.controllers) == 0 {
+			panic("No compatible replays found")
+		}
+	} else if !localReplay && (settings.Knockout.AddDanser || len(controller.controllers) == 0) {
+		control := NewSubControl()
+		control.diff = beatMap.Diff.Clone()
+
+		control.danceController = NewGenericController()
+		control.danceController.SetBeatMap(beatMap)
+
+		controller.replays = append([]RpData{{settings.Knockout.DanserName, settings.Knockout.DanserName, control.diff.GetModString(), control.diff.Mods, 100, 0, 0, osu.NONE, -1, time.Now(), nil}}, controller.replays...)
+		controller.controllers = append([]*subControl{control}, controller.controllers...)
+
+		if len(candidates) == 0 {
+			controller.bMap.Diff.AddMod(difficulty.Autoplay)
+		}
+	}
+
+	settings.PLAYERS = len(controller.replays)
+}
+
+// addReplays creates sub controllers for given replays. Mods of local replay are replaced with beatmap's mods if they differ.
+func (controller *ReplayController) addReplays(candidates []*rplpa.Replay, localReplay bool) {
 	displayedMods := ^difficulty.ParseMods(settings.Knockout.HideMods)
 
 	for i, replay := range candidates {
@@ -122,7 +175,7 @@ func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 
 		control := NewSubControl()
 
-		control.diff = beatMap.Diff.Clone()
+		control.diff = controller.bMap.Diff.Clone()
 		control.diff.SetMods(difficulty.None)
 
 		if replay.ScoreInfo != nil && replay.ScoreInfo.Mods != nil && len(replay.ScoreInfo.Mods) > 0 {
@@ -141,8 +194,8 @@ func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 			control.diff.Mods |= difficulty.Lazer
 		}
 
-		if localReplay && !beatMap.Diff.Equals(control.diff) {
-			control.diff.SetMods2(beatMap.Diff.ExportMods2())
+		if localReplay && !controller.bMap.Diff.Equals(control.diff) {
+			control.diff.SetMods2(controller.bMap.Diff.ExportMods2())
 			control.modifiedMods = true
 		}
 
@@ -155,29 +208,107 @@ func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 		control.newHandling = replay.OsuVersion >= 20190506 // This was when slider scoring was changed, so *I think* replay handling as well: https://osu.ppy.sh/home/changelog/cuttingedge/20190506
 		control.oldSpinners = replay.OsuVersion < 20190510  // This was when spinner scoring was changed: https://osu.ppy.sh/home/changelog/cuttingedge/20190510.2
 
-		controller.replays = append(controller.replays, RpData{replay.Username, replay.Username + string(rune(unicode.MaxRune-i)), (control.diff.Mods & displayedMods).String(), control.diff.Mods, 100, 0, int64(mxCombo), osu.NONE, replay.ScoreID, replay.Timestamp})
+		controller.replays = append(controller.replays, RpData{replay.Username, replay.Username + string(rune(unicode.MaxRune-i)), (control.diff.Mods & displayedMods).String(), control.diff.Mods, 100, 0, int64(mxCombo), osu.NONE, replay.ScoreID, replay.Timestamp, controller.teams[replay]})
 		controller.controllers = append(controller.controllers, control)
 
 		log.Println("\tExpected score:", replay.Score)
 		log.Println("\tReplay loaded!")
 	}
+}
 
-	if !localReplay && (settings.Knockout.AddDanser || len(controller.controllers) == 0) {
-		control := NewSubControl()
-		control.diff = beatMap.Diff.Clone()
+// filterTargetPractice excludes replays that don't match the beatmap, because Target Practice replaces its objects with targets.
+// If all replays use Target Practice and the beatmap hasn't been converted yet, it's converted here.
+func (controller *ReplayController) filterTargetPractice(candidates []*rplpa.Replay) []*rplpa.Replay {
+	isTarget := func(replay *rplpa.Replay) bool {
+		return difficulty.Modifier(replay.Mods).Active(difficulty.Target)
+	}
 
-		control.danceController = NewGenericController()
-		control.danceController.SetBeatMap(beatMap)
+	converted := controller.bMap.Diff.CheckModActive(difficulty.Target)
 
-		controller.replays = append([]RpData{{settings.Knockout.DanserName, settings.Knockout.DanserName, control.diff.GetModString(), control.diff.Mods, 100, 0, 0, osu.NONE, -1, time.Now()}}, controller.replays...)
-		controller.controllers = append([]*subControl{control}, controller.controllers...)
+	if !converted && len(candidates) > 0 && !slices.ContainsFunc(candidates, func(replay *rplpa.Replay) bool { return !isTarget(replay) }) {
+		log.Println("All replays use Target Practice, converting the beatmap...")
 
-		if len(candidates) == 0 {
-			controller.bMap.Diff.AddMod(difficulty.Autoplay)
+		controller.bMap.Diff.AddMod(difficulty.Target)
+		beatmap.ConvertToTargets(controller.bMap, false)
+
+		converted = true
+	}
+
+	return slices.DeleteFunc(candidates, func(replay *rplpa.Replay) bool {
+		if isTarget(replay) != converted {
+			log.Println("Excluding for Target Practice mismatch:", replay.Username)
+			return true
+		}
+
+		return false
+	})
+}
+
+// filterRandom excludes replays which don't match beatmap's Random mod settings, because objects can be repositioned only with one seed.
+// If all replays use Random with the same settings and the beatmap doesn't use it yet, the beatmap is randomized here.
+// Replays with Random mod that doesn't carry the seed can't be reproduced, so they're always excluded.
+func (controller *ReplayController) filterRandom(candidates []*rplpa.Replay) []*rplpa.Replay {
+	candidates = slices.DeleteFunc(candidates, func(replay *rplpa.Replay) bool {
+		if mod, ok := getRandomMod(replay); ok {
+			if _, ok = mod.Settings["seed"]; !ok {
+				log.Println("Excluding for Random mod without seed:", replay.Username)
+				return true
+			}
+		}
+
+		return false
+	})
+
+	config, randomized := difficulty.GetModConfig[difficulty.RandomSettings](controller.bMap.Diff)
+
+	if !randomized && len(candidates) > 0 {
+		mod, ok := getRandomMod(candidates[0])
+
+		if ok && !slices.ContainsFunc(candidates[1:], func(replay *rplpa.Replay) bool {
+			mod2, ok2 := getRandomMod(replay)
+			return !ok2 || getRandomSettings(mod2) != getRandomSettings(mod)
+		}) {
+			log.Println("All replays use Random with the same seed, randomizing the beatmap...")
+
+			controller.bMap.Diff.SetMods2(append(controller.bMap.Diff.ExportMods2(), mod))
+			beatmap.ApplyRandom(controller.bMap)
+
+			config, randomized = getRandomSettings(mod), true
 		}
 	}
 
-	settings.PLAYERS = len(controller.replays)
+	return slices.DeleteFunc(candidates, func(replay *rplpa.Replay) bool {
+		mod, ok := getRandomMod(replay)
+
+		if ok != randomized || (ok && getRandomSettings(mod) != config) {
+			log.Println("Excluding for Random seed mismatch:", replay.Username)
+			return true
+		}
+
+		return false
+	})
+}
+
+// getRandomMod returns lazer's Random mod with its settings. Stable replays don't carry the seed, so they're treated as not randomized.
+func getRandomMod(replay *rplpa.Replay) (rplpa.ModInfo, bool) {
+	if replay.ScoreInfo != nil {
+		for _, mod := range replay.ScoreInfo.Mods {
+			if difficulty.ParseFromAcronym(mod.Acronym).Active(difficulty.Random) {
+				return *mod, true
+			}
+		}
+	}
+
+	return rplpa.ModInfo{}, false
+}
+
+func getRandomSettings(mod rplpa.ModInfo) difficulty.RandomSettings {
+	diff := difficulty.NewDifficulty(5, 5, 5, 5)
+	diff.SetMods2([]rplpa.ModInfo{mod})
+
+	config, _ := difficulty.GetModConfig[difficulty.RandomSettings](diff)
+
+	return config
 }
 
 func organizeReplays() {
@@ -220,7 +351,9 @@ func organizeReplays() {
 func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay) {
 	excludedMods := difficulty.ParseMods(settings.Knockout.ExcludeMods)
 
-	tryAddReplay := func(path string, modExclude bool) {
+	controller.teams = make(map[*rplpa.Replay]*settings.KnockoutTeam)
+
+	tryAddReplay := func(path string, modExclude bool, team *settings.KnockoutTeam) {
 		log.Println("Loading: ", path)
 
 		data, err := os.ReadFile(path)
@@ -240,7 +373,12 @@ func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay)
 			return
 		}
 
-		if !difficulty.Modifier(replayD.Mods).Compatible() || difficulty.Modifier(replayD.Mods).Active(difficulty.Target) {
+		if int64(replayD.PlayMode) != controller.bMap.Mode {
+			log.Println("Excluding for different game mode:", replayD.Username)
+			return
+		}
+
+		if !difficulty.Modifier(replayD.Mods).Compatible() {
 			log.Println("Excluding for incompatible mods:", replayD.Username)
 			return
 		}
@@ -256,11 +394,20 @@ func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay)
 		}
 
 		candidates = append(candidates, replayD)
+
+		if team != nil {
+			controller.teams[replayD] = team
+		}
 	}
 
 	if settings.KNOCKOUTREPLAYS != nil && len(settings.KNOCKOUTREPLAYS) > 0 {
-		for _, r := range settings.KNOCKOUTREPLAYS {
-			tryAddReplay(r, false)
+		for i, r := range settings.KNOCKOUTREPLAYS {
+			var team *settings.KnockoutTeam
+			if i < len(settings.KNOCKOUTTEAMS) {
+				team = settings.KNOCKOUTTEAMS[i]
+			}
+
+			tryAddReplay(r, false, team)
 		}
 	} else {
 		replayDir := filepath.Join(env.DataDir(), replaysMaster, controller.bMap.MD5)
@@ -268,14 +415,18 @@ func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay)
 		replayPaths, _ := files.SearchFiles(replayDir, "*.osr", 0)
 
 		for _, replayPath := range replayPaths {
-			tryAddReplay(replayPath, true)
+			tryAddReplay(replayPath, true, nil)
 		}
 	}
 
 	return
 }
 
-func loadFrames(subController *subControl, frames []*rplpa.ReplayData) {
+// TimewarpFrameTime is the median frame time in ms, after applying rate changing mods, below which the replay was probably timewarped
+const TimewarpFrameTime = 13.0
+
+// CleanFrames removes mania seed frame and the incorrect first frame with 0 delta from replay frames
+func CleanFrames(frames []*rplpa.ReplayData) []*rplpa.ReplayData {
 	// Remove mania seed frame if its present
 	for i, frame := range frames {
 		if frame.Time == -12345 {
@@ -289,33 +440,50 @@ func loadFrames(subController *subControl, frames []*rplpa.ReplayData) {
 		frames = frames[1:]
 	}
 
-	times := make([]float64, 0, len(frames))
+	return frames
+}
 
-	duration := 0
+// MedianFrameTime returns median delta of frames with non-negative delta, in beatmap time
+func MedianFrameTime(frames []*rplpa.ReplayData) float64 {
+	times := make([]float64, 0, len(frames))
 
 	for _, frame := range frames {
 		if frame.Time >= 0 {
 			times = append(times, float64(frame.Time))
 		}
+	}
 
-		duration += int(frame.Time)
+	if len(times) == 0 {
+		return 0
 	}
 
 	sort.Float64s(times)
 
 	l := len(times)
 
-	meanFrameTime := times[l/2]
+	median := times[l/2]
 
 	if l%2 == 0 {
-		meanFrameTime = (times[l/2] + times[l/2-1]) / 2
+		median = (times[l/2] + times[l/2-1]) / 2
 	}
 
-	meanFrameTime = subController.diff.GetModifiedTime(meanFrameTime)
+	return median
+}
+
+func loadFrames(subController *subControl, frames []*rplpa.ReplayData) {
+	frames = CleanFrames(frames)
+
+	duration := 0
+
+	for _, frame := range frames {
+		duration += int(frame.Time)
+	}
+
+	meanFrameTime := subController.diff.GetModifiedTime(MedianFrameTime(frames))
 
 	log.Println(fmt.Sprintf("\tMedian cv frametime: %.2fms", meanFrameTime))
 
-	if meanFrameTime <= 13 && !subController.diff.CheckModActive(difficulty.Autoplay|difficulty.Relax|difficulty.Relax2) {
+	if meanFrameTime <= TimewarpFrameTime && !subController.diff.CheckModActive(difficulty.Autoplay|difficulty.Relax|difficulty.Relax2) {
 		log.Println("\tWARNING!!! THIS REPLAY WAS PROBABLY TIMEWARPED!!!")
 	}
 
@@ -382,11 +550,13 @@ func (controller *ReplayController) InitCursors() {
 		diffs = append(diffs, c.diff)
 	}
 
-	controller.ruleset = osu.NewOsuRuleset(controller.bMap, controller.cursors, diffs)
+	controller.ruleset = newRuleset(controller.bMap, controller.cursors, diffs)
+
+	osuRuleset, isOsu := controller.ruleset.(*osu.OsuRuleSet)
 
 	for i, c := range controller.controllers {
-		if controller.replays[i].ModsV.Active(difficulty.Relax) {
-			controller.controllers[i].relaxController = input.NewRelaxInputProcessor(controller.ruleset, controller.cursors[i])
+		if controller.replays[i].ModsV.Active(difficulty.Relax) && isOsu {
+			controller.controllers[i].relaxController = input.NewRelaxInputProcessor(osuRuleset, controller.cursors[i])
 		}
 
 		if controller.replays[i].ModsV.Active(difficulty.Relax2) {
@@ -483,7 +653,9 @@ func (controller *ReplayController) processLazer(i int, c *subControl, nTime flo
 			controller.cursors[i].CurrentFrameTime = replayTime
 			controller.cursors[i].IsReplayFrame = true
 
-			if !isRelax {
+			if controller.bMap.Mode == rulesets.ModeTaiko {
+				setTaikoKeys(controller.cursors[i], frame.KeyPressed)
+			} else if !isRelax {
 				controller.cursors[i].LeftKey = frame.KeyPressed.LeftClick && frame.KeyPressed.Key1
 				controller.cursors[i].RightKey = frame.KeyPressed.RightClick && frame.KeyPressed.Key2
 
@@ -571,7 +743,9 @@ func (controller *ReplayController) processStable(i int, c *subControl, nTime fl
 			controller.cursors[i].CurrentFrameTime = replayTime
 			controller.cursors[i].IsReplayFrame = true
 
-			if !isRelax {
+			if controller.bMap.Mode == rulesets.ModeTaiko {
+				setTaikoKeys(controller.cursors[i], frame.KeyPressed)
+			} else if !isRelax {
 				controller.cursors[i].LeftKey = frame.KeyPressed.LeftClick && frame.KeyPressed.Key1
 				controller.cursors[i].RightKey = frame.KeyPressed.RightClick && frame.KeyPressed.Key2
 
@@ -641,6 +815,27 @@ func (controller *ReplayController) processStable(i int, c *subControl, nTime fl
 	}
 }
 
+// newRuleset creates a ruleset matching beatmap's game mode
+func newRuleset(bMap *beatmap.BeatMap, cursors []*graphics.Cursor, diffs []*difficulty.Difficulty) rulesets.Ruleset {
+	if bMap.Mode == rulesets.ModeTaiko {
+		return taiko.NewTaikoRuleset(bMap, cursors, diffs)
+	}
+
+	return osu.NewOsuRuleset(bMap, cursors, diffs)
+}
+
+// setTaikoKeys maps stable taiko replay buttons to cursor buttons. Unlike in osu!standard, keyboard keys don't set mouse buttons:
+// M1 - left centre, M2 - left rim, K1 - right centre, K2 - right rim
+func setTaikoKeys(cursor *graphics.Cursor, keys *rplpa.KeyPressed) {
+	cursor.LeftMouse = keys.LeftClick
+	cursor.RightMouse = keys.RightClick
+	cursor.LeftKey = keys.Key1
+	cursor.RightKey = keys.Key2
+
+	cursor.LeftButton = keys.LeftClick || keys.Key1
+	cursor.RightButton = keys.RightClick || keys.Key2
+}
+
 func (controller *ReplayController) GetCursors() []*graphics.Cursor {
 	return controller.cursors
 }
@@ -649,7 +844,7 @@ func (controller *ReplayController) GetReplays() []RpData {
 	return controller.replays
 }
 
-func (controller *ReplayController) GetRuleset() *osu.OsuRuleSet {
+func (controller *ReplayController) GetRuleset() rulesets.Ruleset {
 	return controller.ruleset
 }
 
@@ -0,0 +1,168 @@
+package dance
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/rplpa"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Last osu!stable version before lazer's 1000-years-in-the-future versioning, makes ReplayController use current slider and spinner handling
+const recordedReplayVersion = 20250306
+
+// ReplayRecorder captures input of a single cursor and saves it as osu!stable replay
+type ReplayRecorder struct {
+	bMap   *beatmap.BeatMap
+	cursor *graphics.Cursor
+
+	frames    []*rplpa.ReplayData
+	frameTime float64
+	lastTime  int64
+	lastKeys  rplpa.KeyPressed
+}
+
+func NewReplayRecorder(bMap *beatmap.BeatMap, cursor *graphics.Cursor) *ReplayRecorder {
+	return &ReplayRecorder{
+		bMap:      bMap,
+		cursor:    cursor,
+		frameTime: 1000.0 / 60 * bMap.Diff.GetSpeed(), // osu!stable records at 60 fps in real time
+		frames: []*rplpa.ReplayData{ // osu!stable always starts with this frame, ReplayController skips it
+			{
+				MouseX:     256,
+				MouseY:     -500,
+				KeyPressed: &rplpa.KeyPressed{},
+			},
+		},
+	}
+}
+
+// Update adds a frame if cursor's buttons changed or enough time passed since the last one. Has to be called after cursor's input is updated.
+func (recorder *ReplayRecorder) Update(time int64) {
+	keys := rplpa.KeyPressed{
+		LeftClick:  recorder.cursor.LeftButton,
+		RightClick: recorder.cursor.RightButton,
+		Key1:       recorder.cursor.LeftKey,
+		Key2:       recorder.cursor.RightKey,
+		Smoke:      recorder.cursor.SmokeKey,
+	}
+
+	keysChanged := keys != recorder.lastKeys
+
+	if len(recorder.frames) > 1 && time <= recorder.lastTime {
+		if keysChanged { // Input changed within the same millisecond, overwrite the last frame
+			last := recorder.frames[len(recorder.frames)-1]
+			last.MouseX = float64(recorder.cursor.RawPosition.X)
+			last.MouseY = float64(recorder.cursor.RawPosition.Y)
+			*last.KeyPressed = keys
+
+			recorder.lastKeys = keys
+		}
+
+		return
+	}
+
+	if len(recorder.frames) > 1 && !keysChanged && float64(time-recorder.lastTime) < recorder.frameTime {
+		return
+	}
+
+	delta := time
+	if len(recorder.frames) > 1 {
+		delta -= recorder.lastTime
+	}
+
+	recorder.frames = append(recorder.frames, &rplpa.ReplayData{
+		Time:       float64(delta),
+		MouseX:     float64(recorder.cursor.RawPosition.X),
+		MouseY:     float64(recorder.cursor.RawPosition.Y),
+		KeyPressed: &keys,
+	})
+
+	recorder.lastTime = time
+	recorder.lastKeys = keys
+}
+
+// Save writes recorded frames with given score to {dir}/{name}.osr and returns the full path
+func (recorder *ReplayRecorder) Save(score osu.Score, diff *difficulty.Difficulty, dir, name string) (string, error) {
+	data, err := recorder.Encode(score, diff)
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, sanitizeFileName(name)+".osr")
+
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Encode returns recorded frames with given score in osu!stable's replay format
+func (recorder *ReplayRecorder) Encode(score osu.Score, diff *difficulty.Difficulty) ([]byte, error) {
+	if len(recorder.frames) < 2 {
+		return nil, errors.New("no frames recorded")
+	}
+
+	if diff.CheckModActive(difficulty.Lazer) {
+		log.Println("ReplayRecorder: osu!stable replays can't store lazer mod settings, they will be replayed with osu!stable mods")
+	}
+
+	mods := diff.Mods
+	if mods.Active(difficulty.Daycore) {
+		mods = (mods & ^difficulty.Daycore) | difficulty.HalfTime
+	}
+
+	mods &= difficulty.LastMod - 1
+
+	replay := &rplpa.Replay{
+		PlayMode:   rplpa.OSU,
+		OsuVersion: recordedReplayVersion,
+		BeatmapMD5: recorder.bMap.MD5,
+		Username:   recorder.cursor.Name,
+		Count300:   uint16(score.Count300),
+		Count100:   uint16(score.Count100),
+		Count50:    uint16(score.Count50),
+		CountGeki:  uint16(score.CountGeki),
+		CountKatu:  uint16(score.CountKatu),
+		CountMiss:  uint16(score.CountMiss),
+		Score:      int32(score.Score),
+		MaxCombo:   uint16(score.Combo),
+		Fullcombo:  score.PerfectCombo,
+		Mods:       uint32(mods),
+		Timestamp:  recorder.cursor.ScoreTime,
+		ReplayData: recorder.frames,
+	}
+
+	hash := md5.Sum([]byte(fmt.Sprintf("%dosu%s%s%d%s", replay.MaxCombo, replay.Username, replay.BeatmapMD5, replay.Score, score.Grade)))
+	replay.ReplayMD5 = hex.EncodeToString(hash[:])
+
+	return rplpa.WriteReplay(replay)
+}
+
+// ReplayName returns file name in osu!stable's replay export format
+func (recorder *ReplayRecorder) ReplayName() string {
+	return fmt.Sprintf("%s - %s - %s [%s] (%s) Osu", recorder.cursor.Name, recorder.bMap.Artist, recorder.bMap.Name, recorder.bMap.Difficulty, recorder.cursor.ScoreTime.Format("2006-01-02_15-04-05"))
+}
+
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+
+		return r
+	}, name)
+}
@@ -8,10 +8,24 @@ import (
 	"github.com/wieku/danser-go/app/dance/spinners"
 	"github.com/wieku/danser-go/app/graphics"
 	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/math/mutils"
 	"github.com/wieku/danser-go/framework/math/vector"
 	"math/rand"
 )
 
+// Section is a time range of the map that uses its own movers instead of the default ones
+type Section struct {
+	Start, End float64
+
+	Mover   func() movers.MultiPointMover
+	MoverID int
+
+	SliderDance       bool
+	RandomSliderDance bool
+
+	Spinner func() spinners.SpinnerMover
+}
+
 type GenericScheduler struct {
 	cursor   *graphics.Cursor
 	queue    []objects.IHitObject
@@ -21,12 +35,88 @@ type GenericScheduler struct {
 	diff     *difficulty.Difficulty
 	index    int
 	id       int
+
+	sections []*Section
+	movers   []movers.MultiPointMover // default mover followed by movers of sections
+
+	crossfade bool
+	fadeMover movers.MultiPointMover
+	fadeStart float64
+	fadeEnd   float64
 }
 
 func NewGenericScheduler(mover func() movers.MultiPointMover, index, id int) Scheduler {
 	return &GenericScheduler{mover: mover(), index: index, id: id}
 }
 
+// SetSections sets map sections that use different movers, has to be called before Init.
+// If crossfade is true, movement between two sections is blended from the previous mover to the next one.
+func (scheduler *GenericScheduler) SetSections(sections []*Section, crossfade bool) {
+	scheduler.sections = sections
+	scheduler.crossfade = crossfade
+
+	scheduler.movers = []movers.MultiPointMover{scheduler.mover}
+
+	for _, section := range sections {
+		scheduler.movers = append(scheduler.movers, section.Mover())
+	}
+}
+
+// sectionOf returns the index of the first section containing the object, -1 if it's not in any
+func (scheduler *GenericScheduler) sectionOf(o objects.IHitObject) int {
+	for i, section := range scheduler.sections {
+		if o.GetStartTime() >= section.Start && o.GetStartTime() <= section.End {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// moverOf returns the mover used in the section of the object
+func (scheduler *GenericScheduler) moverOf(o objects.IHitObject) movers.MultiPointMover {
+	if len(scheduler.sections) == 0 {
+		return scheduler.mover
+	}
+
+	return scheduler.movers[scheduler.sectionOf(o)+1]
+}
+
+// setObjects passes the objects to the mover of the section the movement ends in.
+// Objects from following sections are cut off, so one mover never handles two sections at once.
+func (scheduler *GenericScheduler) setObjects(objs []objects.IHitObject) int {
+	if len(scheduler.sections) == 0 || len(objs) < 2 {
+		return scheduler.mover.SetObjects(objs)
+	}
+
+	section := scheduler.sectionOf(objs[1])
+
+	for i := 2; i < len(objs); i++ {
+		if scheduler.sectionOf(objs[i]) != section {
+			objs = objs[:i]
+			break
+		}
+	}
+
+	mover := scheduler.movers[section+1]
+
+	if mover != scheduler.mover {
+		scheduler.fadeMover = nil
+
+		if scheduler.crossfade {
+			scheduler.fadeMover = scheduler.mover
+			scheduler.fadeMover.SetObjects(objs[:2])
+
+			scheduler.fadeStart = objs[0].GetEndTime()
+			scheduler.fadeEnd = objs[1].GetStartTime()
+		}
+
+		scheduler.mover = mover
+	}
+
+	return mover.SetObjects(objs)
+}
+
 func (scheduler *GenericScheduler) Init(objs []objects.IHitObject, diff *difficulty.Difficulty, cursor *graphics.Cursor, spinnerMoverCtor func() spinners.SpinnerMover, initKeys bool) {
 	scheduler.diff = diff
 	scheduler.cursor = cursor
@@ -34,17 +124,33 @@ func (scheduler *GenericScheduler) Init(objs []objects.IHitObject, diff *difficu
 
 	scheduler.mover.Reset(diff, scheduler.id)
 
+	for i, section := range scheduler.sections {
+		scheduler.movers[i+1].Reset(diff, section.MoverID)
+	}
+
 	config := settings.CursorDance.Movers[scheduler.index%len(settings.CursorDance.Movers)]
 
 	// Slider dance / random slider dance resolving
 	for i := 0; i < len(scheduler.queue); i++ {
-		scheduler.queue = PreprocessQueue(i, scheduler.queue, (config.SliderDance && !config.RandomSliderDance) || (config.RandomSliderDance && rand.Intn(2) == 0))
+		sliderDance, randomSliderDance := config.SliderDance, config.RandomSliderDance
+
+		if section := scheduler.sectionOf(scheduler.queue[i]); section >= 0 {
+			sliderDance, randomSliderDance = scheduler.sections[section].SliderDance, scheduler.sections[section].RandomSliderDance
+		}
+
+		scheduler.queue = PreprocessQueue(i, scheduler.queue, (sliderDance && !randomSliderDance) || (randomSliderDance && rand.Intn(2) == 0))
 	}
 
 	// Convert spinners to pseudo spinners that have beginning and ending angles, simplifies mover codes as well
 	for i := 0; i < len(scheduler.queue); i++ {
 		if s, ok := scheduler.queue[i].(*objects.Spinner); ok {
-			scheduler.queue[i] = spinners.NewSpinner(s, spinnerMoverCtor, scheduler.index)
+			ctor := spinnerMoverCtor
+
+			if section := scheduler.sectionOf(s); section >= 0 {
+				ctor = scheduler.sections[section].Spinner
+			}
+
+			scheduler.queue[i] = spinners.NewSpinner(s, ctor, scheduler.index)
 		}
 	}
 
@@ -94,7 +200,11 @@ func (scheduler *GenericScheduler) Init(objs []objects.IHitObject, diff *difficu
 	}
 
 	if initKeys {
-		scheduler.input = input.NewNaturalInputProcessor(scheduler.queue, cursor, scheduler.mover, diff.GetSpeed())
+		if settings.CursorDance.Humanize.Enabled {
+			scheduler.input = input.NewHumanizedInputProcessor(scheduler.queue, cursor, scheduler.mover, diff, scheduler.index)
+		} else {
+			scheduler.input = input.NewNaturalInputProcessor(scheduler.queue, cursor, scheduler.mover, diff.GetSpeed())
+		}
 	}
 
 	scheduler.queue = append([]objects.IHitObject{objects.DummyCircle(vector.NewVec2f(100, 100), -500)}, scheduler.queue...)
@@ -102,7 +212,7 @@ func (scheduler *GenericScheduler) Init(objs []objects.IHitObject, diff *difficu
 	scheduler.cursor.SetPos(vector.NewVec2f(100, 100))
 	scheduler.cursor.Update(0)
 
-	toRemove := scheduler.mover.SetObjects(scheduler.queue) - 1
+	toRemove := scheduler.setObjects(scheduler.queue) - 1
 	scheduler.queue = scheduler.queue[toRemove:]
 }
 
@@ -126,9 +236,9 @@ func (scheduler *GenericScheduler) Update(time float64) {
 			if scheduler.lastTime <= gStartTime || time <= gEndTime {
 				if scheduler.lastTime <= gStartTime { // brief movement lock for ExGon mover
 					useMover = false
-					scheduler.cursor.SetPos(scheduler.mover.GetObjectsStartPosition(g))
+					scheduler.cursor.SetPos(scheduler.moverOf(g).GetObjectsStartPosition(g))
 				} else {
-					scheduler.cursor.SetPos(scheduler.mover.GetObjectsPosition(time, g))
+					scheduler.cursor.SetPos(scheduler.moverOf(g).GetObjectsPosition(time, g))
 				}
 			}
 
@@ -146,7 +256,7 @@ func (scheduler *GenericScheduler) Update(time float64) {
 				toRemove := 1
 
 				if upperLimit-i > 1 {
-					toRemove = scheduler.mover.SetObjects(scheduler.queue[i:upperLimit]) - 1
+					toRemove = scheduler.setObjects(scheduler.queue[i:upperLimit]) - 1
 				}
 
 				scheduler.queue = append(scheduler.queue[:i], scheduler.queue[i+toRemove:]...)
@@ -155,7 +265,15 @@ func (scheduler *GenericScheduler) Update(time float64) {
 		}
 
 		if useMover && scheduler.mover.GetEndTime() >= time {
-			scheduler.cursor.SetPos(scheduler.mover.Update(time))
+			position := scheduler.mover.Update(time)
+
+			if scheduler.fadeMover != nil && time < scheduler.fadeEnd {
+				progress := mutils.Clamp((time-scheduler.fadeStart)/max(scheduler.fadeEnd-scheduler.fadeStart, 1), 0, 1)
+
+				position = scheduler.fadeMover.Update(time).Lerp(position, float32(progress))
+			}
+
+			scheduler.cursor.SetPos(position)
 		}
 	}
 
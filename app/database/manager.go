@@ -171,17 +171,17 @@ func LoadBeatmaps(skipDatabaseCheck bool, importListener ImportListener) []*beat
 
 	allMaps := loadBeatmapsFromDatabase()
 
-	stdMaps := make([]*beatmap.BeatMap, 0, len(allMaps)/2)
+	modeMaps := make([]*beatmap.BeatMap, 0, len(allMaps)/2)
 
 	for _, b := range allMaps {
-		if b.Mode == 0 {
-			stdMaps = append(stdMaps, b)
+		if b.Mode == settings.PLAYMODE {
+			modeMaps = append(modeMaps, b)
 		}
 	}
 
-	log.Println("DatabaseManager: Loaded", len(stdMaps), "total.")
+	log.Println("DatabaseManager: Loaded", len(modeMaps), "total.")
 
-	return stdMaps
+	return modeMaps
 }
 
 func unpackMaps() (dirs []string) {
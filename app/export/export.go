@@ -0,0 +1,215 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/ffmpeg"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/bass"
+	"github.com/wieku/danser-go/framework/files"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"io"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const audioName = "audio.mp3"
+
+// RateChange renders beatmap's audio at given speed and pitch and saves it together with rescaled beatmap as a new beatmap set in Songs directory.
+// ar, od, cs and hp override the difficulty of the new map if they're not NaN.
+// Timing points and hit objects have to be parsed beforehand and BASS has to be initialized in offscreen mode. Returns path to the new .osu file.
+func RateChange(beatMap *beatmap.BeatMap, speed, pitch, ar, od, cs, hp float64) (string, error) {
+	if speed <= 0 || math.IsNaN(speed) {
+		return "", fmt.Errorf("invalid speed: %g", speed)
+	}
+
+	suffix := fmt.Sprintf(" (%gx)", speed)
+	if pitch != 1 {
+		suffix = fmt.Sprintf(" (%gx, %gp)", speed, pitch)
+	}
+
+	outDir := filepath.Join(settings.General.GetSongsDir(), beatMap.Dir+suffix)
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", err
+	}
+
+	log.Println("Copying beatmap files...")
+
+	if err := copySetFiles(beatMap, outDir); err != nil {
+		log.Println("Failed to copy beatmap files:", err)
+	}
+
+	log.Println("Rendering audio...")
+
+	if err := renderAudio(beatMap, speed, pitch, filepath.Join(outDir, audioName)); err != nil {
+		return "", err
+	}
+
+	if beatMap.Video != "" {
+		log.Println("Rendering video...")
+
+		if err := renderVideo(beatMap, speed, outDir); err != nil {
+			log.Println("Failed to render video:", err)
+		}
+	}
+
+	if err := exportStoryboard(beatMap, speed, outDir); err != nil {
+		log.Println("Failed to export storyboard:", err)
+	}
+
+	beatMap.ChangeRate(speed)
+
+	if !math.IsNaN(ar) {
+		beatMap.Diff.SetAR(ar)
+	}
+
+	if !math.IsNaN(od) {
+		beatMap.Diff.SetOD(od)
+	}
+
+	if !math.IsNaN(cs) {
+		beatMap.Diff.SetCS(cs)
+	}
+
+	if !math.IsNaN(hp) {
+		beatMap.Diff.SetHP(hp)
+	}
+
+	clampDifficulty(beatMap)
+
+	// Storyboard is saved to .osb file with rescaled times
+	beatMap.StoryboardEvents = nil
+
+	beatMap.Audio = audioName
+	beatMap.Difficulty += suffix
+	beatMap.ID = 0
+	beatMap.SetID = -1
+
+	name := fmt.Sprintf("%s - %s (%s) [%s].osu", beatMap.Artist, beatMap.Name, beatMap.Creator, beatMap.Difficulty)
+	path := filepath.Join(outDir, files.FixName(name))
+
+	if err := beatmap.SaveBeatMap(beatMap, path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func renderAudio(beatMap *beatmap.BeatMap, speed, pitch float64, path string) error {
+	audioPath, err := beatMap.GetAudioFile()
+	if err != nil {
+		return err
+	}
+
+	track := bass.NewTrack(audioPath)
+	if track == nil {
+		return errors.New("failed to load audio file: " + audioPath)
+	}
+
+	track.SetTempo(speed)
+	track.SetPitch(pitch)
+	track.PlayV(1)
+
+	defer track.Stop()
+
+	return ffmpeg.EncodeAudio(bass.NewMixerReader(track.GetLength()/speed), path)
+}
+
+func renderVideo(beatMap *beatmap.BeatMap, speed float64, outDir string) error {
+	videoPath, err := beatMap.GetRelatedFile(beatMap.Video)
+	if err != nil {
+		return err
+	}
+
+	dstPath := filepath.Join(outDir, beatMap.Video)
+
+	if err = os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return err
+	}
+
+	return ffmpeg.ChangeVideoRate(videoPath, dstPath, speed)
+}
+
+// clampDifficulty keeps difficulty settings in the range .osu files can hold, parser would clamp them on load anyway
+func clampDifficulty(beatMap *beatmap.BeatMap) {
+	clamp := func(name string, value float64, set func(float64)) {
+		if clamped := mutils.Clamp(value, 0, 10); clamped != value {
+			log.Println(fmt.Sprintf("%s %g can't be saved in .osu file, %g is used instead. The map won't play the same as with a speed mod.", name, value, clamped))
+			set(clamped)
+		}
+	}
+
+	clamp("AR", beatMap.Diff.GetBaseAR(), beatMap.Diff.SetAR)
+	clamp("OD", beatMap.Diff.GetBaseOD(), beatMap.Diff.SetOD)
+	clamp("CS", beatMap.Diff.GetBaseCS(), beatMap.Diff.SetCS)
+	clamp("HP", beatMap.Diff.GetBaseHP(), beatMap.Diff.SetHP)
+}
+
+// copySetFiles copies background, hitsounds, storyboard images and other files of beatmap's set.
+// Difficulty and storyboard files, audio and video are skipped, they're saved separately.
+func copySetFiles(beatMap *beatmap.BeatMap, outDir string) error {
+	setDir := filepath.Join(settings.General.GetSongsDir(), beatMap.Dir)
+
+	skipped := make(map[string]bool)
+
+	if audioPath, err := beatMap.GetAudioFile(); err == nil {
+		skipped[audioPath] = true
+	}
+
+	if beatMap.Video != "" {
+		if videoPath, err := beatMap.GetRelatedFile(beatMap.Video); err == nil {
+			skipped[videoPath] = true
+		}
+	}
+
+	return filepath.WalkDir(setDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || skipped[path] {
+			return nil
+		}
+
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".osu" || ext == ".osb" {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(setDir, path)
+		if err != nil {
+			return err
+		}
+
+		return copyFile(path, filepath.Join(outDir, relPath))
+	})
+}
+
+func copyFile(srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	if err = os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return err
+	}
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+
+	return err
+}
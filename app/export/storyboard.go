@@ -0,0 +1,161 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/files"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Events saved by the .osu writer, they're not copied to the storyboard
+var writtenEvents = []string{"Background", "0", "Video", "1", "Break", "2"}
+
+// exportStoryboard saves storyboard events from beatmap's .osu and .osb files as a single .osb file with times rescaled to given speed.
+// It has to be called before beatmap.ChangeRate, nothing is saved if the beatmap doesn't have a storyboard.
+func exportStoryboard(beatMap *beatmap.BeatMap, speed float64, outDir string) error {
+	var variables, events []string
+
+	osuEvents, _, err := readStoryboard(filepath.Join(settings.General.GetSongsDir(), beatMap.Dir, beatMap.File))
+	if err != nil {
+		return err
+	}
+
+	for _, line := range osuEvents {
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "_") && slices.Contains(writtenEvents, strings.SplitN(line, ",", 2)[0]) {
+			continue
+		}
+
+		events = append(events, rescaleEvent(line, speed))
+	}
+
+	osbName := files.FixName(fmt.Sprintf("%s - %s (%s).osb", beatMap.Artist, beatMap.Name, beatMap.Creator))
+
+	if osbPath, err2 := beatMap.GetRelatedFile(osbName); err2 == nil {
+		osbEvents, osbVariables, err3 := readStoryboard(osbPath)
+		if err3 != nil {
+			return err3
+		}
+
+		for _, line := range osbEvents {
+			events = append(events, rescaleEvent(line, speed))
+		}
+
+		variables = osbVariables
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	file, err := os.Create(filepath.Join(outDir, osbName))
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+
+	if len(variables) > 0 {
+		fmt.Fprintln(w, "[Variables]")
+
+		for _, line := range variables {
+			fmt.Fprintln(w, line)
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "[Events]")
+
+	for _, line := range events {
+		fmt.Fprintln(w, line)
+	}
+
+	return w.Flush()
+}
+
+// readStoryboard returns lines of Events and Variables sections, comments and empty lines are skipped
+func readStoryboard(path string) (events, variables []string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer file.Close()
+
+	scanner := files.NewScannerBuf(file, 10*1024*1024)
+
+	var currentSection string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "//") || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			currentSection = strings.TrimSuffix(strings.TrimPrefix(trimmed, "["), "]")
+			continue
+		}
+
+		switch currentSection {
+		case "Events":
+			events = append(events, line)
+		case "Variables":
+			variables = append(variables, line)
+		}
+	}
+
+	return events, variables, scanner.Err()
+}
+
+// rescaleEvent rescales times of a single storyboard event or command. Times that use variables are left as they are.
+func rescaleEvent(line string, speed float64) string {
+	trimmed := strings.TrimLeft(line, " _")
+	indent := line[:len(line)-len(trimmed)]
+
+	split := strings.Split(trimmed, ",")
+
+	var times []int
+
+	if indent == "" {
+		switch split[0] {
+		case "Sample", "5", "Video", "1":
+			times = []int{1}
+		case "Animation", "6":
+			if len(split) > 7 {
+				if delay, err := strconv.ParseFloat(split[7], 64); err == nil {
+					split[7] = strconv.FormatFloat(delay/speed, 'f', -1, 64)
+				}
+			}
+		}
+	} else {
+		switch split[0] {
+		case "L":
+			times = []int{1}
+		default: // Triggers have start and end times at the same place as commands
+			times = []int{2, 3}
+		}
+	}
+
+	for _, i := range times {
+		if i >= len(split) {
+			continue
+		}
+
+		if value, err := strconv.ParseFloat(strings.TrimSpace(split[i]), 64); err == nil {
+			split[i] = strconv.FormatFloat(math.Round(value/speed), 'f', -1, 64)
+		}
+	}
+
+	return indent + strings.Join(split, ",")
+}
@@ -0,0 +1,83 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/files"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+)
+
+// EncodeAudio encodes 48kHz 32-bit float stereo PCM read from source to given file, codec is chosen by ffmpeg from file's extension
+func EncodeAudio(source io.Reader, path string) error {
+	execPath, err := files.GetCommandExec("ffmpeg", "ffmpeg")
+	if err != nil {
+		return errors.New("ffmpeg not found! Please make sure it's installed in danser directory or in PATH")
+	}
+
+	options := []string{
+		"-y",
+
+		"-f", "f32le",
+		"-acodec", "pcm_f32le",
+		"-ar", "48000",
+		"-ac", "2",
+		"-i", "-",
+
+		"-nostats",
+		"-q:a", "2",
+		path,
+	}
+
+	log.Println("Running ffmpeg with options:", options)
+
+	cmd := exec.Command(execPath, options...)
+	cmd.Stdin = source
+
+	if settings.Recording.ShowFFmpegLogs {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	if err = cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed to encode audio: %w", err)
+	}
+
+	return nil
+}
+
+// ChangeVideoRate re-encodes the video so it plays at given speed, audio streams are dropped
+func ChangeVideoRate(srcPath, dstPath string, speed float64) error {
+	execPath, err := files.GetCommandExec("ffmpeg", "ffmpeg")
+	if err != nil {
+		return errors.New("ffmpeg not found! Please make sure it's installed in danser directory or in PATH")
+	}
+
+	options := []string{
+		"-y",
+		"-i", srcPath,
+
+		"-nostats",
+		"-an",
+		"-filter:v", fmt.Sprintf("setpts=PTS/%g", speed),
+		dstPath,
+	}
+
+	log.Println("Running ffmpeg with options:", options)
+
+	cmd := exec.Command(execPath, options...)
+
+	if settings.Recording.ShowFFmpegLogs {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	if err = cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed to encode video: %w", err)
+	}
+
+	return nil
+}
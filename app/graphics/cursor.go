@@ -56,7 +56,20 @@ func initCursor() {
 	fboBatch = batch.NewQuadBatchSize(1)
 	fboBatch.SetCamera(mgl32.Ortho(0, float32(settings.Graphics.GetWidth()), 0, float32(settings.Graphics.GetHeight()), -1, 1))
 
-	osuRect = Camera.GetWorldRect()
+	osuRect = getOsuRect()
+}
+
+// getOsuRect returns the world rectangle visible with player's camera. If player didn't set it, the camera is set up the same way, which doesn't need OpenGL.
+func getOsuRect() camera.Rectangle {
+	if Camera != nil {
+		return Camera.GetWorldRect()
+	}
+
+	osuCamera := camera.NewCamera()
+	osuCamera.SetOsuViewport(int(settings.Graphics.GetWidth()), int(settings.Graphics.GetHeight()), settings.Playfield.Scale, true, settings.Playfield.OsuShift)
+	osuCamera.Update()
+
+	return osuCamera.GetWorldRect()
 }
 
 type Cursor struct {
@@ -106,6 +119,12 @@ type Cursor struct {
 }
 
 func NewCursor() *Cursor {
+	if settings.HEADLESS { // Only input state is needed, skip everything that requires OpenGL
+		osuRect = getOsuRect()
+
+		return &Cursor{Position: vector.NewVec2f(256, -500), scale: animation.NewGlider(1.0)}
+	}
+
 	if cursorFbo == nil {
 		initCursor()
 	}
@@ -177,7 +196,10 @@ func (cursor *Cursor) SetPos(pt vector.Vector2f) {
 	}
 
 	cursor.Position = tmp
-	cursor.renderer.SetPosition(cursor.Position)
+
+	if cursor.renderer != nil {
+		cursor.renderer.SetPosition(cursor.Position)
+	}
 }
 
 func (cursor *Cursor) SetScreenPos(pt vector.Vector2f) {
@@ -185,6 +207,10 @@ func (cursor *Cursor) SetScreenPos(pt vector.Vector2f) {
 }
 
 func (cursor *Cursor) Update(delta float64) {
+	if cursor.renderer == nil {
+		return
+	}
+
 	delta = math.Abs(delta)
 	cursor.time += delta
 
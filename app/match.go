@@ -0,0 +1,59 @@
+package app
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/rulesets"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/states"
+	"github.com/wieku/rplpa"
+	"os"
+	"strings"
+)
+
+// resolveMatchPicks finds beatmaps of all maps in the match. If md5 of the map is not given in the match file,
+// the one from the first replay is used.
+func resolveMatchPicks(match *settings.Match, beatMaps []*beatmap.BeatMap) ([]*states.MatchPick, error) {
+	picks := make([]*states.MatchPick, 0, len(match.Maps))
+
+	for _, m := range match.Maps {
+		data, err := os.ReadFile(m.Replays[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+
+		rp, err := rplpa.ParseReplay(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+
+		if !rulesets.IsModeSupported(int64(rp.PlayMode)) {
+			return nil, fmt.Errorf("%s: modes other than osu!standard and osu!taiko are not supported", m.Name)
+		}
+
+		md5 := m.MD5
+		if md5 == "" {
+			md5 = rp.BeatmapMD5
+		}
+
+		pick := &states.MatchPick{
+			MatchMap: m,
+			PlayMode: int64(rp.PlayMode),
+		}
+
+		for _, b := range beatMaps {
+			if strings.EqualFold(b.MD5, md5) {
+				pick.BeatMap = b
+				break
+			}
+		}
+
+		if pick.BeatMap == nil {
+			return nil, fmt.Errorf("%s: beatmap with md5 %s not found", m.Name, md5)
+		}
+
+		picks = append(picks, pick)
+	}
+
+	return picks, nil
+}
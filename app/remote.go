@@ -0,0 +1,187 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/analysis"
+	"github.com/wieku/danser-go/app/beatmap"
+	difficulty2 "github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/remote"
+	"github.com/wieku/danser-go/app/rulesets"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/skin"
+	"github.com/wieku/danser-go/app/states"
+	"github.com/wieku/danser-go/build"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"github.com/wieku/rplpa"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var remoteServer *remote.Server
+
+// remoteBeatmaps holds beatmaps loaded from the database at startup, so remote can load them without touching the database again
+var remoteBeatmaps []*beatmap.BeatMap
+
+// remoteKnockout is true if danser was started in knockout mode without a specific replay, beatmaps loaded by remote then use knockout too
+var remoteKnockout bool
+
+func startRemote(address, token string) {
+	remoteKnockout = settings.KNOCKOUT && settings.REPLAY == ""
+
+	remoteServer = remote.NewServer(loadRemote, token)
+
+	if err := remoteServer.Start(address); err != nil {
+		panic(fmt.Sprintf("Failed to start remote server: %s", err))
+	}
+
+	if p, ok := player.(*states.Player); ok {
+		remoteServer.SetPlayer(p)
+	}
+}
+
+func stopRemote() {
+	if remoteServer != nil {
+		remoteServer.Close()
+	}
+}
+
+// loadRemote replaces the running player with a new one playing the requested beatmap or replay
+func loadRemote(request remote.LoadRequest) error {
+	mods := difficulty2.ParseMods(strings.ToUpper(request.Mods))
+	var modsNew []rplpa.ModInfo
+
+	replayPath := ""
+	playMode := int64(rulesets.ModeOsu)
+
+	if request.Replay != "" {
+		if !isInsideDir(request.Replay, settings.General.GetReplaysDir()) && !isInsideDir(request.Replay, settings.General.GetSongsDir()) {
+			return errors.New("replay has to be in songs or replays directory")
+		}
+
+		data, err := os.ReadFile(request.Replay)
+		if err != nil {
+			return err
+		}
+
+		rp, err := rplpa.ParseReplay(data)
+		if err != nil {
+			return err
+		}
+
+		if !rulesets.IsModeSupported(int64(rp.PlayMode)) {
+			return errors.New("modes other than osu!standard and osu!taiko are not supported")
+		}
+
+		if rp.ReplayData == nil || len(rp.ReplayData) < 2 {
+			return errors.New("replay is missing input data")
+		}
+
+		request.MD5 = rp.BeatmapMD5
+		request.ID = -1
+
+		mods, modsNew = analysis.ReplayMods(rp)
+
+		replayPath = request.Replay
+		playMode = int64(rp.PlayMode)
+	}
+
+	var beatMap *beatmap.BeatMap
+
+	for _, b := range remoteBeatmaps {
+		if (request.ID > -1 && b.ID == request.ID) || (request.ID < 0 && strings.EqualFold(b.MD5, request.MD5)) {
+			beatMap = b
+			break
+		}
+	}
+
+	if beatMap == nil {
+		return errors.New("beatmap not found")
+	}
+
+	if replayPath == "" && beatMap.Mode != rulesets.ModeOsu {
+		return errors.New("only osu!standard beatmaps can be loaded without a replay")
+	}
+
+	if !mods.Compatible() {
+		return errors.New("incompatible mods selected")
+	}
+
+	var oldPlayer *states.Player
+
+	goroutines.CallMain(func() {
+		oldPlayer, _ = player.(*states.Player)
+		player = nil
+	})
+
+	remoteServer.SetPlayer(nil)
+
+	// Has to be stopped outside the main thread, update loop may still wait for it
+	if oldPlayer != nil {
+		oldPlayer.Stop()
+		goroutines.CallMain(oldPlayer.Dispose)
+	}
+
+	log.Println("Remote: Loading", beatMap.Artist, "-", beatMap.Name, "["+beatMap.Difficulty+"]")
+
+	var newPlayer *states.Player
+
+	goroutines.CallMain(func() {
+		settings.REPLAY = replayPath
+		settings.PLAYMODE = playMode
+		settings.KNOCKOUT = replayPath != "" || remoteKnockout
+		settings.START = 0
+		settings.END = math.Inf(1)
+
+		// Beatmap could have been played already
+		beatMap.Clear()
+		beatMap.Pauses = nil
+
+		if modsNew != nil {
+			beatMap.Diff.SetMods2(modsNew)
+		} else {
+			beatMap.Diff.SetMods(mods)
+		}
+
+		skin.ClearBeatmapColors()
+
+		beatmap.ParseTimingPointsAndPauses(beatMap)
+		beatmap.ParseObjects(beatMap, false, true)
+		beatMap.LoadCustomSamples()
+
+		newPlayer = states.NewPlayer(beatMap)
+		player = newPlayer
+
+		win.SetTitle("danser " + build.VERSION + " - " + beatMap.Artist + " - " + beatMap.Name + " [" + beatMap.Difficulty + "]")
+	})
+
+	remoteServer.SetPlayer(newPlayer)
+
+	return nil
+}
+
+// isInsideDir checks if path, after resolving symlinks, points to a file inside dir
+func isInsideDir(path, dir string) bool {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+
+	path, err1 := filepath.Abs(path)
+	dir, err2 := filepath.Abs(dir)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(dir, path)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,431 @@
+// Package remote contains a local HTTP/JSON API for controlling a running danser instance.
+//
+// Endpoints:
+//
+//	GET  /status    state of the player: time, beatmap, score of every cursor and knockout alive list
+//	POST /pause     pause the playback
+//	POST /resume    resume the playback
+//	POST /seek      {"time": 12345} skip forward to given beatmap time in milliseconds
+//	POST /speed     {"speed": 1.5} change the playback speed multiplier
+//	POST /load      {"md5": "...", "id": 123, "mods": "HDHR", "replay": "path/to/replay.osr"} load another beatmap or replay
+//	GET  /settings  current settings
+//	POST /settings  apply a settings patch, the same format as -sPatch
+//	POST /hud       {"ComboCounter": false, "PPCounter": true} show or hide HUD elements in Gameplay settings
+//
+// POST requests have to be sent with "Content-Type: application/json" and requests carrying an Origin header are rejected,
+// so web pages can't control danser from the browser. If a token is set, every request has to carry it in "Authorization: Bearer <token>" header.
+// Without a token the server can only listen on a loopback address and accepts only requests with Host header matching that address.
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/states"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// callTimeout is how long a request waits for the player to process it
+const callTimeout = time.Second
+
+// defaultHost is used if the address doesn't specify one
+const defaultHost = "127.0.0.1"
+
+// LoadRequest describes a beatmap or replay to load. If Replay is set, the beatmap and mods are taken from it.
+type LoadRequest struct {
+	MD5    string `json:"md5"`
+	ID     int64  `json:"id"`
+	Mods   string `json:"mods"`
+	Replay string `json:"replay"`
+}
+
+// Loader stops the current player and starts a new one with requested beatmap
+type Loader func(request LoadRequest) error
+
+type Server struct {
+	loader Loader
+	token  string
+
+	mutex  sync.Mutex
+	player *states.Player
+
+	loadMutex sync.Mutex
+
+	// Host headers accepted without a token, set by Start
+	allowedHosts []string
+
+	httpServer *http.Server
+}
+
+// NewServer creates a server that uses loader for /load requests. If token is not empty, requests without it are rejected.
+func NewServer(loader Loader, token string) *Server {
+	server := &Server{
+		loader: loader,
+		token:  token,
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /status", server.handleStatus)
+	mux.HandleFunc("POST /pause", server.handlePause(true))
+	mux.HandleFunc("POST /resume", server.handlePause(false))
+	mux.HandleFunc("POST /seek", server.handleSeek)
+	mux.HandleFunc("POST /speed", server.handleSpeed)
+	mux.HandleFunc("POST /load", server.handleLoad)
+	mux.HandleFunc("GET /settings", server.handleGetSettings)
+	mux.HandleFunc("POST /settings", server.handlePatchSettings)
+	mux.HandleFunc("POST /hud", server.handleHUD)
+
+	server.httpServer = &http.Server{
+		Handler:           server.validate(server.authorize(mux)),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	return server
+}
+
+// Start starts listening on given address, e.g. "127.0.0.1:9045". If the host is missing, server listens on loopback only.
+func (server *Server) Start(address string) error {
+	address, err := resolveAddress(address, server.token != "")
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	server.allowedHosts = []string{address, listener.Addr().String()}
+
+	log.Println("Remote: Listening on", listener.Addr().String())
+
+	goroutines.Run(func() {
+		if err := server.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println("Remote: Server stopped:", err)
+		}
+	})
+
+	return nil
+}
+
+func (server *Server) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := server.httpServer.Shutdown(ctx); err != nil {
+		log.Println("Remote: Failed to stop the server:", err)
+	}
+}
+
+// validate rejects requests that could come from a web page: cross-origin ones, POSTs that are not JSON and,
+// without a token, ones with Host header other than server's address, which protects against DNS rebinding
+func (server *Server) validate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" {
+			writeError(w, http.StatusForbidden, errors.New("cross-origin requests are not allowed"))
+			return
+		}
+
+		if server.token == "" && !slices.Contains(server.allowedHosts, r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("invalid host: %s", r.Host))
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("content type has to be application/json"))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize rejects requests that don't carry server's token
+func (server *Server) authorize(next http.Handler) http.Handler {
+	if server.token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(server.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetPlayer sets the player commands are sent to
+func (server *Server) SetPlayer(player *states.Player) {
+	server.mutex.Lock()
+	server.player = player
+	server.mutex.Unlock()
+}
+
+func (server *Server) getPlayer() *states.Player {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.player
+}
+
+// callPlayer runs f on player's update thread and writes its result as JSON
+func (server *Server) callPlayer(w http.ResponseWriter, f func(player *states.Player) (any, error)) {
+	player := server.getPlayer()
+	if player == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("player is not running"))
+		return
+	}
+
+	var result any
+	var err error
+
+	if !player.Call(func() { result, err = f(player) }, callTimeout) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("player is not responding"))
+		return
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (server *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	server.callPlayer(w, func(player *states.Player) (any, error) {
+		return player.GetStatus(), nil
+	})
+}
+
+func (server *Server) handlePause(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		server.callPlayer(w, func(player *states.Player) (any, error) {
+			player.SetPaused(paused)
+			return player.GetStatus(), nil
+		})
+	}
+}
+
+func (server *Server) handleSeek(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Time *float64 `json:"time"`
+	}
+
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if request.Time == nil {
+		writeError(w, http.StatusBadRequest, errors.New("time is missing"))
+		return
+	}
+
+	server.callPlayer(w, func(player *states.Player) (any, error) {
+		if err := player.Seek(*request.Time); err != nil {
+			return nil, err
+		}
+
+		return player.GetStatus(), nil
+	})
+}
+
+func (server *Server) handleSpeed(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Speed float64 `json:"speed"`
+	}
+
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.callPlayer(w, func(player *states.Player) (any, error) {
+		if err := player.SetSpeed(request.Speed); err != nil {
+			return nil, err
+		}
+
+		return player.GetStatus(), nil
+	})
+}
+
+func (server *Server) handleLoad(w http.ResponseWriter, r *http.Request) {
+	request := LoadRequest{ID: -1}
+
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if request.MD5 == "" && request.ID < 0 && request.Replay == "" {
+		writeError(w, http.StatusBadRequest, errors.New("md5, id or replay has to be specified"))
+		return
+	}
+
+	// Loading takes a while, don't start another one in the meantime
+	server.loadMutex.Lock()
+	defer server.loadMutex.Unlock()
+
+	if err := server.loader(request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.handleStatus(w, r)
+}
+
+func (server *Server) handleGetSettings(w http.ResponseWriter, _ *http.Request) {
+	var data []byte
+	var err error
+
+	goroutines.CallMain(func() {
+		data, err = json.Marshal(settings.GetFormat())
+	})
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func (server *Server) handlePatchSettings(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.applyPatch(w, string(data))
+}
+
+func (server *Server) handleHUD(w http.ResponseWriter, r *http.Request) {
+	var request map[string]bool
+
+	if err := readJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var elements map[string]json.RawMessage
+
+	goroutines.CallMain(func() {
+		data, _ := json.Marshal(settings.Gameplay)
+		_ = json.Unmarshal(data, &elements)
+	})
+
+	gameplay := make(map[string]any)
+
+	for name, show := range request {
+		var element map[string]json.RawMessage
+
+		if raw, ok := elements[name]; !ok || json.Unmarshal(raw, &element) != nil || element["Show"] == nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown HUD element: %s", name))
+			return
+		}
+
+		gameplay[name] = map[string]bool{"Show": show}
+	}
+
+	patch, err := json.Marshal(map[string]any{"Gameplay": gameplay})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.applyPatch(w, string(patch))
+}
+
+func (server *Server) applyPatch(w http.ResponseWriter, patch string) {
+	var err error
+
+	goroutines.CallMain(func() {
+		err = settings.ApplyLivePatch(patch)
+	})
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server.handleGetSettings(w, nil)
+}
+
+// resolveAddress fills in the loopback host if it's missing and refuses other hosts if the server doesn't use a token
+func resolveAddress(address string, hasToken bool) (string, error) {
+	if !strings.Contains(address, ":") {
+		address = ":" + address
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+
+	if host == "" {
+		host = defaultHost
+	}
+
+	if !hasToken && !isLoopback(host) {
+		return "", fmt.Errorf("listening on %s requires a token, use a loopback address or set -remotetoken", host)
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+func readJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -1,6 +1,7 @@
 package osu
 
 import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/app/beatmap/objects"
 	"math"
 )
@@ -43,7 +44,8 @@ func (circle *Circle) UpdateClickFor(player *difficultyPlayer, time int64) bool
 	state := circle.state[player]
 
 	if !state.isHit {
-		position := circle.hitCircle.GetStackedStartPositionMod(player.diff)
+		// Lazer's Transform and Wiggle move circle's hit area
+		position := circle.hitCircle.GetStackedStartPositionMod(player.diff).Add(objects.GetFunModOffset(circle.hitCircle, float64(time), player.diff))
 
 		clicked := player.leftCondE || player.rightCondE
 
@@ -60,19 +62,24 @@ func (circle *Circle) UpdateClickFor(player *difficultyPlayer, time int64) bool
 						player.rightCondE = false
 					}
 
-					hit := circle.ruleSet.GetResultForDelta(player, math.Abs(float64(time)-circle.hitCircle.GetEndTime()))
+					delta := math.Abs(float64(time) - circle.hitCircle.GetEndTime())
+
+					hit := circle.ruleSet.GetResultForDelta(player, delta)
+					if player.diff.CheckModActive(difficulty.Target) {
+						hit = circle.getTargetResult(player, delta, position)
+					}
 
 					if hit != Ignore {
 						combo := Increase
 						if hit == Miss {
 							combo = Reset
 						} else {
-							if len(circle.players) == 1 {
+							if circle.ruleSet.hasFeedback() {
 								circle.hitCircle.PlaySound()
 							}
 						}
 
-						if len(circle.players) == 1 {
+						if circle.ruleSet.hasFeedback() {
 							circle.hitCircle.Arm(hit != Miss, float64(time))
 						}
 
@@ -85,7 +92,7 @@ func (circle *Circle) UpdateClickFor(player *difficultyPlayer, time int64) bool
 					player.leftCondE = false
 					player.rightCondE = false
 
-					if action == Shake && len(circle.players) == 1 {
+					if action == Shake && circle.ruleSet.hasFeedback() {
 						circle.hitCircle.Shake(float64(time))
 					}
 				}
@@ -105,7 +112,7 @@ func (circle *Circle) UpdatePostFor(player *difficultyPlayer, time int64, _ bool
 		position := circle.hitCircle.GetStackedPositionAtMod(float64(time), player.diff)
 		circle.ruleSet.SendResult(player.cursor, createJudgementResult(Miss, Hit300, Reset, time, position, circle))
 
-		if len(circle.players) == 1 {
+		if circle.ruleSet.hasFeedback() {
 			circle.hitCircle.Arm(false, float64(time))
 		}
 
@@ -135,7 +142,7 @@ func (circle *Circle) MissForcefully(player *difficultyPlayer, time int64) {
 	if !state.isHit {
 		position := circle.hitCircle.GetStackedPositionAtMod(float64(time), player.diff)
 
-		if len(circle.players) == 1 {
+		if circle.ruleSet.hasFeedback() {
 			circle.hitCircle.Arm(false, float64(time))
 		}
 
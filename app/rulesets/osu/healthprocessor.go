@@ -47,6 +47,37 @@ type IHealthProcessor interface {
 
 type FailListener func()
 
+// PassingState decides whether storyboard shows its Pass or Fail layer. Like in osu!, player is passing if health is at least half full
+// at the start of a break. Failed players are never passing.
+type PassingState struct {
+	pauses    []*beatmap.Pause
+	nextPause int
+	passing   bool
+}
+
+func NewPassingState(beatMap *beatmap.BeatMap) *PassingState {
+	return &PassingState{
+		pauses:  beatMap.Pauses,
+		passing: true,
+	}
+}
+
+// Update has to be called every millisecond, after health was updated
+func (state *PassingState) Update(time int64, health float64, failed bool) {
+	if failed {
+		state.passing = false
+		return
+	}
+
+	for ; state.nextPause < len(state.pauses) && float64(time) >= state.pauses[state.nextPause].GetStartTime(); state.nextPause++ {
+		state.passing = health >= 0.5
+	}
+}
+
+func (state *PassingState) IsPassing() bool {
+	return state.passing
+}
+
 type drainPeriod struct {
 	start, end int64
 }
@@ -1,6 +1,7 @@
 package osu
 
 import (
+	"fmt"
 	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/framework/math/vector"
 )
@@ -120,6 +121,74 @@ func (r HitResult) ScoreValueMod(mod difficulty.Modifier) int64 {
 	return r.ScoreValue()
 }
 
+// SliderAccuracyResults returns slider judgements that are timed like circles. Lazer judges slider heads by accuracy
+// unless it's disabled in Classic mod settings.
+func SliderAccuracyResults(diff *difficulty.Difficulty) HitResult {
+	results := SliderStart
+
+	if diff.CheckModActive(difficulty.Lazer) {
+		classicConf, confFound := difficulty.GetModConfig[difficulty.ClassicSettings](diff)
+
+		if !diff.CheckModActive(difficulty.Classic) || !confFound || !classicConf.NoSliderHeadAccuracy {
+			results |= BaseHits
+		}
+	}
+
+	return results
+}
+
+func (r HitResult) String() string {
+	v := r & (^Additions)
+
+	var name string
+
+	switch v {
+	case Ignore:
+		name = "Ignore"
+	case SliderMiss:
+		name = "SliderMiss"
+	case Miss:
+		name = "Miss"
+	case Hit50:
+		name = "Hit50"
+	case Hit100:
+		name = "Hit100"
+	case Hit300:
+		name = "Hit300"
+	case SliderStart:
+		name = "SliderStart"
+	case SliderPoint:
+		name = "SliderPoint"
+	case SliderRepeat:
+		name = "SliderRepeat"
+	case LegacySliderEnd:
+		name = "LegacySliderEnd"
+	case SliderEnd:
+		name = "SliderEnd"
+	case SliderFinish:
+		name = "SliderFinish"
+	case SpinnerSpin:
+		name = "SpinnerSpin"
+	case SpinnerPoints:
+		name = "SpinnerPoints"
+	case SpinnerBonus:
+		name = "SpinnerBonus"
+	case PositionalMiss:
+		name = "PositionalMiss"
+	default:
+		return fmt.Sprintf("HitResult(%d)", int64(r))
+	}
+
+	switch r & Additions {
+	case GekiAddition:
+		name += "g"
+	case KatuAddition:
+		name += "k"
+	}
+
+	return name
+}
+
 type ComboResult uint8
 
 const (
@@ -128,6 +197,19 @@ const (
 	Increase
 )
 
+func (r ComboResult) String() string {
+	switch r {
+	case Reset:
+		return "Reset"
+	case Hold:
+		return "Hold"
+	case Increase:
+		return "Increase"
+	}
+
+	return fmt.Sprintf("ComboResult(%d)", uint8(r))
+}
+
 type JudgementResult struct {
 	HitResult HitResult
 	MaxResult HitResult
@@ -0,0 +1,124 @@
+// Package osutest contains a deterministic harness for osu!standard ruleset tests.
+// Beatmaps are built in code and played with scripted replay frames, without rendering or audio.
+package osutest
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"strconv"
+	"strings"
+)
+
+// MapBuilder builds a BeatMap from hit objects described in osu! file format
+type MapBuilder struct {
+	beatMap  *beatmap.BeatMap
+	newCombo bool
+}
+
+// NewMap creates a builder of a beatmap with given difficulty, slider multiplier of 1 and slider tick rate of 1
+func NewMap(hp, cs, od, ar float64) *MapBuilder {
+	beatMap := beatmap.NewBeatMap()
+	beatMap.Name = "test"
+	beatMap.Artist = "danser"
+	beatMap.Creator = "danser"
+	beatMap.Difficulty = "test"
+	beatMap.Version = 14
+
+	beatMap.Diff.SetHP(hp)
+	beatMap.Diff.SetCS(cs)
+	beatMap.Diff.SetOD(od)
+	beatMap.Diff.SetAR(ar)
+
+	beatMap.SliderMultiplier = 1
+	beatMap.Timings.SliderMult = 1
+	beatMap.Timings.TickRate = 1
+
+	return &MapBuilder{beatMap: beatMap}
+}
+
+// SliderMultiplier sets base slider velocity in hundreds of osu!pixels per beat
+func (builder *MapBuilder) SliderMultiplier(multiplier float64) *MapBuilder {
+	builder.beatMap.SliderMultiplier = multiplier
+	builder.beatMap.Timings.SliderMult = multiplier
+
+	return builder
+}
+
+// TickRate sets amount of slider ticks per beat
+func (builder *MapBuilder) TickRate(rate float64) *MapBuilder {
+	builder.beatMap.Timings.TickRate = rate
+
+	return builder
+}
+
+// Timing adds an uninherited timing point
+func (builder *MapBuilder) Timing(time, beatLength float64) *MapBuilder {
+	builder.beatMap.ParsePoint(fmt.Sprintf("%s,%s,4,1,0,100,1,0", formatFloat(time), formatFloat(beatLength)))
+
+	return builder
+}
+
+// SV adds an inherited timing point changing slider velocity by given multiplier
+func (builder *MapBuilder) SV(time, multiplier float64) *MapBuilder {
+	builder.beatMap.ParsePoint(fmt.Sprintf("%s,%s,4,1,0,100,0,0", formatFloat(time), formatFloat(-100/multiplier)))
+
+	return builder
+}
+
+// NewCombo makes the next object start a new combo
+func (builder *MapBuilder) NewCombo() *MapBuilder {
+	builder.newCombo = true
+
+	return builder
+}
+
+// Circle adds a hit circle
+func (builder *MapBuilder) Circle(x, y float32, time int64) *MapBuilder {
+	return builder.add(x, y, time, objects.CIRCLE, "0:0:0:0:")
+}
+
+// Slider adds a slider. Curve is given in osu! file format without the start point, e.g. "L|200:100" or "B|150:50|200:100".
+func (builder *MapBuilder) Slider(x, y float32, time int64, curve string, repeats int, length float64) *MapBuilder {
+	return builder.add(x, y, time, objects.SLIDER, fmt.Sprintf("%s,%d,%s", curve, repeats, formatFloat(length)))
+}
+
+// Spinner adds a spinner in the middle of the playfield
+func (builder *MapBuilder) Spinner(startTime, endTime int64) *MapBuilder {
+	return builder.add(256, 192, startTime, objects.SPINNER, fmt.Sprintf("%d,0:0:0:0:", endTime))
+}
+
+func (builder *MapBuilder) add(x, y float32, time int64, objType objects.Type, extras string) *MapBuilder {
+	if builder.newCombo {
+		objType |= objects.NEWCOMBO
+		builder.newCombo = false
+	}
+
+	line := fmt.Sprintf("%s,%s,%d,%d,0,%s", formatFloat(float64(x)), formatFloat(float64(y)), time, objType, extras)
+
+	obj := objects.CreateObject(strings.Split(line, ","))
+	if obj == nil {
+		panic("osutest: failed to create object from: " + line)
+	}
+
+	builder.beatMap.HitObjects = append(builder.beatMap.HitObjects, obj)
+
+	return builder
+}
+
+// Build finalizes timing points and objects. Beatmap needs at least one timing point.
+func (builder *MapBuilder) Build() *beatmap.BeatMap {
+	if !builder.beatMap.Timings.HasPoints() {
+		panic("osutest: beatmap needs at least one timing point")
+	}
+
+	builder.beatMap.FinalizePoints()
+
+	beatmap.FinalizeObjects(builder.beatMap, false)
+
+	return builder.beatMap
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,260 @@
+package osutest
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/rplpa"
+	"math"
+	"sort"
+)
+
+// Frame is a single replay frame. Left and Right are K1 and K2 keys.
+type Frame struct {
+	Time        int64
+	X, Y        float32
+	Left, Right bool
+}
+
+// Script records replay frames. Every frame keeps cursor position and key state of the previous one unless changed.
+type Script struct {
+	frames      []Frame
+	x, y        float32
+	left, right bool
+}
+
+func NewScript() *Script {
+	return &Script{x: 256, y: -500}
+}
+
+// MoveTo moves the cursor at given time
+func (script *Script) MoveTo(time int64, x, y float32) *Script {
+	script.x, script.y = x, y
+	return script.add(time)
+}
+
+// Press presses K1 (left) or K2 (right) at given time
+func (script *Script) Press(time int64, right bool) *Script {
+	script.setKey(right, true)
+	return script.add(time)
+}
+
+// Release releases K1 (left) or K2 (right) at given time
+func (script *Script) Release(time int64, right bool) *Script {
+	script.setKey(right, false)
+	return script.add(time)
+}
+
+// Tap moves the cursor to given position, presses K1 and releases it after 30ms
+func (script *Script) Tap(time int64, x, y float32) *Script {
+	return script.MoveTo(time, x, y).Press(time, false).Release(time+30, false)
+}
+
+// FollowSlider moves the cursor along the slider path every 10ms from startTime to endTime without changing key state.
+// Path is taken without mods, so it's not valid for HR or mirror mods.
+func (script *Script) FollowSlider(beatMap *beatmap.BeatMap, index int, startTime, endTime int64) *Script {
+	obj := beatMap.HitObjects[index]
+
+	for time := startTime; time <= endTime; time += 10 {
+		pos := obj.GetStackedPositionAtMod(float64(time), beatMap.Diff)
+		script.MoveTo(time, pos.X, pos.Y)
+	}
+
+	return script
+}
+
+// Spin circles the cursor around the playfield centre at given angular velocity (radians per millisecond) every 16ms from startTime to endTime
+func (script *Script) Spin(startTime, endTime int64, velocity float64) *Script {
+	for time := startTime; time <= endTime; time += 16 {
+		angle := velocity * float64(time-startTime)
+		script.MoveTo(time, 256+float32(50*math.Cos(angle)), 192+float32(50*math.Sin(angle)))
+	}
+
+	return script
+}
+
+// Frames returns recorded frames sorted by time
+func (script *Script) Frames() []Frame {
+	frames := make([]Frame, len(script.frames))
+	copy(frames, script.frames)
+
+	sort.SliceStable(frames, func(i, j int) bool {
+		return frames[i].Time < frames[j].Time
+	})
+
+	return frames
+}
+
+func (script *Script) setKey(right, state bool) {
+	if right {
+		script.right = state
+	} else {
+		script.left = state
+	}
+}
+
+func (script *Script) add(time int64) *Script {
+	script.frames = append(script.frames, Frame{
+		Time:  time,
+		X:     script.x,
+		Y:     script.y,
+		Left:  script.left,
+		Right: script.right,
+	})
+
+	return script
+}
+
+// Harness plays replay frames with ReplayController and records everything the ruleset reports
+type Harness struct {
+	// RuleSet and Cursor are created by Run
+	RuleSet *osu.OsuRuleSet
+	Cursor  *graphics.Cursor
+	BeatMap *beatmap.BeatMap
+
+	// Results contains judgements in the order they were sent, including positional misses
+	Results []osu.JudgementResult
+
+	// HP contains player's health right after each judgement
+	HP []float64
+
+	Failed bool
+
+	mods difficulty.Modifier
+}
+
+// NewHarness prepares osu!standard play for a single player with given mods.
+// Lazer mod (LZ) makes the replay a lazer one, switching the ruleset to lazer's notelock, judgements and health processor.
+// Tests using the harness have to set settings.HEADLESS in TestMain, only input state of the cursor is needed.
+func NewHarness(beatMap *beatmap.BeatMap, mods difficulty.Modifier) *Harness {
+	return &Harness{
+		BeatMap: beatMap,
+		mods:    mods,
+	}
+}
+
+// Run plays the frames on ReplayController millisecond by millisecond till all objects are judged.
+// K1 and K2 are recorded with their mouse buttons like osu!stable does, all keys are released after the last frame.
+func (harness *Harness) Run(frames []Frame) {
+	bMap := harness.BeatMap
+
+	if len(bMap.HitObjects) == 0 {
+		return
+	}
+
+	startTime := int64(math.Floor(min(bMap.HitObjects[0].GetStartTime(), 0) - bMap.Diff.Preempt - 1000))
+	endTime := int64(bMap.HitObjects[len(bMap.HitObjects)-1].GetEndTime()) + 1000
+
+	if len(frames) > 0 {
+		startTime = min(startTime, frames[0].Time-1)
+	}
+
+	controller := dance.NewReplayControllerWithReplays(bMap, []*rplpa.Replay{harness.createReplay(frames, startTime)})
+	controller.InitCursors()
+
+	harness.Cursor = controller.GetCursors()[0]
+	harness.RuleSet = controller.GetRuleset().(*osu.OsuRuleSet)
+
+	harness.RuleSet.SetListener(func(_ *graphics.Cursor, result osu.JudgementResult, _ osu.Score) {
+		harness.Results = append(harness.Results, result)
+		harness.HP = append(harness.HP, harness.RuleSet.GetHP(harness.Cursor))
+	})
+
+	harness.RuleSet.SetFailListener(func(_ *graphics.Cursor) {
+		harness.Failed = true
+	})
+
+	for time := startTime; time <= endTime; time++ {
+		controller.Update(float64(time), 1)
+	}
+}
+
+// createReplay converts frames to a replay with time deltas. First frame sets cursor's position at startTime, it's consumed by ReplayController.InitCursors.
+func (harness *Harness) createReplay(frames []Frame, startTime int64) *rplpa.Replay {
+	replay := &rplpa.Replay{
+		PlayMode:   int8(harness.BeatMap.Mode),
+		OsuVersion: 20250101,
+		Username:   "test",
+		Mods:       uint32(harness.mods &^ difficulty.Lazer),
+	}
+
+	if harness.mods.Active(difficulty.Lazer) {
+		replay.OsuVersion = 30000000
+	}
+
+	first := Frame{X: 256, Y: -500}
+	if len(frames) > 0 {
+		first = Frame{X: frames[0].X, Y: frames[0].Y}
+	}
+
+	first.Time = startTime
+
+	lastTime := int64(0)
+
+	for _, frame := range append([]Frame{first}, frames...) {
+		replay.ReplayData = append(replay.ReplayData, &rplpa.ReplayData{
+			Time:   float64(frame.Time - lastTime),
+			MouseX: float64(frame.X),
+			MouseY: float64(frame.Y),
+			KeyPressed: &rplpa.KeyPressed{
+				LeftClick:  frame.Left,
+				RightClick: frame.Right,
+				Key1:       frame.Left,
+				Key2:       frame.Right,
+			},
+		})
+
+		lastTime = frame.Time
+	}
+
+	return replay
+}
+
+// Score returns player's final score
+func (harness *Harness) Score() osu.Score {
+	return harness.RuleSet.GetScore(harness.Cursor)
+}
+
+// HPNow returns player's current health
+func (harness *Harness) HPNow() float64 {
+	return harness.RuleSet.GetHP(harness.Cursor)
+}
+
+// HPAfter returns player's health right after the last judgement of the object with given index
+func (harness *Harness) HPAfter(index int64) float64 {
+	for i := len(harness.Results) - 1; i >= 0; i-- {
+		if harness.Results[i].Number == index {
+			return harness.HP[i]
+		}
+	}
+
+	return math.NaN()
+}
+
+// ResultsFor returns judgements of the object with given index, without positional misses
+func (harness *Harness) ResultsFor(index int64) []osu.HitResult {
+	var results []osu.HitResult
+
+	for _, r := range harness.Results {
+		if r.Number == index && r.HitResult != osu.PositionalMiss {
+			results = append(results, r.HitResult&^osu.Additions)
+		}
+	}
+
+	return results
+}
+
+// Count returns how many times given result was sent, additions are ignored
+func (harness *Harness) Count(result osu.HitResult) int {
+	count := 0
+
+	for _, r := range harness.Results {
+		if r.HitResult&^osu.Additions == result {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,249 @@
+package osutest
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/settings"
+	"os"
+	"slices"
+	"testing"
+)
+
+var rulesetModes = []struct {
+	name string
+	mods difficulty.Modifier
+}{
+	{"stable", difficulty.None},
+	{"lazer", difficulty.Lazer},
+}
+
+func TestMain(m *testing.M) {
+	settings.HEADLESS = true
+
+	os.Exit(m.Run())
+}
+
+func expectResults(t *testing.T, harness *Harness, index int64, expected ...osu.HitResult) {
+	t.Helper()
+
+	if actual := harness.ResultsFor(index); !slices.Equal(actual, expected) {
+		t.Errorf("object %d: expected %v, got %v", index, expected, actual)
+	}
+}
+
+func TestCircleHitWindows(t *testing.T) {
+	// OD5: 300 within 50ms, 100 within 100ms, 50 within 150ms
+	beatMap := NewMap(5, 4, 5, 9).
+		Timing(0, 500).
+		Circle(100, 100, 1000).
+		Circle(200, 100, 2000).
+		Circle(300, 100, 3000).
+		Circle(400, 100, 4000).
+		Build()
+
+	frames := NewScript().
+		Tap(1000, 100, 100).
+		Tap(2070, 200, 100).
+		Tap(3120, 300, 100).
+		Frames()
+
+	for _, mode := range rulesetModes {
+		t.Run(mode.name, func(t *testing.T) {
+			harness := NewHarness(beatMap, mode.mods)
+			harness.Run(frames)
+
+			expectResults(t, harness, 0, osu.Hit300)
+			expectResults(t, harness, 1, osu.Hit100)
+			expectResults(t, harness, 2, osu.Hit50)
+			expectResults(t, harness, 3, osu.Miss)
+
+			score := harness.Score()
+
+			if score.Count300 != 1 || score.Count100 != 1 || score.Count50 != 1 || score.CountMiss != 1 {
+				t.Errorf("unexpected hit counts: %d/%d/%d/%d", score.Count300, score.Count100, score.Count50, score.CountMiss)
+			}
+
+			if score.Combo != 3 {
+				t.Errorf("expected max combo 3, got %d", score.Combo)
+			}
+
+			// Written this way so NaN of a missing judgement fails too
+			if before, after := harness.HPAfter(2), harness.HPAfter(3); !(after < before) {
+				t.Errorf("expected health to drop after a miss: %f -> %f", before, after)
+			}
+		})
+	}
+}
+
+func TestNotelock(t *testing.T) {
+	beatMap := NewMap(5, 4, 5, 9).
+		Timing(0, 500).
+		Circle(100, 192, 1000).
+		Circle(400, 192, 1100).
+		Build()
+
+	// Second circle is clicked while the first one can still be hit
+	frames := NewScript().
+		Tap(1050, 400, 192).
+		Frames()
+
+	t.Run("stable", func(t *testing.T) {
+		harness := NewHarness(beatMap, difficulty.None)
+		harness.Run(frames)
+
+		// Click is eaten by the notelock, both circles are missed later
+		expectResults(t, harness, 0, osu.Miss)
+		expectResults(t, harness, 1, osu.Miss)
+	})
+
+	t.Run("lazer", func(t *testing.T) {
+		harness := NewHarness(beatMap, difficulty.Lazer)
+		harness.Run(frames)
+
+		// Second circle is hit and the first one is missed forcefully
+		expectResults(t, harness, 0, osu.Miss)
+		expectResults(t, harness, 1, osu.Hit300)
+	})
+}
+
+func TestSliderFollow(t *testing.T) {
+	// 200px at SV 1 and 500ms beat lasts 1000ms, with one tick in the middle
+	beatMap := NewMap(5, 4, 5, 9).
+		Timing(0, 500).
+		Slider(100, 192, 1000, "L|300:192", 1, 200).
+		Build()
+
+	frames := NewScript().
+		MoveTo(1000, 100, 192).
+		Press(1000, false).
+		FollowSlider(beatMap, 0, 1000, 2000).
+		Release(2010, false).
+		Frames()
+
+	for _, mode := range rulesetModes {
+		t.Run(mode.name, func(t *testing.T) {
+			harness := NewHarness(beatMap, mode.mods)
+			harness.Run(frames)
+
+			if count := harness.Count(osu.SliderPoint); count != 1 {
+				t.Errorf("expected 1 slider tick, got %d", count)
+			}
+
+			if count := harness.Count(osu.SliderEnd); count != 1 {
+				t.Errorf("expected 1 slider end, got %d", count)
+			}
+
+			if count := harness.Count(osu.SliderMiss); count != 0 {
+				t.Errorf("expected no slider breaks, got %d", count)
+			}
+
+			results := harness.ResultsFor(0)
+
+			expectedLast := osu.Hit300
+			if mode.mods.Active(difficulty.Lazer) {
+				expectedLast = osu.SliderFinish
+
+				if len(results) == 0 || results[0] != osu.Hit300 {
+					t.Errorf("expected Hit300 on slider head, got %v", results)
+				}
+			}
+
+			if len(results) == 0 || results[len(results)-1] != expectedLast {
+				t.Errorf("expected %v as the final judgement, got %v", expectedLast, results)
+			}
+
+			score := harness.Score()
+
+			if score.Count300 != 1 || score.CountMiss != 0 || score.CountSB != 0 {
+				t.Errorf("unexpected score: %d 300s, %d misses, %d slider breaks", score.Count300, score.CountMiss, score.CountSB)
+			}
+		})
+	}
+}
+
+func TestSliderEarlyRelease(t *testing.T) {
+	beatMap := NewMap(5, 4, 5, 9).
+		Timing(0, 500).
+		Slider(100, 192, 1000, "L|300:192", 1, 200).
+		Build()
+
+	// Key is released before the tick, so the tick and the end are missed
+	frames := NewScript().
+		MoveTo(1000, 100, 192).
+		Press(1000, false).
+		FollowSlider(beatMap, 0, 1000, 1200).
+		Release(1200, false).
+		FollowSlider(beatMap, 0, 1210, 2000).
+		Frames()
+
+	for _, mode := range rulesetModes {
+		t.Run(mode.name, func(t *testing.T) {
+			harness := NewHarness(beatMap, mode.mods)
+			harness.Run(frames)
+
+			if count := harness.Count(osu.SliderMiss); count != 2 {
+				t.Errorf("expected 2 missed slider parts, got %d", count)
+			}
+
+			results := harness.ResultsFor(0)
+
+			if !mode.mods.Active(difficulty.Lazer) {
+				// Only the head was scored out of 3 parts
+				if len(results) == 0 || results[len(results)-1] != osu.Hit50 {
+					t.Errorf("expected Hit50 as the final judgement, got %v", results)
+				}
+			} else if len(results) == 0 || results[0] != osu.Hit300 {
+				t.Errorf("expected Hit300 on slider head, got %v", results)
+			}
+		})
+	}
+}
+
+func TestSpinner(t *testing.T) {
+	beatMap := NewMap(5, 4, 5, 9).
+		Timing(0, 500).
+		Spinner(1000, 5000).
+		Build()
+
+	// 0.045 rad/ms is a bit below the maximum spinning speed and gives ~25 rotations, well above OD5 requirement
+	spun := NewScript().
+		MoveTo(1000, 306, 192).
+		Press(1000, false).
+		Spin(1000, 5000, 0.045).
+		Release(5010, false).
+		Frames()
+
+	held := NewScript().
+		MoveTo(1000, 306, 192).
+		Press(1000, false).
+		Release(5010, false).
+		Frames()
+
+	for _, mode := range rulesetModes {
+		t.Run(mode.name, func(t *testing.T) {
+			spunHarness := NewHarness(beatMap, mode.mods)
+			spunHarness.Run(spun)
+
+			heldHarness := NewHarness(beatMap, mode.mods)
+			heldHarness.Run(held)
+
+			spunResults := spunHarness.ResultsFor(0)
+			if len(spunResults) == 0 || spunResults[len(spunResults)-1] != osu.Hit300 {
+				t.Errorf("expected Hit300 after spinning, got %v", spunResults)
+			}
+
+			heldResults := heldHarness.ResultsFor(0)
+			if len(heldResults) == 0 || heldResults[len(heldResults)-1] != osu.Miss {
+				t.Errorf("expected Miss without spinning, got %v", heldResults)
+			}
+
+			if spunHarness.HPNow() <= heldHarness.HPNow() {
+				t.Errorf("expected more health after spinning: %f <= %f", spunHarness.HPNow(), heldHarness.HPNow())
+			}
+
+			if spunHarness.Failed || heldHarness.Failed {
+				t.Error("player shouldn't fail on a single spinner")
+			}
+		})
+	}
+}
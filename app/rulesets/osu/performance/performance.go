@@ -9,30 +9,33 @@ import (
 	"github.com/wieku/danser-go/app/settings"
 )
 
+// Versions lists all available pp algorithm versions, from the oldest one
+var Versions = []string{"211112", "220930", "241007", "250306"}
+
 var diffCalcInit func() api.IDifficultyCalculator
 var ppCalcInit func() api.IPerformanceCalculator
 
-func initConstructors() {
-	if diffCalcInit != nil {
-		return
-	}
-
-	switch settings.Gameplay.PPVersion {
+func getConstructors(version string) (func() api.IDifficultyCalculator, func() api.IPerformanceCalculator) {
+	switch version {
 	case "211112":
-		diffCalcInit = pp211112.NewDifficultyCalculator
-		ppCalcInit = pp211112.NewPPCalculator
+		return pp211112.NewDifficultyCalculator, pp211112.NewPPCalculator
 	case "220930":
-		diffCalcInit = pp220930.NewDifficultyCalculator
-		ppCalcInit = pp220930.NewPPCalculator
+		return pp220930.NewDifficultyCalculator, pp220930.NewPPCalculator
 	case "241007":
-		diffCalcInit = pp241007.NewDifficultyCalculator
-		ppCalcInit = pp241007.NewPPCalculator
+		return pp241007.NewDifficultyCalculator, pp241007.NewPPCalculator
 	default:
-		diffCalcInit = pp250306.NewDifficultyCalculator
-		ppCalcInit = pp250306.NewPPCalculator
+		return pp250306.NewDifficultyCalculator, pp250306.NewPPCalculator
 	}
 }
 
+func initConstructors() {
+	if diffCalcInit != nil {
+		return
+	}
+
+	diffCalcInit, ppCalcInit = getConstructors(settings.Gameplay.PPVersion)
+}
+
 var diffCalc api.IDifficultyCalculator
 
 func GetDifficultyCalculator() api.IDifficultyCalculator {
@@ -50,3 +53,19 @@ func CreatePPCalculator() api.IPerformanceCalculator {
 
 	return ppCalcInit()
 }
+
+// NewDifficultyCalculator creates difficulty calculator of given pp version regardless of settings.Gameplay.PPVersion.
+// Unknown versions fall back to the latest one.
+func NewDifficultyCalculator(version string) api.IDifficultyCalculator {
+	diffInit, _ := getConstructors(version)
+
+	return diffInit()
+}
+
+// NewPPCalculator creates performance calculator of given pp version regardless of settings.Gameplay.PPVersion.
+// Unknown versions fall back to the latest one.
+func NewPPCalculator(version string) api.IPerformanceCalculator {
+	_, ppInit := getConstructors(version)
+
+	return ppInit()
+}
@@ -0,0 +1,301 @@
+package performance
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/api"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/env"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Run "go test ./app/rulesets/osu/performance/ -update" after an intended change in difficulty calculation to regenerate golden files
+var update = flag.Bool("update", false, "Regenerate golden files instead of comparing against them")
+
+const (
+	mapsDir   = "testdata/maps"
+	goldenDir = "testdata/golden"
+
+	// Relative tolerance, allows tiny floating point differences between platforms
+	tolerance = 1e-9
+)
+
+var testMods = []string{"NM", "HD", "HR", "DT", "EZHT", "HDFL", "HRDT"}
+
+// goldenResult holds outputs of a single difficulty calculator version
+type goldenResult struct {
+	Single api.Attributes   `json:"single"`
+	Step   []api.Attributes `json:"step"`
+	Peaks  api.StrainPeaks  `json:"peaks"`
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	env.Init("danser")
+
+	absDir, err := filepath.Abs(mapsDir)
+	if err != nil {
+		panic(err)
+	}
+
+	settings.General.OsuSongsDir = absDir
+
+	os.Exit(m.Run())
+}
+
+func TestDifficultyCalculators(t *testing.T) {
+	maps, err := filepath.Glob(filepath.Join(mapsDir, "*.osu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(maps) == 0 {
+		t.Fatal("no test maps found in", mapsDir)
+	}
+
+	for _, mapPath := range maps {
+		mapName := strings.TrimSuffix(filepath.Base(mapPath), ".osu")
+
+		for _, mods := range testMods {
+			t.Run(mapName+"/"+mods, func(t *testing.T) {
+				beatMap := loadTestMap(t, mapPath)
+
+				results := calculateAll(beatMap, difficulty.ParseMods(mods))
+
+				goldenPath := filepath.Join(goldenDir, fmt.Sprintf("%s_%s.json", mapName, mods))
+
+				if *update {
+					writeGolden(t, goldenPath, results)
+					return
+				}
+
+				compareGolden(t, goldenPath, results)
+			})
+		}
+	}
+}
+
+func loadTestMap(t *testing.T, path string) *beatmap.BeatMap {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	beatMap := beatmap.ParseBeatMapFile(file)
+	if beatMap == nil {
+		t.Fatalf("failed to parse %s", path)
+	}
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, true, false)
+
+	return beatMap
+}
+
+func calculateAll(beatMap *beatmap.BeatMap, mods difficulty.Modifier) map[string]goldenResult {
+	diff := difficulty.NewDifficulty(beatMap.Diff.GetBaseHP(), beatMap.Diff.GetBaseCS(), beatMap.Diff.GetBaseOD(), beatMap.Diff.GetBaseAR())
+	diff.SetMods(mods)
+	diff.DiffCalcMode = true
+
+	beatMap.CalculateStackLeniency(diff)
+
+	results := make(map[string]goldenResult)
+
+	for _, version := range Versions {
+		calc := NewDifficultyCalculator(version)
+
+		results[version] = goldenResult{
+			Single: calc.CalculateSingle(beatMap.HitObjects, diff),
+			Step:   calc.CalculateStep(beatMap.HitObjects, diff),
+			Peaks:  calc.CalculateStrainPeaks(beatMap.HitObjects, diff),
+		}
+	}
+
+	return results
+}
+
+func writeGolden(t *testing.T, path string, results map[string]goldenResult) {
+	t.Helper()
+
+	data, err := json.MarshalIndent(toJSONTree(reflect.ValueOf(results)), "", "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func compareGolden(t *testing.T, path string, results map[string]goldenResult) {
+	t.Helper()
+
+	expectedData, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run tests with -update to create it: %s", err)
+	}
+
+	// Compare generic JSON trees so the diff shows field names without reflection over every result type
+	actualData, err := json.Marshal(toJSONTree(reflect.ValueOf(results)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected, actual any
+
+	if err = json.Unmarshal(expectedData, &expected); err != nil {
+		t.Fatalf("corrupted golden file %s: %s", path, err)
+	}
+
+	if err = json.Unmarshal(actualData, &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	var diffs []string
+
+	diffValues("", expected, actual, &diffs)
+
+	if len(diffs) == 0 {
+		return
+	}
+
+	const maxShown = 30
+
+	shown := diffs[:min(len(diffs), maxShown)]
+
+	msg := fmt.Sprintf("%d values differ from %s:\n\t%s", len(diffs), path, strings.Join(shown, "\n\t"))
+	if len(diffs) > maxShown {
+		msg += fmt.Sprintf("\n\t... and %d more", len(diffs)-maxShown)
+	}
+
+	t.Error(msg)
+}
+
+// toJSONTree converts v to maps, slices and values encoding/json can handle. Calculators leave fields they don't
+// support as NaN, so non-finite floats are stored as strings.
+func toJSONTree(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Sprint(f)
+		}
+
+		return f
+	case reflect.Struct:
+		tree := make(map[string]any, v.NumField())
+
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Name
+			if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" {
+				name = tag
+			}
+
+			tree[name] = toJSONTree(v.Field(i))
+		}
+
+		return tree
+	case reflect.Map:
+		tree := make(map[string]any, v.Len())
+
+		for _, k := range v.MapKeys() {
+			tree[fmt.Sprint(k.Interface())] = toJSONTree(v.MapIndex(k))
+		}
+
+		return tree
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+
+		tree := make([]any, v.Len())
+
+		for i := range tree {
+			tree[i] = toJSONTree(v.Index(i))
+		}
+
+		return tree
+	default:
+		return v.Interface()
+	}
+}
+
+// diffValues walks both JSON trees and appends a line for every value that differs
+func diffValues(path string, expected, actual any, diffs *[]string) {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected object, got %v", path, actual))
+			return
+		}
+
+		keys := make([]string, 0, len(e))
+		for k := range e {
+			keys = append(keys, k)
+		}
+
+		for k := range a {
+			if _, found := e[k]; !found {
+				keys = append(keys, k)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			diffValues(path+"."+k, e[k], a[k], diffs)
+		}
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected array, got %v", path, actual))
+			return
+		}
+
+		if len(e) != len(a) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected %d elements, got %d", path, len(e), len(a)))
+		}
+
+		for i := 0; i < min(len(e), len(a)); i++ {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), e[i], a[i], diffs)
+		}
+	case float64:
+		a, ok := actual.(float64)
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected %v, got %v", path, e, actual))
+			return
+		}
+
+		if math.Abs(e-a) > tolerance*max(1, math.Abs(e)) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected %v, got %v (%+g)", path, e, a, a-e))
+		}
+	default:
+		if expected != actual {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected %v, got %v", path, expected, actual))
+		}
+	}
+}
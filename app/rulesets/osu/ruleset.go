@@ -88,6 +88,7 @@ type subSet struct {
 
 	score          *Score
 	hp             IHealthProcessor
+	passing        *PassingState
 	scoreProcessor scoreProcessor
 
 	currentKatu int
@@ -103,13 +104,13 @@ type subSet struct {
 	potentialCombo int
 }
 
-type hitListener func(cursor *graphics.Cursor, judgementResult JudgementResult, score Score)
+type hitListener = func(cursor *graphics.Cursor, judgementResult JudgementResult, score Score)
 
-type clickListener func(cursor *graphics.Cursor, leftMouse, rightMouse, leftKb, rightKb, smoke ButtonAction)
+type clickListener = func(cursor *graphics.Cursor, leftMouse, rightMouse, leftKb, rightKb, smoke ButtonAction)
 
-type endListener func(time int64, number int64)
+type endListener = func(time int64, number int64)
 
-type failListener func(cursor *graphics.Cursor)
+type failListener = func(cursor *graphics.Cursor)
 
 type OsuRuleSet struct {
 	beatMap *beatmap.BeatMap
@@ -251,6 +252,18 @@ func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, diffs [
 
 		sc.Init(beatMap, player)
 
+		if adaptive := diff.GetAdaptiveSpeed(); adaptive != nil {
+			// Rate may be left over from a previous ruleset of the same player
+			adaptive.Reset()
+
+			// Only circles and slider heads have hit windows in lazer, so slider's end time there is the end time of its head
+			for _, obj := range beatMap.HitObjects {
+				if obj.GetType() != objects.SPINNER {
+					adaptive.AddObject(obj.GetID(), obj.GetStartTime())
+				}
+			}
+		}
+
 		ruleset.cursors[cursor] = &subSet{
 			player: player,
 			score: &Score{
@@ -258,6 +271,7 @@ func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, diffs [
 			},
 			ppv2:           performance.CreatePPCalculator(),
 			hp:             hp,
+			passing:        NewPassingState(beatMap),
 			recoveries:     recoveries,
 			scoreProcessor: sc,
 		}
@@ -287,6 +301,12 @@ func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, diffs [
 }
 
 func (set *OsuRuleSet) Update(time int64) {
+	for _, subSet := range set.cursors {
+		if adaptive := subSet.player.diff.GetAdaptiveSpeed(); adaptive != nil {
+			adaptive.Update(float64(time))
+		}
+	}
+
 	if len(set.processed) > 0 {
 		for i := 0; i < len(set.processed); i++ {
 			g := set.processed[i]
@@ -320,6 +340,7 @@ func (set *OsuRuleSet) Update(time int64) {
 
 	for _, subSet := range set.cursors {
 		subSet.hp.Update(time)
+		subSet.passing.Update(time, subSet.hp.GetHealth(), subSet.failed)
 	}
 
 	if len(set.queue) == 0 && len(set.processed) == 0 && !set.ended {
@@ -483,6 +504,11 @@ func (set *OsuRuleSet) UpdatePostFor(cursor *graphics.Cursor, time int64, proces
 	}
 }
 
+// hasFeedback returns true if hit objects should reflect judgements with their animations and sounds
+func (set *OsuRuleSet) hasFeedback() bool {
+	return len(set.cursors) == 1 && !settings.HEADLESS
+}
+
 func (set *OsuRuleSet) SendResult(cursor *graphics.Cursor, judgementResult JudgementResult) {
 	subSet := set.cursors[cursor]
 
@@ -494,6 +520,15 @@ func (set *OsuRuleSet) SendResult(cursor *graphics.Cursor, judgementResult Judge
 		return
 	}
 
+	// Only first judgement of an object counts, for sliders it's the slider head
+	if adaptive := subSet.player.diff.GetAdaptiveSpeed(); adaptive != nil {
+		if judgementResult.HitResult&(BaseHits|SliderStart) > 0 {
+			adaptive.AddResult(judgementResult.Number, true, float64(judgementResult.Time))
+		} else if judgementResult.HitResult&(Miss|SliderMiss) > 0 {
+			adaptive.AddResult(judgementResult.Number, false, float64(judgementResult.Time))
+		}
+	}
+
 	if (subSet.player.diff.Mods.Active(difficulty.SuddenDeath|difficulty.Perfect) && judgementResult.ComboResult == Reset) ||
 		(subSet.player.diff.Mods.Active(difficulty.Perfect) && (judgementResult.HitResult&BaseHitsM > 0 && judgementResult.HitResult&BaseHitsM != Hit300)) {
 		if judgementResult.HitResult&BaseHitsM > 0 {
@@ -540,7 +575,7 @@ func (set *OsuRuleSet) SendResult(cursor *graphics.Cursor, judgementResult Judge
 		set.hitListener(cursor, judgementResult, *subSet.score)
 	}
 
-	if len(set.cursors) == 1 && judgementResult.HitResult != SliderFinish && !settings.RECORD {
+	if len(set.cursors) == 1 && judgementResult.HitResult != SliderFinish && !settings.RECORD && !settings.HEADLESS {
 		log.Println(fmt.Sprintf(
 			"Got: %3d, Combo: %4d, Max Combo: %4d, Score: %9d, Acc: %6.2f%%, 300: %4d, 100: %3d, 50: %2d, miss: %2d, from: %d, at: %d, pos: %.0fx%.0f, pp: %.2f",
 			judgementResult.HitResult.ScoreValueMod(subSet.player.diff.Mods),
@@ -859,6 +894,18 @@ func (set *OsuRuleSet) GetHP(cursor *graphics.Cursor) float64 {
 	return subSet.hp.GetHealth()
 }
 
+func (set *OsuRuleSet) IsFailed(cursor *graphics.Cursor) bool {
+	return set.cursors[cursor].failed
+}
+
+func (set *OsuRuleSet) IsPassing(cursor *graphics.Cursor) bool {
+	return set.cursors[cursor].passing.IsPassing()
+}
+
+func (set *OsuRuleSet) IsEnded() bool {
+	return set.ended
+}
+
 func (set *OsuRuleSet) GetPlayer(cursor *graphics.Cursor) *difficultyPlayer {
 	subSet := set.cursors[cursor]
 	return subSet.player
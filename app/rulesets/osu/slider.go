@@ -117,7 +117,8 @@ func (slider *Slider) Init(ruleSet *OsuRuleSet, object objects.IHitObject, playe
 func (slider *Slider) UpdateClickFor(player *difficultyPlayer, time int64) bool {
 	state := slider.state[player]
 
-	position := slider.hitSlider.GetStackedStartPositionMod(player.diff)
+	// Lazer's Transform and Wiggle move slider's hit area
+	position := slider.hitSlider.GetStackedStartPositionMod(player.diff).Add(objects.GetFunModOffset(slider.hitSlider, float64(time), player.diff))
 
 	clicked := player.leftCondE || player.rightCondE
 
@@ -153,7 +154,7 @@ func (slider *Slider) UpdateClickFor(player *difficultyPlayer, time int64) bool
 				}
 
 				if hit != Ignore {
-					if len(slider.players) == 1 {
+					if slider.ruleSet.hasFeedback() {
 						slider.hitSlider.HitEdge(0, float64(time), hit != SliderMiss)
 					}
 
@@ -184,7 +185,9 @@ func (slider *Slider) UpdateClickFor(player *difficultyPlayer, time int64) bool
 }
 
 func (slider *Slider) lazerPostHeadProcess(player *difficultyPlayer, state *sliderstate, time int64) {
-	sliderPosition := slider.hitSlider.GetStackedPositionAtModLazer(float64(time), player.diff)
+	funModOffset := objects.GetFunModOffset(slider.hitSlider, float64(time), player.diff)
+
+	sliderPosition := slider.hitSlider.GetStackedPositionAtModLazer(float64(time), player.diff).Add(funModOffset)
 
 	followRadiusFull := player.diff.GetRadius() * 2.4
 
@@ -199,7 +202,7 @@ func (slider *Slider) lazerPostHeadProcess(player *difficultyPlayer, state *slid
 			break
 		}
 
-		currPos := slider.hitSlider.GetStackedPositionAtModLazer(float64(point.time), player.diff)
+		currPos := slider.hitSlider.GetStackedPositionAtModLazer(float64(point.time), player.diff).Add(funModOffset)
 
 		if player.cursor.RawPosition.Dst(currPos) > followRadiusFull {
 			allTicksInRange = false
@@ -213,7 +216,7 @@ func (slider *Slider) lazerPostHeadProcess(player *difficultyPlayer, state *slid
 		state.sliding = true
 		state.slideStart = time
 
-		if len(slider.players) == 1 {
+		if slider.ruleSet.hasFeedback() {
 			slider.hitSlider.InitSlide(float64(time))
 		}
 	}
@@ -240,6 +243,7 @@ func (slider *Slider) UpdateFor(player *difficultyPlayer, time int64, processSli
 	}
 
 	sliderPosition = objects.ModifyPosition(slider.hitSlider.HitObject, sliderPosition, player.diff) // Calculate stacked position
+	sliderPosition = sliderPosition.Add(objects.GetFunModOffset(slider.hitSlider, float64(time), player.diff))
 
 	if time >= int64(slider.hitSlider.GetStartTime()) && ((!state.isHit && !lzMod) || (lzMod && state.isStartHit)) {
 		mouseDownAcceptable := false
@@ -289,7 +293,7 @@ func (slider *Slider) UpdateFor(player *difficultyPlayer, time int64, processSli
 			state.sliding = true
 			state.slideStart = time
 
-			if len(slider.players) == 1 {
+			if slider.ruleSet.hasFeedback() {
 				slider.hitSlider.InitSlide(float64(time))
 			}
 		}
@@ -301,7 +305,7 @@ func (slider *Slider) UpdateFor(player *difficultyPlayer, time int64, processSli
 		}
 
 		if !allowable && state.sliding && state.scored+state.missed < len(state.points) {
-			if len(slider.players) == 1 {
+			if slider.ruleSet.hasFeedback() {
 				slider.hitSlider.KillSlide(float64(time))
 			}
 
@@ -417,7 +421,7 @@ func (slider *Slider) UpdatePostFor(player *difficultyPlayer, time int64, proces
 	}
 
 	if (time >= int64(slider.hitSlider.GetEndTime()) || (processSliderEndsAhead && int64(slider.hitSlider.GetEndTime())-time == 1)) && !state.isHit {
-		if len(slider.players) == 1 && !state.isStartHit && !player.diff.CheckModActive(difficulty.Lazer) {
+		if slider.ruleSet.hasFeedback() && !state.isStartHit && !player.diff.CheckModActive(difficulty.Lazer) {
 			slider.hitSlider.ArmStart(false, float64(time))
 		}
 
@@ -427,7 +431,7 @@ func (slider *Slider) UpdatePostFor(player *difficultyPlayer, time int64, proces
 
 		rate := float64(state.scored) / float64(len(state.points)+1)
 
-		if len(slider.players) == 1 {
+		if slider.ruleSet.hasFeedback() {
 			lzActive := player.diff.CheckModActive(difficulty.Lazer)
 
 			if ((!lzActive || player.lzLegacySound) && rate > 0) || (lzActive && !player.lzLegacySound && state.endScored) {
@@ -477,7 +481,7 @@ func (slider *Slider) processHeadMiss(player *difficultyPlayer, time int64) {
 	state := slider.state[player]
 
 	if time > int64(slider.hitSlider.GetStartTime())+player.diff.Hit50 && !state.isStartHit {
-		if len(slider.players) == 1 && !state.isHit { //don't fade if slider already ended (and armed the start)
+		if slider.ruleSet.hasFeedback() && !state.isHit { //don't fade if slider already ended (and armed the start)
 			slider.hitSlider.ArmStart(false, float64(time))
 		}
 
@@ -522,7 +526,7 @@ func (slider *Slider) MissForcefully(player *difficultyPlayer, time int64) {
 	if !state.isStartHit {
 		position := slider.hitSlider.GetStackedStartPositionMod(player.diff)
 
-		if len(slider.players) == 1 {
+		if slider.ruleSet.hasFeedback() {
 			slider.hitSlider.HitEdge(0, float64(time), false)
 		}
 
@@ -140,7 +140,7 @@ func (spinner *Spinner) processStable(player *difficultyPlayer, time int64) {
 
 		state.currentVelocity = max(-0.05, min(state.currentVelocity, 0.05))
 
-		if len(spinner.players) == 1 {
+		if spinner.ruleSet.hasFeedback() {
 			if state.currentVelocity == 0 {
 				spinner.hitSpinner.PauseSpinSample()
 			} else {
@@ -206,7 +206,7 @@ func (spinner *Spinner) processStable(player *difficultyPlayer, time int64) {
 		state.rotationCountFD += rotationAddition
 		state.rotationCountF += float32(math.Abs(float64(float32(rotationAddition)) / math.Pi))
 
-		if len(spinner.players) == 1 {
+		if spinner.ruleSet.hasFeedback() {
 			spinner.hitSpinner.SetRotation(player.diff.GetModifiedTime(state.rotationCountFD))
 			spinner.hitSpinner.SetRPM(state.rpm)
 			spinner.hitSpinner.UpdateCompletion(float64(state.rotationCountF) / float64(state.requirement))
@@ -217,12 +217,12 @@ func (spinner *Spinner) processStable(player *difficultyPlayer, time int64) {
 		if state.rotationCount != state.lastRotationCount {
 			state.scoringRotationCount++
 
-			if state.scoringRotationCount == spinner.getRequirementClear(player) && len(spinner.players) == 1 {
+			if state.scoringRotationCount == spinner.getRequirementClear(player) && spinner.ruleSet.hasFeedback() {
 				spinner.hitSpinner.Clear()
 			}
 
 			if state.scoringRotationCount > state.requirement+3 && (state.scoringRotationCount-(state.requirement+3))%2 == 0 {
-				if len(spinner.players) == 1 {
+				if spinner.ruleSet.hasFeedback() {
 					spinner.hitSpinner.Bonus(1000)
 				}
 
@@ -268,7 +268,7 @@ func (spinner *Spinner) processLazer(player *difficultyPlayer, time int64) {
 		var deltaRPM float32 = 0
 
 		if player.gameDownState || player.diff.CheckModActive(difficulty.Relax) {
-			delta *= float32(player.diff.GetSpeed())
+			delta *= float32(player.diff.GetSpeedAt(float64(time)))
 
 			if delta != 0 {
 				state.totalAccumulatedRotation += delta
@@ -304,7 +304,7 @@ func (spinner *Spinner) processLazer(player *difficultyPlayer, time int64) {
 
 		state.rotationCountFPrev = mutils.Lerp(state.rotationCountFPrev, state.rotationCountF, 1-math32.Pow(0.99, float32(player.diff.GetModifiedTime(timeDiff))))
 
-		if len(spinner.players) == 1 {
+		if spinner.ruleSet.hasFeedback() {
 			if spinning {
 				spinner.hitSpinner.StartSpinSample()
 			} else {
@@ -318,7 +318,7 @@ func (spinner *Spinner) processLazer(player *difficultyPlayer, time int64) {
 			state.rpm = state.rpm*decay1 + (1.0-decay1)*(math.Abs(float64(deltaRPM)/timeDiff*1000))/360*60
 		}
 
-		if len(spinner.players) == 1 {
+		if spinner.ruleSet.hasFeedback() {
 			spinner.hitSpinner.SetRotation(float64(state.rotationCountFPrev * math32.Pi / 180))
 			spinner.hitSpinner.SetRPM(state.rpm)
 			spinner.hitSpinner.UpdateCompletion(float64(state.getCompletion()))
@@ -327,7 +327,7 @@ func (spinner *Spinner) processLazer(player *difficultyPlayer, time int64) {
 		totalSpins := state.maximumBonusSpins + state.requirement + difficulty.LzSpinBonusGap
 
 		for i := state.lastRotationCount; i < state.rotationCount; i++ {
-			if i == state.requirement && len(spinner.players) == 1 {
+			if i == state.requirement && spinner.ruleSet.hasFeedback() {
 				spinner.hitSpinner.Clear()
 			}
 
@@ -335,14 +335,14 @@ func (spinner *Spinner) processLazer(player *difficultyPlayer, time int64) {
 				if i < state.requirement+difficulty.LzSpinBonusGap {
 					spinner.ruleSet.SendResult(player.cursor, createJudgementResult(SpinnerPoints, SpinnerPoints, Hold, time, spinnerPosition, spinner))
 				} else {
-					if len(spinner.players) == 1 {
+					if spinner.ruleSet.hasFeedback() {
 						spinner.hitSpinner.Bonus(int(SpinnerBonus.ScoreValueMod(player.diff.Mods)))
 					}
 
 					spinner.ruleSet.SendResult(player.cursor, createJudgementResult(SpinnerBonus, SpinnerBonus, Hold, time, spinnerPosition, spinner))
 				}
 			} else {
-				if len(spinner.players) == 1 {
+				if spinner.ruleSet.hasFeedback() {
 					spinner.hitSpinner.Bonus(0)
 				}
 			}
@@ -381,7 +381,7 @@ func (spinner *Spinner) UpdatePostFor(player *difficultyPlayer, time int64, _ bo
 			combo = Increase
 		}
 
-		if len(spinner.players) == 1 {
+		if spinner.ruleSet.hasFeedback() {
 			spinner.hitSpinner.StopSpinSample()
 			spinner.hitSpinner.Hit(float64(time), hit != Miss)
 		}
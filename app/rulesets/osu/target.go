@@ -0,0 +1,30 @@
+package osu
+
+import (
+	"github.com/wieku/danser-go/framework/math/vector"
+)
+
+// Target Practice judges targets by the distance of the click from target's centre, timing only decides whether the target can be hit.
+// Parts of the radius that give 300 and 100, the rest of the target gives 50.
+const (
+	targetRadius300 = 1.0 / 3
+	targetRadius100 = 2.0 / 3
+)
+
+// getTargetResult returns the result of a click on a target at given position, delta is the distance from target's time
+func (circle *Circle) getTargetResult(player *difficultyPlayer, delta float64, position vector.Vector2f) HitResult {
+	if circle.ruleSet.GetResultForDelta(player, delta) == Miss {
+		return Miss
+	}
+
+	distance := float64(player.cursor.RawPosition.Dst(position) / player.diff.GetRadius())
+
+	switch {
+	case distance <= targetRadius300:
+		return Hit300
+	case distance <= targetRadius100:
+		return Hit100
+	}
+
+	return Hit50
+}
@@ -0,0 +1,62 @@
+package rulesets
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/api"
+)
+
+// Game modes as stored in beatmaps and replays
+const (
+	ModeOsu   = 0
+	ModeTaiko = 1
+)
+
+type HitListener = func(cursor *graphics.Cursor, judgementResult osu.JudgementResult, score osu.Score)
+
+type ClickListener = func(cursor *graphics.Cursor, leftMouse, rightMouse, leftKb, rightKb, smoke osu.ButtonAction)
+
+type EndListener = func(time int64, number int64)
+
+type FailListener = func(cursor *graphics.Cursor)
+
+// Ruleset judges cursors' input against the beatmap. Judgements are reported with osu! types so HUD elements can be shared between game modes.
+type Ruleset interface {
+	// UpdateClickFor processes button changes of the cursor
+	UpdateClickFor(cursor *graphics.Cursor, time int64)
+	// UpdateNormalFor processes cursor's position and held buttons
+	UpdateNormalFor(cursor *graphics.Cursor, time int64, processSliderEndsAhead bool)
+	// UpdatePostFor processes judgements that have to happen after all input was handled
+	UpdatePostFor(cursor *graphics.Cursor, time int64, processSliderEndsAhead bool)
+	// Update processes time-based state shared by all cursors, has to be called once per millisecond
+	Update(time int64)
+	// PlayerStopped notifies that cursor's input ended at given time
+	PlayerStopped(cursor *graphics.Cursor, time int64)
+
+	GetScore(cursor *graphics.Cursor) osu.Score
+	GetFCPP(cursor *graphics.Cursor) api.PPv2Results
+	GetSSPP(cursor *graphics.Cursor) api.PPv2Results
+	GetCurrentDiffAttribs(cursor *graphics.Cursor) api.Attributes
+	GetFinalDiffAttribs(cursor *graphics.Cursor) api.Attributes
+
+	GetHP(cursor *graphics.Cursor) float64
+	IsFailed(cursor *graphics.Cursor) bool
+	// IsPassing returns true if storyboard should show its Pass layer for the cursor
+	IsPassing(cursor *graphics.Cursor) bool
+	IsEnded() bool
+
+	SetListener(listener HitListener)
+	SetClickListener(listener ClickListener)
+	SetEndListener(listener EndListener)
+	SetFailListener(listener FailListener)
+
+	GetPlayerDifficulty(cursor *graphics.Cursor) *difficulty.Difficulty
+	GetBeatMap() *beatmap.BeatMap
+}
+
+// IsModeSupported returns true if danser has a ruleset for given game mode
+func IsModeSupported(mode int64) bool {
+	return mode == ModeOsu || mode == ModeTaiko
+}
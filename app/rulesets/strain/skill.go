@@ -0,0 +1,90 @@
+// Package strain contains a strain skill used by difficulty calculators of rulesets other than osu!standard
+package strain
+
+import (
+	"github.com/wieku/danser-go/framework/collections"
+	"math"
+)
+
+// Skill accumulates exponentially decaying strain and keeps its peaks in sections of fixed length, like osu!lazer's StrainDecaySkill
+type Skill struct {
+	// Strain left after one second
+	DecayBase float64
+
+	// The weight by which each strain peak decays
+	DecayWeight float64
+
+	// The length of each strain section
+	SectionLength float64
+
+	currentStrain float64
+	lastTime      float64
+
+	currentSectionPeak float64
+	currentSectionEnd  float64
+
+	started bool
+
+	strainPeaks *collections.SortedList[float64]
+}
+
+func NewSkill(decayBase, decayWeight, sectionLength float64) *Skill {
+	return &Skill{
+		DecayBase:     decayBase,
+		DecayWeight:   decayWeight,
+		SectionLength: sectionLength,
+		strainPeaks:   collections.NewSortedList[float64](),
+	}
+}
+
+// Process adds strain of an object at given time. Times have to be already adjusted by the clock rate.
+func (skill *Skill) Process(time, deltaTime, strain float64) {
+	if !skill.started {
+		skill.currentSectionEnd = math.Ceil(time/skill.SectionLength) * skill.SectionLength
+		skill.started = true
+	}
+
+	for time > skill.currentSectionEnd {
+		if skill.currentSectionPeak > 0 {
+			skill.strainPeaks.Add(skill.currentSectionPeak)
+		}
+
+		skill.currentSectionPeak = skill.currentStrain * skill.decay(skill.currentSectionEnd-skill.lastTime)
+		skill.currentSectionEnd += skill.SectionLength
+	}
+
+	skill.currentStrain = skill.currentStrain*skill.decay(deltaTime) + strain
+	skill.currentSectionPeak = max(skill.currentSectionPeak, skill.currentStrain)
+
+	skill.lastTime = time
+}
+
+func (skill *Skill) decay(ms float64) float64 {
+	return math.Pow(skill.DecayBase, ms/1000)
+}
+
+// DifficultyValue returns weighted sum of strain peaks, including the peak of the current section
+func (skill *Skill) DifficultyValue() float64 {
+	difficulty := 0.0
+	weight := 1.0
+
+	currentAdded := skill.currentSectionPeak <= 0
+
+	for i := skill.strainPeaks.Len() - 1; i >= -1; i-- {
+		if !currentAdded && (i < 0 || skill.currentSectionPeak >= skill.strainPeaks.Get(i)) {
+			difficulty += skill.currentSectionPeak * weight
+			weight *= skill.DecayWeight
+
+			currentAdded = true
+		}
+
+		if i < 0 {
+			break
+		}
+
+		difficulty += skill.strainPeaks.Get(i) * weight
+		weight *= skill.DecayWeight
+	}
+
+	return difficulty
+}
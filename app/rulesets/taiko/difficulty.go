@@ -0,0 +1,152 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/api"
+	"github.com/wieku/danser-go/app/rulesets/strain"
+	"math"
+)
+
+const (
+	starScalingFactor = 0.04125
+
+	rhythmChangeBaseThreshold = 0.2
+	rhythmChangeBase          = 2.0
+)
+
+type colourSwitch int
+
+const (
+	switchNone = colourSwitch(iota)
+	switchEven
+	switchOdd
+)
+
+// strainSkill is a port of osu!lazer's legacy taiko Strain skill. Pattern changes between dons and kats and rhythm changes make notes harder.
+type strainSkill struct {
+	*strain.Skill
+
+	lastColourSwitch colourSwitch
+	sameColourCount  int
+
+	lastDeltaTime float64
+	hasLast       bool
+}
+
+func newStrainSkill() *strainSkill {
+	return &strainSkill{
+		Skill:           strain.NewSkill(0.3, 0.9, 400),
+		sameColourCount: 1,
+	}
+}
+
+func (skill *strainSkill) strainValueOf(last, current objects.IHitObject, deltaTime float64) float64 {
+	addition := 1.0
+
+	lastHit, lastOk := last.(*objects.Circle)
+	currentHit, currentOk := current.(*objects.Circle)
+
+	if lastOk && currentOk && deltaTime < 1000 {
+		if skill.hasColourChange(isRim(lastHit) != isRim(currentHit)) {
+			addition += 0.75
+		}
+
+		if skill.hasRhythmChange(deltaTime) {
+			addition += 1
+		}
+	} else {
+		skill.lastColourSwitch = switchNone
+		skill.sameColourCount = 1
+	}
+
+	additionFactor := 1.0
+
+	// Scale the addition factor linearly from 0.4 to 1 for deltaTime from 0 to 50
+	if deltaTime < 50 {
+		additionFactor = 0.4 + 0.6*deltaTime/50
+	}
+
+	return additionFactor * addition
+}
+
+func (skill *strainSkill) hasRhythmChange(deltaTime float64) bool {
+	if deltaTime == 0 || !skill.hasLast || skill.lastDeltaTime == 0 {
+		return false
+	}
+
+	timeElapsedRatio := max(skill.lastDeltaTime/deltaTime, deltaTime/skill.lastDeltaTime)
+
+	if timeElapsedRatio >= 8 {
+		return false
+	}
+
+	difference := math.Mod(math.Log(timeElapsedRatio)/math.Log(rhythmChangeBase), 1.0)
+
+	return difference > rhythmChangeBaseThreshold && difference < 1-rhythmChangeBaseThreshold
+}
+
+func (skill *strainSkill) hasColourChange(typeChange bool) bool {
+	if !typeChange {
+		skill.sameColourCount++
+		return false
+	}
+
+	oldColourSwitch := skill.lastColourSwitch
+
+	newColourSwitch := switchOdd
+	if skill.sameColourCount%2 == 0 {
+		newColourSwitch = switchEven
+	}
+
+	skill.lastColourSwitch = newColourSwitch
+	skill.sameColourCount = 1
+
+	// Only changes of color switch parity give a bonus
+	return oldColourSwitch != switchNone && oldColourSwitch != newColourSwitch
+}
+
+func isRim(circle *objects.Circle) bool {
+	return circle.GetSample()&(soundWhistle|soundClap) > 0
+}
+
+// CalculateStep calculates successive star ratings of the beatmap, attributes at index i include objects up to i-th one.
+// It uses osu!stable's taiko star rating algorithm, the same one legacy taiko pp is based on.
+func CalculateStep(hitObjects []objects.IHitObject, diff *difficulty.Difficulty) []api.Attributes {
+	attributes := make([]api.Attributes, 0, len(hitObjects))
+
+	skill := newStrainSkill()
+
+	var attr api.Attributes
+
+	for i, o := range hitObjects {
+		switch o.(type) {
+		case *objects.Circle:
+			attr.Circles++
+			attr.MaxCombo++
+		case *objects.Slider:
+			attr.Sliders++
+		case *objects.Spinner:
+			attr.Spinners++
+		}
+
+		attr.ObjectCount++
+
+		if i > 0 {
+			last := hitObjects[i-1]
+
+			deltaTime := (o.GetStartTime() - last.GetStartTime()) / diff.Speed
+
+			skill.Process(o.GetStartTime()/diff.Speed, deltaTime, skill.strainValueOf(last, o, deltaTime))
+
+			skill.lastDeltaTime = deltaTime
+			skill.hasLast = true
+
+			attr.Total = skill.DifficultyValue() * starScalingFactor
+		}
+
+		attributes = append(attributes, attr)
+	}
+
+	return attributes
+}
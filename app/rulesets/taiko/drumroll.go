@@ -0,0 +1,125 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"math"
+)
+
+type drumRollState struct {
+	ObjectState
+
+	hitTicks []bool
+}
+
+type DrumRoll struct {
+	ruleSet *TaikoRuleSet
+	roll    *objects.Slider
+	players []*taikoPlayer
+	state   map[*taikoPlayer]*drumRollState
+
+	strong      bool
+	ticks       []float64
+	tickSpacing float64
+}
+
+func (roll *DrumRoll) Init(ruleSet *TaikoRuleSet, object objects.IHitObject, players []*taikoPlayer) {
+	roll.ruleSet = ruleSet
+	roll.roll = object.(*objects.Slider)
+	roll.players = players
+	roll.state = make(map[*taikoPlayer]*drumRollState)
+
+	roll.strong = roll.roll.GetBaseSample()&soundFinish > 0
+
+	tickRate := 4.0
+	if ruleSet.beatMap.Timings.TickRate == 3 {
+		tickRate = 3
+	}
+
+	roll.tickSpacing = ruleSet.beatMap.Timings.GetPointAt(roll.roll.GetStartTime()).GetBaseBeatLength() / tickRate
+
+	if roll.tickSpacing > 0 {
+		for t := roll.roll.GetStartTime(); t < roll.roll.GetEndTime()+roll.tickSpacing/2; t += roll.tickSpacing {
+			roll.ticks = append(roll.ticks, t)
+		}
+	}
+
+	for _, player := range players {
+		roll.state[player] = &drumRollState{hitTicks: make([]bool, len(roll.ticks))}
+	}
+}
+
+func (roll *DrumRoll) Press(player *taikoPlayer, time int64, _ bool) PressResult {
+	state := roll.state[player]
+
+	if state.Finished {
+		return Passed
+	}
+
+	fTime := float64(time)
+
+	if fTime < roll.roll.GetStartTime() {
+		return Blocked
+	}
+
+	if fTime > roll.roll.GetEndTime() {
+		return Passed
+	}
+
+	for i, tick := range roll.ticks {
+		if state.hitTicks[i] || math.Abs(fTime-tick) > roll.tickSpacing/2 {
+			continue
+		}
+
+		state.hitTicks[i] = true
+		state.Hits++
+
+		roll.ruleSet.SendResult(player.cursor, osu.JudgementResult{
+			HitResult:   osu.SliderPoint,
+			MaxResult:   osu.SliderPoint,
+			ComboResult: osu.Hold,
+			Time:        time,
+			Position:    roll.roll.GetStackedStartPositionMod(player.diff),
+			Number:      roll.roll.GetID(),
+		})
+
+		break
+	}
+
+	return Consumed
+}
+
+func (roll *DrumRoll) UpdateFor(player *taikoPlayer, time int64) bool {
+	state := roll.state[player]
+
+	if !state.Finished && float64(time) > roll.roll.GetEndTime()+roll.tickSpacing/2 {
+		state.Finished = true
+		state.Time = time
+	}
+
+	return state.Finished
+}
+
+func (roll *DrumRoll) IsFinished(player *taikoPlayer) bool {
+	return roll.state[player].Finished
+}
+
+func (roll *DrumRoll) GetState(player *taikoPlayer) ObjectState {
+	return roll.state[player].ObjectState
+}
+
+func (roll *DrumRoll) GetKind() Kind {
+	return KindDrumRoll
+}
+
+func (roll *DrumRoll) IsStrong() bool {
+	return roll.strong
+}
+
+func (roll *DrumRoll) GetNumber() int64 {
+	return roll.roll.GetID()
+}
+
+func (roll *DrumRoll) GetObject() objects.IHitObject {
+	return roll.roll
+}
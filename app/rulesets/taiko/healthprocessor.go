@@ -0,0 +1,57 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/framework/math/mutils"
+)
+
+const (
+	objectCountFactor = 3.0
+
+	Hp300  = 3.0
+	Hp100  = 1.1
+	HpMiss = -1.0
+)
+
+// HealthProcessor is a port of osu!lazer's taiko health processor. Health starts empty and doesn't drain, map is passed if it ends at least half full.
+type HealthProcessor struct {
+	hpMultiplier     float64
+	hpMissMultiplier float64
+
+	health float64
+}
+
+func NewHealthProcessor(beatMap *beatmap.BeatMap, diff *difficulty.Difficulty) *HealthProcessor {
+	hits := 0
+
+	for _, o := range beatMap.HitObjects {
+		if _, ok := o.(*objects.Circle); ok {
+			hits++
+		}
+	}
+
+	return &HealthProcessor{
+		hpMultiplier:     1 / (objectCountFactor * float64(max(1, hits)) * difficulty.DifficultyRate(diff.HPMod, 0.5, 0.75, 0.98)),
+		hpMissMultiplier: difficulty.DifficultyRate(diff.HPMod, 0.0018, 0.0075, 0.0120),
+	}
+}
+
+func (hp *HealthProcessor) AddResult(result osu.JudgementResult) {
+	switch result.HitResult & osu.BaseHitsM {
+	case osu.Hit300:
+		hp.health += Hp300 * hp.hpMultiplier
+	case osu.Hit100:
+		hp.health += Hp100 * hp.hpMultiplier
+	case osu.Miss:
+		hp.health += HpMiss * hp.hpMissMultiplier
+	}
+
+	hp.health = mutils.Clamp(hp.health, 0, 1)
+}
+
+func (hp *HealthProcessor) GetHealth() float64 {
+	return hp.health
+}
@@ -0,0 +1,164 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"math"
+)
+
+type hitState struct {
+	ObjectState
+
+	pending     bool // strong note was hit and waits for the second hand
+	pendingTime int64
+}
+
+type Hit struct {
+	ruleSet *TaikoRuleSet
+	hitNote *objects.Circle
+	players []*taikoPlayer
+	state   map[*taikoPlayer]*hitState
+
+	rim    bool
+	strong bool
+}
+
+func (hit *Hit) Init(ruleSet *TaikoRuleSet, object objects.IHitObject, players []*taikoPlayer) {
+	hit.ruleSet = ruleSet
+	hit.hitNote = object.(*objects.Circle)
+	hit.players = players
+	hit.state = make(map[*taikoPlayer]*hitState)
+
+	sample := hit.hitNote.GetSample()
+
+	hit.rim = sample&(soundWhistle|soundClap) > 0
+	hit.strong = sample&soundFinish > 0
+
+	for _, player := range players {
+		hit.state[player] = new(hitState)
+	}
+}
+
+func (hit *Hit) Press(player *taikoPlayer, time int64, rim bool) PressResult {
+	state := hit.state[player]
+
+	if state.Finished {
+		return Passed
+	}
+
+	if state.pending {
+		if rim != hit.rim || time-state.pendingTime > strongHitWindow {
+			return Passed
+		}
+
+		hit.finish(player, state.Result, state.pendingTime, true)
+
+		return Consumed
+	}
+
+	delta := float64(time) - hit.hitNote.GetStartTime()
+
+	if delta < -player.missWindow {
+		return Blocked
+	}
+
+	result := osu.Miss
+
+	if rim == hit.rim {
+		if math.Abs(delta) < player.greatWindow {
+			result = osu.Hit300
+		} else if math.Abs(delta) < player.okWindow {
+			result = osu.Hit100
+		}
+	}
+
+	if result != osu.Miss && hit.strong {
+		state.Result = result
+		state.pending = true
+		state.pendingTime = time
+
+		return Consumed
+	}
+
+	hit.finish(player, result, time, false)
+
+	return Consumed
+}
+
+func (hit *Hit) UpdateFor(player *taikoPlayer, time int64) bool {
+	state := hit.state[player]
+
+	if state.Finished {
+		return true
+	}
+
+	if state.pending {
+		if time-state.pendingTime > strongHitWindow {
+			hit.finish(player, state.Result, state.pendingTime, false)
+		}
+	} else if float64(time)-hit.hitNote.GetStartTime() >= player.okWindow {
+		hit.finish(player, osu.Miss, time, false)
+	}
+
+	return state.Finished
+}
+
+func (hit *Hit) finish(player *taikoPlayer, result osu.HitResult, time int64, bothHands bool) {
+	state := hit.state[player]
+
+	state.Finished = true
+	state.pending = false
+	state.Time = time
+
+	if bothHands {
+		if result == osu.Hit300 {
+			result = osu.Hit300g
+		} else {
+			result = osu.Hit100k
+		}
+	}
+
+	state.Result = result
+
+	combo := osu.Increase
+	if result == osu.Miss {
+		combo = osu.Reset
+	}
+
+	hit.ruleSet.SendResult(player.cursor, osu.JudgementResult{
+		HitResult:   result,
+		MaxResult:   osu.Hit300,
+		ComboResult: combo,
+		Time:        time,
+		Position:    hit.hitNote.GetStackedStartPositionMod(player.diff),
+		Number:      hit.hitNote.GetID(),
+	})
+}
+
+func (hit *Hit) IsFinished(player *taikoPlayer) bool {
+	return hit.state[player].Finished
+}
+
+func (hit *Hit) GetState(player *taikoPlayer) ObjectState {
+	return hit.state[player].ObjectState
+}
+
+func (hit *Hit) GetKind() Kind {
+	if hit.rim {
+		return KindKat
+	}
+
+	return KindDon
+}
+
+func (hit *Hit) IsStrong() bool {
+	return hit.strong
+}
+
+func (hit *Hit) GetNumber() int64 {
+	return hit.hitNote.GetID()
+}
+
+func (hit *Hit) GetObject() objects.IHitObject {
+	return hit.hitNote
+}
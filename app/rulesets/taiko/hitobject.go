@@ -0,0 +1,56 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+)
+
+// Hitsound flags that decide the type of taiko hit
+const (
+	soundWhistle = 2
+	soundFinish  = 4
+	soundClap    = 8
+)
+
+// Two-handed hits on strong notes have to land within this time after the first one
+const strongHitWindow = 30
+
+type Kind int
+
+const (
+	KindDon = Kind(iota)
+	KindKat
+	KindDrumRoll
+	KindSwell
+)
+
+type PressResult int
+
+const (
+	// Passed means the object didn't react to the press, next object can try to consume it
+	Passed = PressResult(iota)
+	// Consumed means the press was used by the object
+	Consumed
+	// Blocked means the press happened too early for this and all later objects
+	Blocked
+)
+
+type ObjectState struct {
+	Finished bool
+	Result   osu.HitResult // Judgement of a don or kat, Ignore for drum rolls and swells
+	Time     int64         // Time when the object was finished
+	Hits     int           // Hits landed on a drum roll or swell
+	Required int           // Hits needed to clear a swell
+}
+
+type HitObject interface {
+	Init(ruleset *TaikoRuleSet, object objects.IHitObject, players []*taikoPlayer)
+	Press(player *taikoPlayer, time int64, rim bool) PressResult
+	UpdateFor(player *taikoPlayer, time int64) bool
+	IsFinished(player *taikoPlayer) bool
+	GetState(player *taikoPlayer) ObjectState
+	GetKind() Kind
+	IsStrong() bool
+	GetNumber() int64
+	GetObject() objects.IHitObject
+}
@@ -0,0 +1,78 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/api"
+	"math"
+)
+
+var _ api.IPerformanceCalculator = (*PPCalculator)(nil)
+
+// PPCalculator is a port of osu!stable's (legacy) osu!taiko performance calculator. Strain part is stored as Speed.
+type PPCalculator struct{}
+
+func (calc *PPCalculator) Calculate(attribs api.Attributes, score api.PerfScore, diff *difficulty.Difficulty) api.PPv2Results {
+	totalHits := score.CountGreat + score.CountOk + score.CountMeh + score.CountMiss
+
+	multiplier := 1.1
+
+	if diff.CheckModActive(difficulty.NoFail) {
+		multiplier *= 0.9
+	}
+
+	if diff.CheckModActive(difficulty.Hidden) {
+		multiplier *= 1.1
+	}
+
+	strainValue := calc.strainValue(attribs, score, diff, totalHits)
+	accValue := calc.accuracyValue(score, diff, totalHits)
+
+	return api.PPv2Results{
+		Speed: strainValue,
+		Acc:   accValue,
+		Total: math.Pow(math.Pow(strainValue, 1.1)+math.Pow(accValue, 1.1), 1.0/1.1) * multiplier,
+	}
+}
+
+func (calc *PPCalculator) strainValue(attribs api.Attributes, score api.PerfScore, diff *difficulty.Difficulty, totalHits int) float64 {
+	strainValue := math.Pow(5.0*max(1.0, attribs.Total/0.0075)-4.0, 2.0) / 100000.0
+
+	// Longer maps are worth more
+	lengthBonus := 1 + 0.1*min(1.0, float64(totalHits)/1500.0)
+	strainValue *= lengthBonus
+
+	// Penalize misses exponentially
+	strainValue *= math.Pow(0.985, float64(score.CountMiss))
+
+	if diff.CheckModActive(difficulty.Hidden) {
+		strainValue *= 1.025
+	}
+
+	if diff.CheckModActive(difficulty.Flashlight) {
+		strainValue *= 1.05 * lengthBonus
+	}
+
+	// Scale the strain value with accuracy slightly
+	return strainValue * score.Accuracy
+}
+
+func (calc *PPCalculator) accuracyValue(score api.PerfScore, diff *difficulty.Difficulty, totalHits int) float64 {
+	greatWindow, _, _ := getHitWindows(diff)
+	greatWindow /= diff.Speed
+
+	if greatWindow <= 0 {
+		return 0
+	}
+
+	accValue := math.Pow(150.0/greatWindow, 1.1) * math.Pow(score.Accuracy, 15) * 22.0
+
+	// Bonus for many hits, it's harder to keep good accuracy up for longer
+	return accValue * min(1.15, math.Pow(float64(totalHits)/1500.0, 0.3))
+}
+
+// getHitWindows returns great, ok and miss hit windows of osu!taiko
+func getHitWindows(diff *difficulty.Difficulty) (great, ok, miss float64) {
+	od := difficulty.DiffFromRate(diff.Hit300U, 80, 50, 20) // Recover OD adjusted by mods
+
+	return difficulty.DifficultyRate(od, 50, 35, 20), difficulty.DifficultyRate(od, 120, 80, 50), difficulty.DifficultyRate(od, 135, 95, 70)
+}
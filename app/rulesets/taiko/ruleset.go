@@ -0,0 +1,467 @@
+package taiko
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/audio"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/api"
+	"github.com/wieku/danser-go/app/settings"
+	"log"
+)
+
+var _ rulesets.Ruleset = (*TaikoRuleSet)(nil)
+
+// Button order as stored in osu!stable taiko replays: M1, M2, K1, K2
+const (
+	leftCentre = iota
+	leftRim
+	rightCentre
+	rightRim
+)
+
+type taikoPlayer struct {
+	cursor *graphics.Cursor
+	diff   *difficulty.Difficulty
+
+	maskedModString string
+
+	greatWindow float64
+	okWindow    float64
+	missWindow  float64
+
+	buttons     [4]bool
+	smokeButton bool
+}
+
+type subSet struct {
+	player *taikoPlayer
+
+	score   *osu.Score
+	hp      *HealthProcessor
+	passing *osu.PassingState
+
+	scoreValue    int64
+	combo         int64
+	modMultiplier float64
+
+	accRaw float64
+	accMax float64
+
+	// Index of the latest object that got a judgement, used to pick difficulty attributes for pp
+	lastObject int64
+
+	failed   bool
+	sdpfFail bool
+}
+
+type TaikoRuleSet struct {
+	beatMap *beatmap.BeatMap
+	cursors map[*graphics.Cursor]*subSet
+
+	objects []HitObject
+	queue   []HitObject
+
+	maxCombo int
+
+	diffAttribs map[string][]api.Attributes
+	ppCalc      *PPCalculator
+
+	ended bool
+
+	hitListener   rulesets.HitListener
+	endListener   rulesets.EndListener
+	failListener  rulesets.FailListener
+	clickListener rulesets.ClickListener
+}
+
+func NewTaikoRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, diffs []*difficulty.Difficulty) *TaikoRuleSet {
+	log.Println("Creating osu!taiko ruleset...")
+
+	ruleset := new(TaikoRuleSet)
+	ruleset.beatMap = beatMap
+	ruleset.cursors = make(map[*graphics.Cursor]*subSet)
+	ruleset.diffAttribs = make(map[string][]api.Attributes)
+	ruleset.ppCalc = &PPCalculator{}
+
+	players := make([]*taikoPlayer, 0, len(cursors))
+
+	for i, cursor := range cursors {
+		diff := diffs[i]
+
+		player := &taikoPlayer{
+			cursor:          cursor,
+			diff:            diff,
+			maskedModString: diff.GetModStringMasked(),
+		}
+
+		player.greatWindow, player.okWindow, player.missWindow = getHitWindows(diff)
+
+		players = append(players, player)
+
+		if ruleset.diffAttribs[player.maskedModString] == nil {
+			attribs := CalculateStep(beatMap.HitObjects, diff)
+
+			ruleset.diffAttribs[player.maskedModString] = attribs
+
+			log.Println("Stars:", attribs[len(attribs)-1].Total)
+
+			ssPP := ruleset.ppCalc.Calculate(attribs[len(attribs)-1], api.PerfScore{CountGreat: attribs[len(attribs)-1].MaxCombo, Accuracy: 1}, diff)
+
+			log.Println(fmt.Sprintf("SS PP: %.2f (Strain: %.2f, Acc: %.2f)", ssPP.Total, ssPP.Speed, ssPP.Acc))
+		}
+
+		ruleset.cursors[cursor] = &subSet{
+			player:        player,
+			score:         &osu.Score{Accuracy: 1},
+			hp:            NewHealthProcessor(beatMap, diff),
+			passing:       osu.NewPassingState(beatMap),
+			modMultiplier: diff.GetScoreMultiplier(),
+		}
+	}
+
+	for _, obj := range beatMap.HitObjects {
+		var tObject HitObject
+
+		switch obj.(type) {
+		case *objects.Circle:
+			tObject = new(Hit)
+			ruleset.maxCombo++
+		case *objects.Slider:
+			tObject = new(DrumRoll)
+		case *objects.Spinner:
+			tObject = new(Swell)
+		default:
+			continue
+		}
+
+		tObject.Init(ruleset, obj, players)
+
+		ruleset.objects = append(ruleset.objects, tObject)
+	}
+
+	ruleset.queue = append(ruleset.queue, ruleset.objects...)
+
+	return ruleset
+}
+
+func (set *TaikoRuleSet) Update(time int64) {
+	for _, subSet := range set.cursors {
+		subSet.passing.Update(time, subSet.hp.GetHealth(), subSet.failed)
+	}
+
+	for i := 0; i < len(set.queue); i++ {
+		g := set.queue[i]
+
+		if g.GetObject().GetStartTime() > float64(time) {
+			break
+		}
+
+		finished := true
+
+		for _, subSet := range set.cursors {
+			finished = finished && g.IsFinished(subSet.player)
+		}
+
+		if finished {
+			if set.endListener != nil {
+				set.endListener(time, g.GetNumber())
+			}
+
+			set.queue = append(set.queue[:i], set.queue[i+1:]...)
+
+			i--
+		}
+	}
+
+	if len(set.queue) == 0 && !set.ended {
+		for _, subSet := range set.cursors {
+			// Like in osu!stable, map is failed if it ends with health below half
+			if subSet.hp.GetHealth() < 0.5 && !subSet.player.diff.CheckModActive(difficulty.NoFail|difficulty.Relax) {
+				set.fail(subSet)
+			}
+
+			log.Println(fmt.Sprintf("%s: %d, %.2f%%, %dx, %.2fpp", subSet.player.cursor.Name, subSet.score.Score, subSet.score.Accuracy*100, subSet.score.Combo, subSet.score.PP.Total))
+		}
+
+		set.ended = true
+	}
+}
+
+func (set *TaikoRuleSet) UpdateClickFor(cursor *graphics.Cursor, time int64) {
+	subSet := set.cursors[cursor]
+	player := subSet.player
+
+	current := [4]bool{cursor.LeftMouse, cursor.RightMouse, cursor.LeftKey, cursor.RightKey}
+
+	var actions [4]osu.ButtonAction
+
+	for i := range actions {
+		actions[i] = buttonActionType(&player.buttons[i], current[i])
+	}
+
+	smokeAction := buttonActionType(&player.smokeButton, cursor.SmokeKey)
+
+	if (actions[0]|actions[1]|actions[2]|actions[3]|smokeAction)&(osu.Clicked|osu.Released) > 0 && set.clickListener != nil {
+		set.clickListener(cursor, actions[leftCentre], actions[leftRim], actions[rightCentre], actions[rightRim], smokeAction)
+	}
+
+	if subSet.failed {
+		return
+	}
+
+	for i, action := range actions {
+		if action&osu.Clicked > 0 {
+			set.press(player, time, i == leftRim || i == rightRim)
+		}
+	}
+}
+
+func buttonActionType(previous *bool, current bool) (ac osu.ButtonAction) {
+	ac = osu.Resting
+
+	if !*previous && current {
+		ac = osu.Clicked
+	} else if *previous && !current {
+		ac = osu.Released
+	} else if *previous && current {
+		ac = osu.Pressed
+	}
+
+	*previous = current
+
+	return
+}
+
+func (set *TaikoRuleSet) press(player *taikoPlayer, time int64, rim bool) {
+	if set.hasFeedback() {
+		point := set.beatMap.Timings.GetPointAt(float64(time))
+
+		sample := 1
+		if rim {
+			sample = soundClap
+		}
+
+		audio.PlaySample(point.SampleSet, 0, sample, point.SampleIndex, point.SampleVolume, -1, 256)
+	}
+
+	for _, g := range set.queue {
+		if g.IsFinished(player) {
+			continue
+		}
+
+		if result := g.Press(player, time, rim); result != Passed {
+			return
+		}
+	}
+}
+
+func (set *TaikoRuleSet) UpdateNormalFor(cursor *graphics.Cursor, time int64, _ bool) {
+	player := set.cursors[cursor].player
+
+	for _, g := range set.queue {
+		if g.GetObject().GetStartTime() > float64(time) {
+			break
+		}
+
+		g.UpdateFor(player, time)
+	}
+}
+
+func (set *TaikoRuleSet) UpdatePostFor(_ *graphics.Cursor, _ int64, _ bool) {}
+
+// PlayerStopped does nothing as remaining notes will be missed anyway and taiko doesn't drain health
+func (set *TaikoRuleSet) PlayerStopped(_ *graphics.Cursor, _ int64) {}
+
+// hasFeedback returns true if drum sounds should be played on presses
+func (set *TaikoRuleSet) hasFeedback() bool {
+	return len(set.cursors) == 1 && !settings.HEADLESS
+}
+
+func (set *TaikoRuleSet) SendResult(cursor *graphics.Cursor, judgementResult osu.JudgementResult) {
+	subSet := set.cursors[cursor]
+	mods := subSet.player.diff.Mods
+
+	baseResult := judgementResult.HitResult & osu.BaseHitsM
+
+	if (mods.Active(difficulty.SuddenDeath|difficulty.Perfect) && judgementResult.ComboResult == osu.Reset) ||
+		(mods.Active(difficulty.Perfect) && baseResult > 0 && baseResult != osu.Hit300) {
+		judgementResult.HitResult = osu.Miss
+		judgementResult.ComboResult = osu.Reset
+		baseResult = osu.Miss
+
+		subSet.sdpfFail = true
+	}
+
+	// Score is calculated like in osu!stable's ScoreV1 for taiko, without the difficulty multiplier
+	value := int64(0)
+
+	switch {
+	case baseResult == osu.Hit300:
+		value = 300
+	case baseResult == osu.Hit100:
+		value = 150
+	case judgementResult.HitResult&(osu.SliderPoint|osu.SpinnerSpin) > 0:
+		value = 300
+	}
+
+	if judgementResult.HitResult&(osu.GekiAddition|osu.KatuAddition) > 0 {
+		value *= 2
+	}
+
+	if judgementResult.ComboResult == osu.Reset {
+		subSet.combo = 0
+	} else if judgementResult.ComboResult == osu.Increase {
+		subSet.combo++
+	}
+
+	if baseResult&(osu.Hit300|osu.Hit100) > 0 {
+		subSet.scoreValue += value + int64(float64(value)*float64(min(subSet.combo/10, 10))/10*subSet.modMultiplier)
+	} else {
+		subSet.scoreValue += value
+	}
+
+	if baseResult > 0 {
+		subSet.accMax++
+
+		if baseResult == osu.Hit300 {
+			subSet.accRaw++
+		} else if baseResult == osu.Hit100 {
+			subSet.accRaw += 0.5
+		}
+	}
+
+	subSet.score.AddResult(judgementResult)
+
+	if judgementResult.HitResult&osu.GekiAddition > 0 {
+		subSet.score.CountGeki++
+	} else if judgementResult.HitResult&osu.KatuAddition > 0 {
+		subSet.score.CountKatu++
+	}
+
+	subSet.score.Score = subSet.scoreValue
+	subSet.score.CurrentCombo = uint(subSet.combo)
+	subSet.score.Combo = max(subSet.score.CurrentCombo, subSet.score.Combo)
+
+	if subSet.accMax > 0 {
+		subSet.score.Accuracy = subSet.accRaw / subSet.accMax
+	}
+
+	subSet.score.CalculateGrade(mods)
+	subSet.score.PerfectCombo = uint(set.maxCombo) == subSet.score.Combo
+
+	subSet.lastObject = max(subSet.lastObject, judgementResult.Number)
+	subSet.score.PP = set.ppCalc.Calculate(set.GetCurrentDiffAttribs(cursor), subSet.score.ToPerfScore(), subSet.player.diff)
+
+	subSet.hp.AddResult(judgementResult)
+
+	if set.hitListener != nil {
+		set.hitListener(cursor, judgementResult, *subSet.score)
+	}
+
+	if subSet.sdpfFail {
+		set.fail(subSet)
+	}
+}
+
+func (set *TaikoRuleSet) fail(subSet *subSet) {
+	if subSet.failed || (subSet.player.cursor.IsReplay && settings.Gameplay.IgnoreFailsInReplays) {
+		return
+	}
+
+	subSet.failed = true
+
+	if set.failListener != nil {
+		set.failListener(subSet.player.cursor)
+	}
+}
+
+func (set *TaikoRuleSet) SetListener(listener rulesets.HitListener) {
+	set.hitListener = listener
+}
+
+func (set *TaikoRuleSet) SetClickListener(listener rulesets.ClickListener) {
+	set.clickListener = listener
+}
+
+func (set *TaikoRuleSet) SetEndListener(listener rulesets.EndListener) {
+	set.endListener = listener
+}
+
+func (set *TaikoRuleSet) SetFailListener(listener rulesets.FailListener) {
+	set.failListener = listener
+}
+
+func (set *TaikoRuleSet) GetFCPP(cursor *graphics.Cursor) api.PPv2Results {
+	subSet := set.cursors[cursor]
+
+	apiScore := subSet.score.ToPerfScore()
+	apiScore.CountGreat += apiScore.CountMiss
+	apiScore.CountMiss = 0
+	apiScore.Accuracy = 1
+
+	if total := apiScore.CountGreat + apiScore.CountOk; total > 0 {
+		apiScore.Accuracy = (float64(apiScore.CountGreat) + float64(apiScore.CountOk)*0.5) / float64(total)
+	}
+
+	return set.ppCalc.Calculate(set.GetCurrentDiffAttribs(cursor), apiScore, subSet.player.diff)
+}
+
+func (set *TaikoRuleSet) GetSSPP(cursor *graphics.Cursor) api.PPv2Results {
+	diff := set.GetCurrentDiffAttribs(cursor)
+
+	return set.ppCalc.Calculate(diff, api.PerfScore{CountGreat: diff.MaxCombo, Accuracy: 1}, set.cursors[cursor].player.diff)
+}
+
+func (set *TaikoRuleSet) GetCurrentDiffAttribs(cursor *graphics.Cursor) api.Attributes {
+	subSet := set.cursors[cursor]
+
+	return set.diffAttribs[subSet.player.maskedModString][subSet.lastObject]
+}
+
+func (set *TaikoRuleSet) GetFinalDiffAttribs(cursor *graphics.Cursor) api.Attributes {
+	attribs := set.diffAttribs[set.cursors[cursor].player.maskedModString]
+
+	return attribs[len(attribs)-1]
+}
+
+func (set *TaikoRuleSet) GetScore(cursor *graphics.Cursor) osu.Score {
+	return *(set.cursors[cursor].score)
+}
+
+func (set *TaikoRuleSet) GetHP(cursor *graphics.Cursor) float64 {
+	return set.cursors[cursor].hp.GetHealth()
+}
+
+func (set *TaikoRuleSet) IsFailed(cursor *graphics.Cursor) bool {
+	return set.cursors[cursor].failed
+}
+
+func (set *TaikoRuleSet) IsPassing(cursor *graphics.Cursor) bool {
+	return set.cursors[cursor].passing.IsPassing()
+}
+
+func (set *TaikoRuleSet) IsEnded() bool {
+	return set.ended
+}
+
+func (set *TaikoRuleSet) GetPlayerDifficulty(cursor *graphics.Cursor) *difficulty.Difficulty {
+	return set.cursors[cursor].player.diff
+}
+
+func (set *TaikoRuleSet) GetBeatMap() *beatmap.BeatMap {
+	return set.beatMap
+}
+
+// GetObjects returns all taiko objects in the beatmap order, including finished ones
+func (set *TaikoRuleSet) GetObjects() []HitObject {
+	return set.objects
+}
+
+func (set *TaikoRuleSet) GetObjectState(cursor *graphics.Cursor, object HitObject) ObjectState {
+	return object.GetState(set.cursors[cursor].player)
+}
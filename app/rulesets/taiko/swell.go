@@ -0,0 +1,118 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+)
+
+type swellState struct {
+	ObjectState
+
+	lastRim bool
+}
+
+type Swell struct {
+	ruleSet *TaikoRuleSet
+	swell   *objects.Spinner
+	players []*taikoPlayer
+	state   map[*taikoPlayer]*swellState
+}
+
+func (swell *Swell) Init(ruleSet *TaikoRuleSet, object objects.IHitObject, players []*taikoPlayer) {
+	swell.ruleSet = ruleSet
+	swell.swell = object.(*objects.Spinner)
+	swell.players = players
+	swell.state = make(map[*taikoPlayer]*swellState)
+
+	for _, player := range players {
+		state := new(swellState)
+
+		// The same formula as in osu!stable
+		state.Required = max(1, int(swell.swell.GetDuration()/1000*player.diff.SpinnerRatio*1.65))
+
+		swell.state[player] = state
+	}
+}
+
+func (swell *Swell) Press(player *taikoPlayer, time int64, rim bool) PressResult {
+	state := swell.state[player]
+
+	if state.Finished {
+		return Passed
+	}
+
+	fTime := float64(time)
+
+	if fTime < swell.swell.GetStartTime() {
+		return Blocked
+	}
+
+	if fTime > swell.swell.GetEndTime() {
+		return Passed
+	}
+
+	// Dons and kats have to alternate
+	if state.Hits > 0 && rim == state.lastRim {
+		return Consumed
+	}
+
+	state.Hits++
+	state.lastRim = rim
+
+	swell.sendResult(player, osu.SpinnerSpin, time)
+
+	if state.Hits >= state.Required {
+		state.Finished = true
+		state.Time = time
+
+		swell.sendResult(player, osu.SpinnerBonus, time)
+	}
+
+	return Consumed
+}
+
+func (swell *Swell) UpdateFor(player *taikoPlayer, time int64) bool {
+	state := swell.state[player]
+
+	if !state.Finished && float64(time) > swell.swell.GetEndTime() {
+		state.Finished = true
+		state.Time = time
+	}
+
+	return state.Finished
+}
+
+func (swell *Swell) sendResult(player *taikoPlayer, result osu.HitResult, time int64) {
+	swell.ruleSet.SendResult(player.cursor, osu.JudgementResult{
+		HitResult:   result,
+		MaxResult:   result,
+		ComboResult: osu.Hold,
+		Time:        time,
+		Position:    swell.swell.GetStackedStartPositionMod(player.diff),
+		Number:      swell.swell.GetID(),
+	})
+}
+
+func (swell *Swell) IsFinished(player *taikoPlayer) bool {
+	return swell.state[player].Finished
+}
+
+func (swell *Swell) GetState(player *taikoPlayer) ObjectState {
+	return swell.state[player].ObjectState
+}
+
+func (swell *Swell) GetKind() Kind {
+	return KindSwell
+}
+
+func (swell *Swell) IsStrong() bool {
+	return false
+}
+
+func (swell *Swell) GetNumber() int64 {
+	return swell.swell.GetID()
+}
+
+func (swell *Swell) GetObject() objects.IHitObject {
+	return swell.swell
+}
@@ -142,3 +142,13 @@ func (d *defaultsFactory) InitPippi() *pippi {
 		SpinnerRadius:    100,
 	}
 }
+
+type script struct {
+	File string `file:"Select mover script" filter:"Mover script (*.txt)|txt" tooltip:"Script computing cursor position between objects. Relative paths are resolved against danser's directory"`
+}
+
+func (d *defaultsFactory) InitScript() *script {
+	return &script{
+		File: "",
+	}
+}
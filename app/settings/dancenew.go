@@ -14,6 +14,16 @@ func initCursorDance() *cursorDance {
 		Battle:             false,
 		DoSpinnersTogether: true,
 		TAGSliderDance:     false,
+		Sections:           []*moverSection{},
+		SectionCrossfade:   true,
+		Humanize: &humanize{
+			Enabled:      false,
+			UnstableRate: 100,
+			HitOffset:    0,
+			AimJitter:    0.05,
+			MissRate:     0,
+			Seed:         0,
+		},
 		MoverSettings: &moverSettings{
 			Bezier: []*bezier{
 				DefaultsFactory.InitBezier(),
@@ -39,12 +49,15 @@ func initCursorDance() *cursorDance {
 			Pippi: []*pippi{
 				DefaultsFactory.InitPippi(),
 			},
+			Script: []*script{
+				DefaultsFactory.InitScript(),
+			},
 		},
 	}
 }
 
 type mover struct {
-	Mover             string `combo:"spline,bezier,circular,linear,axis,aggressive,flower,momentum,exgon,pippi"`
+	Mover             string `combo:"spline,bezier,circular,linear,axis,aggressive,flower,momentum,exgon,pippi,script"`
 	SliderDance       bool
 	RandomSliderDance bool
 }
@@ -72,16 +85,50 @@ func (d *defaultsFactory) InitSpinner() *spinner {
 	}
 }
 
+type moverSection struct {
+	Type              string  `combo:"time|Time range,kiai|Kiai sections,objects|Object index range"`
+	Start             float64 `min:"0" max:"1000000" showif:"Type=time,objects" tooltip:"Time in milliseconds or index of the first object, depending on section type"`
+	End               float64 `min:"0" max:"1000000" showif:"Type=time,objects" tooltip:"Time in milliseconds or index of the last object, depending on section type"`
+	Mover             string  `combo:"spline,bezier,circular,linear,axis,aggressive,flower,momentum,exgon,pippi,script"`
+	MoverSettings     int     `label:"Mover settings index" max:"10" tooltip:"Which entry of mover's list in Mover settings is used"`
+	SliderDance       bool
+	RandomSliderDance bool
+	Spinner           string `combo:"heart,triangle,square,cube,circle"`
+}
+
+func (d *defaultsFactory) InitMoverSection() *moverSection {
+	return &moverSection{
+		Type:          "kiai",
+		Start:         0,
+		End:           0,
+		Mover:         "momentum",
+		MoverSettings: 0,
+		Spinner:       "circle",
+	}
+}
+
 type cursorDance struct {
-	Movers             []*mover   `new:"InitMover" wiki:"Help|https://github.com/Wieku/danser-go/wiki/Movers#available-movers"`
-	Spinners           []*spinner `new:"InitSpinner" wiki:"Help|https://github.com/Wieku/danser-go/wiki/Movers#available-spinner-movers"`
-	ComboTag           bool       `liveedit:"false"`
-	Battle             bool       `liveedit:"false"`
-	DoSpinnersTogether bool       `liveedit:"false"`
-	TAGSliderDance     bool       `label:"TAG slider dance" liveedit:"false"`
+	Movers             []*mover        `new:"InitMover" wiki:"Help|https://github.com/Wieku/danser-go/wiki/Movers#available-movers"`
+	Spinners           []*spinner      `new:"InitSpinner" wiki:"Help|https://github.com/Wieku/danser-go/wiki/Movers#available-spinner-movers"`
+	ComboTag           bool            `liveedit:"false"`
+	Battle             bool            `liveedit:"false"`
+	DoSpinnersTogether bool            `liveedit:"false"`
+	TAGSliderDance     bool            `label:"TAG slider dance" liveedit:"false"`
+	Sections           []*moverSection `new:"InitMoverSection" label:"Mover sections" liveedit:"false" tooltip:"Use different movers in parts of the map, the first matching section is used. Objects outside of sections use movers above"`
+	SectionCrossfade   bool            `label:"Crossfade between sections" liveedit:"false" tooltip:"Blend the movement from the last object of a section to the first object of the next one instead of switching movers instantly"`
+	Humanize           *humanize       `label:"Humanized input" liveedit:"false"`
 	MoverSettings      *moverSettings
 }
 
+type humanize struct {
+	Enabled      bool    `tooltip:"Cursors hit objects with human-like timing and aim errors instead of perfectly. Results are judged normally"`
+	UnstableRate float64 `label:"Target unstable rate" max:"300" format:"%.0f" showif:"Enabled=true"`
+	HitOffset    float64 `label:"Hit offset bias" min:"-50" max:"50" format:"%.0fms" showif:"Enabled=true" tooltip:"Average hit error, positive values make hits late"`
+	AimJitter    float64 `max:"0.5" scale:"100.0" format:"%.0f%%" showif:"Enabled=true" tooltip:"Aim error as a percentage of the distance to the object. It gets bigger for jumps faster than 150ms"`
+	MissRate     float64 `max:"0.2" scale:"100.0" format:"%.1f%%" showif:"Enabled=true" tooltip:"Chance to not press the key for an object at all"`
+	Seed         int64   `showif:"Enabled=true" tooltip:"Random seed, the same seed gives the same errors on the same map. 0 uses a different seed every time"`
+}
+
 type moverSettings struct {
 	Bezier     []*bezier   `new:"InitBezier"`
 	Flower     []*flower   `new:"InitFlower"`
@@ -91,4 +138,5 @@ type moverSettings struct {
 	ExGon      []*exgon    `new:"InitExGon"`
 	Linear     []*linear   `new:"InitLinear"`
 	Pippi      []*pippi    `new:"InitPippi"`
+	Script     []*script   `new:"InitScript"`
 }
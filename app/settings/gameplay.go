@@ -1,5 +1,10 @@
 package settings
 
+import (
+	"github.com/wieku/danser-go/framework/env"
+	"path/filepath"
+)
+
 var Gameplay = initGameplay()
 
 func initGameplay() *gameplay {
@@ -240,6 +245,8 @@ func initGameplay() *gameplay {
 		ShowHitLighting:         false,
 		FlashlightDim:           1,
 		PlayUsername:            "Guest",
+		SavePlayReplays:         false,
+		PlayReplaysDir:          "replays",
 		IgnoreFailsInReplays:    false,
 		PPVersion:               "latest",
 		LazerClassicScore:       false,
@@ -270,9 +277,27 @@ type gameplay struct {
 	ShowHitLighting         bool
 	FlashlightDim           float64
 	PlayUsername            string `liveedit:"false"`
+	SavePlayReplays         bool   `label:"Save replays in play mode" liveedit:"false"`
+	PlayReplaysDir          string `label:"Play mode replays directory" path:"Select play mode replays directory" tooltip:"Relative paths are resolved against danser's directory" liveedit:"false"`
 	IgnoreFailsInReplays    bool
 	PPVersion               string `liveedit:"false" label:"PP counter version" combo:"211112|2021 pp rework (First Xexxar),220930|2022 pp rework,241007|2024 pp rework,latest|2025 Q1 update (latest)"`
 	LazerClassicScore       bool   `label:"Use \"Classic\" score for osu!lazer plays"`
+
+	playReplaysDir *string
+}
+
+func (g *gameplay) GetPlayReplaysDir() string {
+	if g.playReplaysDir == nil {
+		dir := filepath.Join(env.DataDir(), g.PlayReplaysDir)
+
+		if filepath.IsAbs(g.PlayReplaysDir) {
+			dir = g.PlayReplaysDir
+		}
+
+		g.playReplaysDir = &dir
+	}
+
+	return *g.playReplaysDir
 }
 
 type boundaries struct {
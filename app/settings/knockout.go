@@ -19,6 +19,10 @@ func initKnockout() *knockout {
 		MaxCursorSize:       7.0,
 		AddDanser:           false,
 		DanserName:          "danser",
+		TeamScoring:         "Sum",
+		TeamBestCount:       3,
+		TeamCursorColors:    true,
+		TeamResultsScreen:   true,
 	}
 }
 
@@ -67,6 +71,18 @@ type knockout struct {
 	// Self explanatory
 	AddDanser  bool   `liveedit:"false"`
 	DanserName string `label:"Danser's name" tooltip:"It's also used in danser replay mode" liveedit:"false"`
+
+	// How team totals are calculated when replays in -knockout2 are assigned to teams. Best counts only TeamBestCount best players of each team
+	TeamScoring string `combo:"Sum,Average,Best" tooltip:"Applicable only to team knockout"`
+
+	// Number of players counted in TeamScoring = Best
+	TeamBestCount int `label:"Best players counted" string:"true" min:"1" max:"100" showif:"TeamScoring=Best"`
+
+	// Whether cursors and player names should use colors of their teams
+	TeamCursorColors bool `label:"Use team colors for cursors" tooltip:"Applicable only to team knockout"`
+
+	// Whether team ranking should be shown after the last object
+	TeamResultsScreen bool `label:"Show team results screen" tooltip:"Applicable only to team knockout"`
 }
 
 type KnockoutMode int
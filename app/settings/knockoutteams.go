@@ -0,0 +1,129 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"strconv"
+	"strings"
+)
+
+// Colors given to teams that don't have one specified
+var defaultTeamColors = []uint32{0xff4f4f, 0x4f8fff, 0x5fdf5f, 0xffd74f, 0xbf6fff, 0xff9f3f, 0x4fdfdf, 0xff7fcf}
+
+// KnockoutTeam is a team replays in team knockout are assigned to
+type KnockoutTeam struct {
+	Name  string
+	Color color2.Color
+}
+
+type knockoutEntry struct {
+	Replay string `json:"replay"`
+	Team   string `json:"team"`
+	Color  string `json:"color"`
+}
+
+// ParseKnockoutReplays parses the -knockout2 list. Entries can be plain replay paths or objects like
+// {"replay": "path.osr", "team": "Red", "color": "#ff0000"}, color is optional and the first one given for the team is used.
+// Returned teams are aligned with replays, they're nil if no replay is assigned to a team.
+func ParseKnockoutReplays(data string) (replays []string, teams []*KnockoutTeam, err error) {
+	parser := newKnockoutTeamParser()
+
+	if replays, teams, err = parser.parse([]byte(data)); err != nil {
+		return nil, nil, err
+	}
+
+	if err = parser.assignColors(); err != nil {
+		return nil, nil, err
+	}
+
+	return replays, teams, nil
+}
+
+// knockoutTeamParser parses replay lists, teams with the same name share one *KnockoutTeam across all parsed lists
+type knockoutTeamParser struct {
+	teamMap map[string]*KnockoutTeam
+	colors  map[string]string
+	order   []string
+}
+
+func newKnockoutTeamParser() *knockoutTeamParser {
+	return &knockoutTeamParser{
+		teamMap: make(map[string]*KnockoutTeam),
+		colors:  make(map[string]string),
+		order:   make([]string, 0),
+	}
+}
+
+// parse returns replays and teams aligned with them, teams are nil if no replay in the list is assigned to a team
+func (parser *knockoutTeamParser) parse(data []byte) (replays []string, teams []*KnockoutTeam, err error) {
+	var entries []json.RawMessage
+
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, err
+	}
+
+	teams = make([]*KnockoutTeam, 0, len(entries))
+	hasTeams := false
+
+	for _, raw := range entries {
+		var entry knockoutEntry
+
+		if err = json.Unmarshal(raw, &entry.Replay); err != nil {
+			if err = json.Unmarshal(raw, &entry); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if entry.Replay == "" {
+			return nil, nil, errors.New("replay path is missing")
+		}
+
+		replays = append(replays, entry.Replay)
+
+		if entry.Team == "" {
+			teams = append(teams, nil)
+			continue
+		}
+
+		team, ok := parser.teamMap[entry.Team]
+		if !ok {
+			team = &KnockoutTeam{Name: entry.Team}
+			parser.teamMap[entry.Team] = team
+			parser.order = append(parser.order, entry.Team)
+		}
+
+		if _, ok = parser.colors[entry.Team]; !ok && entry.Color != "" {
+			parser.colors[entry.Team] = entry.Color
+		}
+
+		teams = append(teams, team)
+		hasTeams = true
+	}
+
+	if !hasTeams {
+		teams = nil
+	}
+
+	return replays, teams, nil
+}
+
+// assignColors sets colors of all parsed teams, teams without a color get one from the default palette in order of appearance
+func (parser *knockoutTeamParser) assignColors() error {
+	for i, name := range parser.order {
+		team := parser.teamMap[name]
+		team.Color = color2.NewI(defaultTeamColors[i%len(defaultTeamColors)])
+
+		if hex, ok := parser.colors[name]; ok {
+			parsed, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+			if err != nil || len(strings.TrimPrefix(hex, "#")) != 6 {
+				return fmt.Errorf("invalid color of team \"%s\": %s", name, hex)
+			}
+
+			team.Color = color2.NewI(uint32(parsed))
+		}
+	}
+
+	return nil
+}
@@ -10,7 +10,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,6 +23,9 @@ var watcher *fsnotify.Watcher
 
 var reloadListeners []func()
 
+var livePatches []string
+var livePatchMutex sync.Mutex
+
 func initSettings() {
 	if err := os.MkdirAll(env.ConfigDir(), 0755); err != nil {
 		panic(err)
@@ -84,6 +89,169 @@ func LoadPatch() {
 	}
 }
 
+// ApplyLivePatch applies a JSON patch to the current settings the same way a settings file reload does and notifies reload listeners.
+// Sections and fields that can't be edited live are rejected. Applied patches are kept across settings file reloads.
+func ApplyLivePatch(patch string) error {
+	var sections map[string]json.RawMessage
+
+	if err := json.Unmarshal([]byte(patch), &sections); err != nil {
+		return err
+	}
+
+	cType := reflect.TypeOf(Config{})
+
+	for name, value := range sections {
+		field, ok := findPatchField(cType, name)
+		if !ok {
+			return fmt.Errorf("unknown settings section: %s", name)
+		}
+
+		if !isLiveEditable(cType, field) {
+			return fmt.Errorf("section %s can't be edited live", field.Name)
+		}
+
+		if err := checkLivePatch(field.Type, value, field.Name); err != nil {
+			return err
+		}
+	}
+
+	// Test on defaults first so a malformed patch doesn't leave current settings half-applied
+	if err := json.Unmarshal([]byte(patch), NewConfigFile()); err != nil {
+		return err
+	}
+
+	livePatchMutex.Lock()
+	defer livePatchMutex.Unlock()
+
+	if err := json.Unmarshal([]byte(patch), currentConfig); err != nil {
+		return err
+	}
+
+	livePatches = append(livePatches, patch)
+
+	currentConfig.attachToGlobals()
+
+	for _, f := range reloadListeners {
+		f()
+	}
+
+	return nil
+}
+
+// checkLivePatch walks patch values of the given type and returns an error if any of them sets a field tagged liveedit:"false"
+func checkLivePatch(fType reflect.Type, value json.RawMessage, path string) error {
+	for fType.Kind() == reflect.Pointer {
+		fType = fType.Elem()
+	}
+
+	switch fType.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+
+		// Invalid values are reported later by json.Unmarshal
+		if json.Unmarshal(value, &fields) != nil {
+			return nil
+		}
+
+		for name, fValue := range fields {
+			field, ok := findPatchField(fType, name)
+			if !ok {
+				continue // Unknown fields are ignored by json.Unmarshal as well
+			}
+
+			fPath := path + "." + field.Name
+
+			if !isLiveEditable(fType, field) {
+				return fmt.Errorf("%s can't be edited live", fPath)
+			}
+
+			if err := checkLivePatch(field.Type, fValue, fPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var elements []json.RawMessage
+
+		if json.Unmarshal(value, &elements) != nil {
+			return nil
+		}
+
+		for i, element := range elements {
+			if err := checkLivePatch(fType.Elem(), element, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		var elements map[string]json.RawMessage
+
+		if json.Unmarshal(value, &elements) != nil {
+			return nil
+		}
+
+		for key, element := range elements {
+			if err := checkLivePatch(fType.Elem(), element, path+"."+key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// findPatchField returns the exported field json.Unmarshal would set for given key, it matches names case-insensitively like encoding/json
+func findPatchField(sType reflect.Type, key string) (reflect.StructField, bool) {
+	for _, field := range reflect.VisibleFields(sType) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// isLiveEditable returns false if the field or the vector setting it belongs to is tagged liveedit:"false"
+func isLiveEditable(sType reflect.Type, field reflect.StructField) bool {
+	if field.Tag.Get("liveedit") == "false" {
+		return false
+	}
+
+	for i := 0; i < sType.NumField(); i++ {
+		vField := sType.Field(i)
+
+		if vField.Tag.Get("vector") == "true" && vField.Tag.Get("liveedit") == "false" &&
+			(vField.Tag.Get("left") == field.Name || vField.Tag.Get("right") == field.Name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func loadLivePatches() {
+	livePatchMutex.Lock()
+	defer livePatchMutex.Unlock()
+
+	for _, patch := range livePatches {
+		if err := json.Unmarshal([]byte(patch), currentConfig); err != nil {
+			log.Println("SettingsManager: Failed to reapply live patch:", err)
+		}
+	}
+}
+
 func setupWatcher(file string) {
 	var err error
 
@@ -117,6 +285,7 @@ func setupWatcher(file string) {
 					currentConfig.Save("", false)
 
 					LoadPatch()
+					loadLivePatches()
 
 					currentConfig.attachToGlobals()
 
@@ -0,0 +1,141 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Ways points are given after each map of the match
+const (
+	// MatchScoringWin gives 1 point to the side with the highest score on the map
+	MatchScoringWin = "win"
+
+	// MatchScoringCumulative adds map scores together
+	MatchScoringCumulative = "cumulative"
+)
+
+// Match is a multi-map tournament match loaded from -match file
+type Match struct {
+	Name    string
+	Scoring string
+
+	// Intermission and StandingsTime are in seconds
+	Intermission  float64
+	StandingsTime float64
+
+	Maps []*MatchMap
+}
+
+// MatchMap is a single pick of the match. Replays and Teams are aligned the same way as in -knockout2 list.
+type MatchMap struct {
+	Name    string
+	MD5     string
+	Replays []string
+	Teams   []*KnockoutTeam
+}
+
+type matchFile struct {
+	Name          string   `json:"name"`
+	Scoring       string   `json:"scoring"`
+	Intermission  *float64 `json:"intermission"`
+	StandingsTime *float64 `json:"standings_time"`
+	Maps          []struct {
+		Name    string          `json:"name"`
+		MD5     string          `json:"md5"`
+		Replays json.RawMessage `json:"replays"`
+	} `json:"maps"`
+}
+
+// LoadMatch loads the match file. Replay entries of each map have the same format as -knockout2 list,
+// relative paths are resolved against match file's directory. Teams with the same name are shared between maps.
+func LoadMatch(path string) (*Match, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file matchFile
+
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	if len(file.Maps) == 0 {
+		return nil, errors.New("match doesn't have any maps")
+	}
+
+	match := &Match{
+		Name:          file.Name,
+		Scoring:       strings.ToLower(file.Scoring),
+		Intermission:  5,
+		StandingsTime: 10,
+	}
+
+	if match.Name == "" {
+		match.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if match.Scoring == "" {
+		match.Scoring = MatchScoringWin
+	}
+
+	if !slices.Contains([]string{MatchScoringWin, MatchScoringCumulative}, match.Scoring) {
+		return nil, fmt.Errorf("unknown scoring: %s", file.Scoring)
+	}
+
+	if file.Intermission != nil {
+		match.Intermission = max(0, *file.Intermission)
+	}
+
+	if file.StandingsTime != nil {
+		match.StandingsTime = max(0, *file.StandingsTime)
+	}
+
+	dir := filepath.Dir(path)
+
+	parser := newKnockoutTeamParser()
+
+	for i, m := range file.Maps {
+		name := m.Name
+		if name == "" {
+			name = fmt.Sprintf("Map %d", i+1)
+		}
+
+		if len(m.Replays) == 0 {
+			return nil, fmt.Errorf("%s: replay list is missing", name)
+		}
+
+		replays, teams, err := parser.parse(m.Replays)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		if len(replays) == 0 {
+			return nil, fmt.Errorf("%s: replay list is empty", name)
+		}
+
+		for j, r := range replays {
+			if !filepath.IsAbs(r) {
+				replays[j] = filepath.Join(dir, r)
+			}
+		}
+
+		match.Maps = append(match.Maps, &MatchMap{
+			Name:    name,
+			MD5:     m.MD5,
+			Replays: replays,
+			Teams:   teams,
+		})
+	}
+
+	if err = parser.assignColors(); err != nil {
+		return nil, err
+	}
+
+	return match, nil
+}
@@ -506,6 +506,12 @@ func FinishBeatmapColors() {
 	}
 }
 
+// ClearBeatmapColors removes combo colors of the previously loaded beatmap
+func ClearBeatmapColors() {
+	beatmapColorsI = beatmapColorsI[:0]
+	beatmapColors = nil
+}
+
 func GetColors() []color.Color {
 	if settings.Skin.UseBeatmapColors && len(beatmapColors) > 0 {
 		return beatmapColors
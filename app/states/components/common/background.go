@@ -391,6 +391,19 @@ func (bg *Background) getColors(image *texture.Pixmap) []color2.Color {
 	return newCol
 }
 
+// Dispose frees background's textures and the storyboard, it has to be called on the main thread
+func (bg *Background) Dispose() {
+	if bg.storyboard != nil {
+		bg.storyboard.Dispose()
+	}
+
+	if bg.background != nil {
+		bg.background.Dispose()
+	}
+
+	bg.blur.Dispose()
+}
+
 func (bg *Background) HasBackground() bool {
 	return bg.background != nil
 }
@@ -242,7 +242,7 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 						}
 
 						slidersRendered = true
-						s.DrawBody(time, objectColors[j], bodyColors[j], borderColors[j], borderColors[ind], cameras[j], scale)
+						s.DrawBody(time, objectColors[j], bodyColors[j], borderColors[j], borderColors[ind], container.objectCamera(s, cameras[j], time), scale)
 					}
 				}
 			}
@@ -280,7 +280,9 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 
 					_, sp := container.renderables[i].renderable.(*objects.Spinner)
 					if !sp || j == 0 {
+						batch.SetCamera(container.objectCamera(proxy.renderable, cameras[j], time))
 						proxy.renderable.Draw(time, objectColors[j], batch)
+						batch.SetCamera(cameras[j])
 					}
 				} else if !settings.Objects.Sliders.SliderMerge {
 					if !enabled {
@@ -293,7 +295,7 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 					}
 
 					slidersRendered = true
-					proxy.renderable.(*objects.Slider).DrawBody(time, objectColors[j], bodyColors[j], borderColors[j], borderColors[ind], cameras[j], scale)
+					proxy.renderable.(*objects.Slider).DrawBody(time, objectColors[j], bodyColors[j], borderColors[j], borderColors[ind], container.objectCamera(proxy.renderable, cameras[j], time), scale)
 				}
 
 				if proxy.endTime <= time {
@@ -322,7 +324,9 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 
 				for i := len(container.renderables) - 1; i >= 0; i-- {
 					if s := container.renderables[i]; !s.isSliderBody {
+						batch.SetCamera(container.objectCamera(s.renderable, cameras[j], time))
 						s.renderable.DrawApproach(time, objectColors[j], batch)
+						batch.SetCamera(cameras[j])
 					}
 				}
 			}
@@ -339,3 +343,14 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 func (container *HitObjectContainer) GetNumProcessed() int {
 	return container.countProcessed
 }
+
+// objectCamera returns the camera with transformations of lazer's visual mods applied to the object
+func (container *HitObjectContainer) objectCamera(renderable objects.Renderable, camera mgl32.Mat4, time float64) mgl32.Mat4 {
+	if o, ok := renderable.(objects.IHitObject); ok {
+		if matrix, ok2 := objects.GetFunModMatrix(o, time, container.beatMap.Diff); ok2 {
+			return camera.Mul4(matrix)
+		}
+	}
+
+	return camera
+}
@@ -0,0 +1,224 @@
+package containers
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/taiko"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/skin"
+	"github.com/wieku/danser-go/framework/graphics/batch"
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"github.com/wieku/danser-go/framework/profiler"
+	"log"
+	"math"
+)
+
+const (
+	taikoLaneY      = 0.3  // lane centre as a fraction of screen height
+	taikoLaneHeight = 0.16 // lane height as a fraction of screen height
+	taikoTargetX    = 0.2  // hit target position as a fraction of screen width
+
+	taikoJudgementTime = 300.0
+)
+
+var (
+	taikoDonColor      = color2.NewIRGB(235, 69, 44)
+	taikoKatColor      = color2.NewIRGB(68, 141, 171)
+	taikoDrumRollColor = color2.NewIRGB(252, 184, 6)
+	taikoSwellColor    = color2.NewIRGB(250, 120, 20)
+)
+
+// TaikoContainer draws osu!taiko playfield of a single player. Notes scroll with the velocity of their timing points.
+type TaikoContainer struct {
+	ruleSet *taiko.TaikoRuleSet
+	cursor  *graphics.Cursor
+	beatMap *beatmap.BeatMap
+}
+
+func NewTaikoContainer(ruleSet *taiko.TaikoRuleSet, cursor *graphics.Cursor) *TaikoContainer {
+	log.Println("Creating osu!taiko container...")
+
+	return &TaikoContainer{
+		ruleSet: ruleSet,
+		cursor:  cursor,
+		beatMap: ruleSet.GetBeatMap(),
+	}
+}
+
+// getVelocity returns scroll velocity in osu!pixels per millisecond at given time
+func (container *TaikoContainer) getVelocity(time float64) float64 {
+	beatLength := container.beatMap.Timings.GetPointAt(time).GetBeatLength()
+	if beatLength <= 0 {
+		return 0
+	}
+
+	return container.beatMap.Timings.SliderMult * 100 / beatLength
+}
+
+func (container *TaikoContainer) Draw(batch *batch.QuadBatch, camera mgl32.Mat4, time, width, height, alpha float64) {
+	profiler.StartGroup("TaikoContainer.Draw", profiler.PDraw)
+
+	scale := height / 480
+	laneY := height * taikoLaneY
+	laneHeight := height * taikoLaneHeight
+	targetX := width * taikoTargetX
+	radius := laneHeight * 0.32
+
+	pixel := graphics.Pixel.GetRegion()
+	circle := skin.GetTexture("hitcircle")
+	overlay := skin.GetTexture("hitcircleoverlay")
+
+	batch.Begin()
+	batch.SetCamera(camera)
+	batch.ResetTransform()
+	batch.SetAdditive(false)
+
+	// Lane
+	batch.SetColor(0, 0, 0, 0.7*alpha)
+	batch.SetTranslation(vector.NewVec2d(width/2, laneY))
+	batch.SetScale(width/2, laneHeight/2)
+	batch.DrawUnit(pixel)
+
+	// Hit target
+	batch.SetColor(1, 1, 1, 0.3*alpha)
+	batch.SetTranslation(vector.NewVec2d(targetX, laneY))
+	batch.SetScale(radius, radius)
+	batch.DrawUnit(*circle)
+	batch.DrawUnit(*overlay)
+
+	if settings.Playfield.DrawObjects {
+		objects := container.ruleSet.GetObjects()
+
+		// Draw in reverse so earlier notes are on top
+		for i := len(objects) - 1; i >= 0; i-- {
+			obj := objects[i]
+			state := container.ruleSet.GetObjectState(container.cursor, obj)
+
+			startTime := obj.GetObject().GetStartTime()
+			endTime := obj.GetObject().GetEndTime()
+
+			velocity := container.getVelocity(startTime) * scale
+
+			startX := targetX + (startTime-time)*velocity
+			endX := targetX + (endTime-time)*velocity
+
+			if endX < -laneHeight || startX > width+laneHeight {
+				continue
+			}
+
+			nRadius := radius
+			if obj.IsStrong() {
+				nRadius *= 1.5
+			}
+
+			switch obj.GetKind() {
+			case taiko.KindDon, taiko.KindKat:
+				// Hit notes disappear, missed ones keep scrolling
+				if state.Finished && state.Result != osu.Miss {
+					continue
+				}
+
+				col := taikoDonColor
+				if obj.GetKind() == taiko.KindKat {
+					col = taikoKatColor
+				}
+
+				container.drawNote(batch, startX, laneY, nRadius, col, alpha)
+			case taiko.KindDrumRoll:
+				batch.SetColor(float64(taikoDrumRollColor.R), float64(taikoDrumRollColor.G), float64(taikoDrumRollColor.B), alpha)
+				batch.SetTranslation(vector.NewVec2d((startX+endX)/2, laneY))
+				batch.SetScale((endX-startX)/2, nRadius*0.9)
+				batch.DrawUnit(pixel)
+
+				container.drawNote(batch, endX, laneY, nRadius, taikoDrumRollColor, alpha)
+				container.drawNote(batch, startX, laneY, nRadius, taikoDrumRollColor, alpha)
+			case taiko.KindSwell:
+				if state.Finished {
+					continue
+				}
+
+				x := startX
+				sRadius := nRadius * 1.2
+
+				// Swell waits at the hit target till it's completed
+				if time >= startTime {
+					x = targetX
+					sRadius *= 1 + float64(state.Hits)/float64(max(1, state.Required))
+				}
+
+				container.drawNote(batch, x, laneY, sRadius, taikoSwellColor, alpha)
+			}
+		}
+
+		container.drawJudgements(batch, time, targetX, laneY, radius, alpha)
+	}
+
+	batch.SetColor(1, 1, 1, 1)
+	batch.ResetTransform()
+	batch.End()
+
+	profiler.EndGroup()
+}
+
+func (container *TaikoContainer) drawNote(batch *batch.QuadBatch, x, y, radius float64, col color2.Color, alpha float64) {
+	batch.SetTranslation(vector.NewVec2d(x, y))
+	batch.SetScale(radius, radius)
+
+	batch.SetColor(float64(col.R), float64(col.G), float64(col.B), alpha)
+	batch.DrawUnit(*skin.GetTexture("hitcircle"))
+
+	batch.SetColor(1, 1, 1, alpha)
+	batch.DrawUnit(*skin.GetTexture("hitcircleoverlay"))
+}
+
+func (container *TaikoContainer) drawJudgements(batch *batch.QuadBatch, time, x, y, radius, alpha float64) {
+	for _, obj := range container.ruleSet.GetObjects() {
+		if obj.GetKind() != taiko.KindDon && obj.GetKind() != taiko.KindKat {
+			continue
+		}
+
+		state := container.ruleSet.GetObjectState(container.cursor, obj)
+
+		if !state.Finished {
+			break
+		}
+
+		progress := (time - float64(state.Time)) / taikoJudgementTime
+		if progress < 0 || progress > 1 {
+			continue
+		}
+
+		tex := ""
+
+		switch state.Result & osu.BaseHitsM {
+		case osu.Hit300:
+			tex = "hit300"
+		case osu.Hit100:
+			tex = "hit100"
+		case osu.Miss:
+			tex = "hit0"
+		}
+
+		switch state.Result & osu.Additions {
+		case osu.KatuAddition:
+			tex += "k"
+		case osu.GekiAddition:
+			tex += "g"
+		}
+
+		hitTexture := skin.GetTexture(tex)
+		if hitTexture == nil {
+			continue
+		}
+
+		hScale := radius * 1.2 / float64(hitTexture.Height) * 2 * (1 + 0.2*math.Sin(progress*math.Pi/2))
+
+		batch.SetColor(1, 1, 1, alpha*(1-progress))
+		batch.SetTranslation(vector.NewVec2d(x, y-radius*2.5))
+		batch.SetScale(hScale*float64(hitTexture.Width)/2, hScale*float64(hitTexture.Height)/2)
+		batch.DrawUnit(*hitTexture)
+	}
+}
@@ -63,6 +63,8 @@ type knockoutPlayer struct {
 	name         string
 	oldIndex     int
 	currentIndex int
+
+	accuracy float64
 }
 
 type bubble struct {
@@ -135,6 +137,9 @@ type KnockoutOverlay struct {
 	fade      *animation.Glider
 
 	alivePlayers int
+
+	teams       []*knockoutTeam
+	teamResults *animation.Glider
 }
 
 func NewKnockoutOverlay(replayController *dance.ReplayController) *KnockoutOverlay {
@@ -163,7 +168,7 @@ func NewKnockoutOverlay(replayController *dance.ReplayController) *KnockoutOverl
 	for i, r := range replayController.GetReplays() {
 		cursor := replayController.GetCursors()[i]
 		overlay.names[cursor] = r.Name
-		overlay.players[r.Name] = &knockoutPlayer{animation.NewGlider(1), animation.NewGlider(0), animation.NewGlider(overlay.ScaledHeight * 0.9 * 1.04 / (51)), animation.NewGlider(float64(i)), animation.NewTargetGlider(0, 0), animation.NewTargetGlider(0, 2), animation.NewTargetGlider(100, 2), 0, 0, r.MaxCombo, false, 0, 0.0, 0, make([]stats, len(replayController.GetBeatMap().HitObjects)), 0.0, osu.Hit300, animation.NewGlider(0), animation.NewGlider(0), r.Name, i, i}
+		overlay.players[r.Name] = &knockoutPlayer{animation.NewGlider(1), animation.NewGlider(0), animation.NewGlider(overlay.ScaledHeight * 0.9 * 1.04 / (51)), animation.NewGlider(float64(i)), animation.NewTargetGlider(0, 0), animation.NewTargetGlider(0, 2), animation.NewTargetGlider(100, 2), 0, 0, r.MaxCombo, false, 0, 0.0, 0, make([]stats, len(replayController.GetBeatMap().HitObjects)), 0.0, osu.Hit300, animation.NewGlider(0), animation.NewGlider(0), r.Name, i, i, 1.0}
 		overlay.players[r.Name].index.SetEasing(easing.InOutQuad)
 		overlay.playersArray = append(overlay.playersArray, overlay.players[r.Name])
 
@@ -176,6 +181,8 @@ func NewKnockoutOverlay(replayController *dance.ReplayController) *KnockoutOverl
 		})
 	}
 
+	overlay.initTeams()
+
 	discord.UpdateKnockout(len(overlay.playersArray), len(overlay.playersArray))
 
 	for i, g := range overlay.playersArray {
@@ -232,6 +239,10 @@ func NewKnockoutOverlay(replayController *dance.ReplayController) *KnockoutOverl
 	}
 
 	replayController.GetRuleset().SetEndListener(func(time int64, number int64) {
+		if number == int64(len(replayController.GetBeatMap().HitObjects)-1) {
+			overlay.showTeamResults()
+		}
+
 		if number == int64(len(replayController.GetBeatMap().HitObjects)-1) && settings.Knockout.RevivePlayersAtEnd {
 			for _, player := range overlay.players {
 				player.hasBroken = false
@@ -281,6 +292,7 @@ func (overlay *KnockoutOverlay) hitReceived(cursor *graphics.Cursor, judgementRe
 	player.perObjectStats[judgementResult.Number].accuracy = score.Accuracy
 
 	player.accDisp.SetValue(score.Accuracy*100, false)
+	player.accuracy = score.Accuracy
 
 	if judgementResult.ComboResult == osu.Increase {
 		player.sCombo++
@@ -374,6 +386,8 @@ func (overlay *KnockoutOverlay) Update(time float64) {
 			player.displayHp = max(0.0, player.displayHp-math.Abs(player.displayHp-currentHp)/6*delta/16.667)
 		}
 	}
+
+	overlay.updateTeams()
 }
 
 func (overlay *KnockoutOverlay) SetMusic(music bass.ITrack) {
@@ -633,6 +647,8 @@ func (overlay *KnockoutOverlay) DrawHUD(batch *batch.QuadBatch, colors []color2.
 			overlay.font.DrawOrigin(batch, 3.2*scl+width+nWidth+xSlideLeft, rowBaseY+ascScl, vector.BottomLeft, scl*0.8, false, "+"+r.Mods)
 		}
 	}
+
+	overlay.drawTeams(batch, alpha)
 }
 
 func (overlay *KnockoutOverlay) IsBroken(cursor *graphics.Cursor) bool {
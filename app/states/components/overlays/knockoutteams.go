@@ -0,0 +1,289 @@
+package overlays
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/utils"
+	"github.com/wieku/danser-go/framework/graphics/batch"
+	"github.com/wieku/danser-go/framework/math/animation"
+	"github.com/wieku/danser-go/framework/math/animation/easing"
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type knockoutTeam struct {
+	*settings.KnockoutTeam
+
+	players []*knockoutPlayer
+
+	score    float64
+	pp       float64
+	accuracy float64
+
+	scoreDisp *animation.TargetGlider
+	ppDisp    *animation.TargetGlider
+	accDisp   *animation.TargetGlider
+
+	index        *animation.Glider
+	currentIndex int
+}
+
+// initTeams groups players by teams assigned in -knockout2 list, players without a team are not shown in team totals
+func (overlay *KnockoutOverlay) initTeams() {
+	teamMap := make(map[*settings.KnockoutTeam]*knockoutTeam)
+
+	for _, r := range overlay.controller.GetReplays() {
+		if r.Team == nil {
+			continue
+		}
+
+		team, ok := teamMap[r.Team]
+		if !ok {
+			team = &knockoutTeam{
+				KnockoutTeam: r.Team,
+				scoreDisp:    animation.NewTargetGlider(0, 0),
+				ppDisp:       animation.NewTargetGlider(0, 2),
+				accDisp:      animation.NewTargetGlider(100, 2),
+				index:        animation.NewGlider(float64(len(overlay.teams))),
+				currentIndex: len(overlay.teams),
+			}
+
+			team.index.SetEasing(easing.InOutQuad)
+
+			teamMap[r.Team] = team
+			overlay.teams = append(overlay.teams, team)
+		}
+
+		team.players = append(team.players, overlay.players[r.Name])
+	}
+
+	overlay.teamResults = animation.NewGlider(0)
+}
+
+// AggregateTeam combines values of team members according to Knockout.TeamScoring, average forces the mean of counted values
+func AggregateTeam(values []float64, average bool) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+
+	scoring := strings.ToLower(settings.Knockout.TeamScoring)
+
+	if scoring == "best" {
+		sorted = sorted[:min(len(sorted), max(1, settings.Knockout.TeamBestCount))]
+	}
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+
+	if average || scoring == "average" {
+		return sum / float64(len(sorted))
+	}
+
+	return sum
+}
+
+func (overlay *KnockoutOverlay) updateTeams() {
+	if len(overlay.teams) == 0 {
+		return
+	}
+
+	for _, team := range overlay.teams {
+		scores := make([]float64, 0, len(team.players))
+		pps := make([]float64, 0, len(team.players))
+		accs := make([]float64, 0, len(team.players))
+
+		for _, p := range team.players {
+			scores = append(scores, float64(p.score))
+			pps = append(pps, p.pp)
+			accs = append(accs, p.accuracy*100)
+		}
+
+		team.score = AggregateTeam(scores, false)
+		team.pp = AggregateTeam(pps, false)
+		team.accuracy = AggregateTeam(accs, true)
+
+		team.scoreDisp.SetValue(team.score, false)
+		team.ppDisp.SetValue(team.pp, false)
+		team.accDisp.SetValue(team.accuracy, false)
+
+		team.scoreDisp.Update(overlay.normalTime)
+		team.ppDisp.Update(overlay.normalTime)
+		team.accDisp.Update(overlay.normalTime)
+		team.index.Update(overlay.normalTime)
+	}
+
+	overlay.teamResults.Update(overlay.normalTime)
+
+	ranking := overlay.rankedTeams()
+
+	for i, team := range ranking {
+		if i != team.currentIndex {
+			team.index.Reset()
+			team.index.AddEvent(overlay.normalTime, overlay.normalTime+200+math.Abs(float64(i-team.currentIndex))*10, float64(i))
+			team.currentIndex = i
+		}
+	}
+}
+
+// rankedTeams returns teams sorted by Knockout.SortBy
+func (overlay *KnockoutOverlay) rankedTeams() []*knockoutTeam {
+	ranking := slices.Clone(overlay.teams)
+
+	cond := strings.ToLower(settings.Knockout.SortBy)
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		switch cond {
+		case "pp":
+			return ranking[i].pp > ranking[j].pp
+		case "acc", "accuracy":
+			return ranking[i].accuracy > ranking[j].accuracy
+		default:
+			return ranking[i].score > ranking[j].score
+		}
+	})
+
+	return ranking
+}
+
+func (overlay *KnockoutOverlay) showTeamResults() {
+	if len(overlay.teams) == 0 || !settings.Knockout.TeamResultsScreen {
+		return
+	}
+
+	overlay.teamResults.Reset()
+	overlay.teamResults.AddEventEase(overlay.normalTime+1000, overlay.normalTime+1500, 1, easing.OutQuad)
+}
+
+// ApplyTeamColors replaces colors of cursors that belong to a team with team colors, alpha is kept
+func (overlay *KnockoutOverlay) ApplyTeamColors(colors []color2.Color) {
+	if len(overlay.teams) == 0 || !settings.Knockout.TeamCursorColors {
+		return
+	}
+
+	replays := overlay.controller.GetReplays()
+
+	for i := range colors {
+		r := replays[i%len(replays)]
+		if r.Team == nil {
+			continue
+		}
+
+		a := colors[i].A
+		colors[i] = r.Team.Color
+		colors[i].A = a
+	}
+}
+
+func teamText(team *knockoutTeam) string {
+	return fmt.Sprintf("%s  %.2f%%  %.2fpp", utils.Humanize(int64(team.scoreDisp.GetValue())), team.accDisp.GetValue(), team.ppDisp.GetValue())
+}
+
+// drawTeams draws team totals at the top of the screen and team results screen if it's visible
+func (overlay *KnockoutOverlay) drawTeams(batch *batch.QuadBatch, alpha float64) {
+	if len(overlay.teams) == 0 {
+		return
+	}
+
+	scl := overlay.ScaledHeight * 0.9 / 51
+	resultsAlpha := overlay.teamResults.GetValue()
+
+	panelAlpha := alpha * (1 - resultsAlpha)
+
+	if panelAlpha > 0.001 {
+		nameWidth := 0.0
+		textWidth := 0.0
+
+		for _, team := range overlay.teams {
+			nameWidth = max(nameWidth, overlay.font.GetWidth(scl, team.Name))
+			textWidth = max(textWidth, overlay.font.GetWidthMonospaced(scl, teamText(team)))
+		}
+
+		width := nameWidth + textWidth + 2*scl
+		left := (overlay.ScaledWidth - width) / 2
+
+		batch.SetColor(0, 0, 0, panelAlpha*0.5)
+		batch.SetSubScale(width/2+scl/2, float64(len(overlay.teams))*scl*1.1/2+scl/4)
+		batch.SetTranslation(vector.NewVec2d(overlay.ScaledWidth/2, scl+float64(len(overlay.teams))*scl*1.1/2))
+		batch.DrawUnit(graphics.Pixel.GetRegion())
+		batch.ResetTransform()
+
+		for _, team := range overlay.teams {
+			y := scl*1.55 + team.index.GetValue()*scl*1.1
+
+			batch.SetColor(float64(team.Color.R), float64(team.Color.G), float64(team.Color.B), panelAlpha)
+			overlay.font.DrawOrigin(batch, left, y, vector.CentreLeft, scl, false, team.Name)
+
+			batch.SetColor(1, 1, 1, panelAlpha)
+			overlay.font.DrawOrigin(batch, left+width, y, vector.CentreRight, scl, true, teamText(team))
+		}
+	}
+
+	if resultsAlpha > 0.001 {
+		overlay.drawTeamResults(batch, alpha*resultsAlpha)
+	}
+}
+
+func (overlay *KnockoutOverlay) drawTeamResults(batch *batch.QuadBatch, alpha float64) {
+	scl := overlay.ScaledHeight / 30
+
+	batch.SetColor(0, 0, 0, alpha*0.8)
+	batch.SetSubScale(overlay.ScaledWidth/2, overlay.ScaledHeight/2)
+	batch.SetTranslation(vector.NewVec2d(overlay.ScaledWidth/2, overlay.ScaledHeight/2))
+	batch.DrawUnit(graphics.Pixel.GetRegion())
+	batch.ResetTransform()
+
+	batch.SetColor(1, 1, 1, alpha)
+	overlay.font.DrawOrigin(batch, overlay.ScaledWidth/2, scl*2, vector.Centre, scl*2, false, "Team results")
+
+	ranking := overlay.rankedTeams()
+
+	rows := 0
+	for _, team := range ranking {
+		rows += 1 + len(team.players)
+	}
+
+	// Shrink the list if there are too many players to fit
+	rowHeight := min(scl*1.2, (overlay.ScaledHeight-scl*5)/float64(rows+len(ranking)))
+	size := rowHeight / 1.2
+
+	left := overlay.ScaledWidth * 0.2
+	right := overlay.ScaledWidth * 0.8
+
+	y := scl*4 + rowHeight/2
+
+	for i, team := range ranking {
+		batch.SetColor(float64(team.Color.R), float64(team.Color.G), float64(team.Color.B), alpha)
+		overlay.font.DrawOrigin(batch, left, y, vector.CentreLeft, size*1.2, false, fmt.Sprintf("#%d %s", i+1, team.Name))
+
+		batch.SetColor(1, 1, 1, alpha)
+		overlay.font.DrawOrigin(batch, right, y, vector.CentreRight, size, true, teamText(team))
+
+		y += rowHeight
+
+		players := slices.Clone(team.players)
+		sort.SliceStable(players, func(i, j int) bool {
+			return players[i].score > players[j].score
+		})
+
+		batch.SetColor(0.8, 0.8, 0.8, alpha)
+
+		for _, p := range players {
+			overlay.font.DrawOrigin(batch, left+size*2, y, vector.CentreLeft, size*0.8, false, p.name)
+			overlay.font.DrawOrigin(batch, right, y, vector.CentreRight, size*0.8, true, fmt.Sprintf("%s  %.2f%%  %.2fpp", utils.Humanize(p.score), p.accuracy*100, p.pp))
+
+			y += rowHeight * 0.8
+		}
+
+		y += rowHeight * 0.5
+	}
+}
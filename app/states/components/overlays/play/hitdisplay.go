@@ -2,7 +2,7 @@ package play
 
 import (
 	"github.com/wieku/danser-go/app/graphics"
-	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/framework/graphics/batch"
 	"github.com/wieku/danser-go/framework/graphics/font"
@@ -12,7 +12,7 @@ import (
 )
 
 type HitDisplay struct {
-	ruleset *osu.OsuRuleSet
+	ruleset rulesets.Ruleset
 	cursor  *graphics.Cursor
 	fnt     *font.Font
 
@@ -32,7 +32,7 @@ type HitDisplay struct {
 	sliderBreaksText string
 }
 
-func NewHitDisplay(ruleset *osu.OsuRuleSet, cursor *graphics.Cursor) *HitDisplay {
+func NewHitDisplay(ruleset rulesets.Ruleset, cursor *graphics.Cursor) *HitDisplay {
 	aSprite := &HitDisplay{
 		ruleset:          ruleset,
 		cursor:           cursor,
@@ -3,7 +3,7 @@ package play
 import (
 	"fmt"
 	"github.com/wieku/danser-go/app/graphics"
-	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/skin"
 	"github.com/wieku/danser-go/framework/assets"
@@ -39,7 +39,7 @@ type RankingPanel struct {
 
 	ScaledWidth float64
 	cursor      *graphics.Cursor
-	ruleset     *osu.OsuRuleSet
+	ruleset     rulesets.Ruleset
 	count300    *HitCounter
 	count100    *HitCounter
 	count50     *HitCounter
@@ -62,7 +62,7 @@ type RankingPanel struct {
 	perfect        *sprite.Sprite
 }
 
-func NewRankingPanel(cursor *graphics.Cursor, ruleset *osu.OsuRuleSet, hitError *HitErrorMeter, hpGraph []vector.Vector2d) *RankingPanel {
+func NewRankingPanel(cursor *graphics.Cursor, ruleset rulesets.Ruleset, hitError *HitErrorMeter, hpGraph []vector.Vector2d) *RankingPanel {
 	panel := &RankingPanel{
 		manager:     sprite.NewManager(),
 		ScaledWidth: settings.Graphics.GetAspectRatio() * 768,
@@ -11,6 +11,7 @@ import (
 	"github.com/wieku/danser-go/app/discord"
 	"github.com/wieku/danser-go/app/graphics"
 	"github.com/wieku/danser-go/app/input"
+	"github.com/wieku/danser-go/app/rulesets"
 	"github.com/wieku/danser-go/app/rulesets/osu"
 	"github.com/wieku/danser-go/app/rulesets/osu/performance"
 	"github.com/wieku/danser-go/app/settings"
@@ -54,7 +55,8 @@ type ScoreOverlay struct {
 	scoreGlider    *animation.TargetGlider
 	accuracyGlider *animation.TargetGlider
 
-	ruleset *osu.OsuRuleSet
+	ruleset    rulesets.Ruleset
+	osuRuleset *osu.OsuRuleSet // nil if other game mode is played
 
 	cursor *graphics.Cursor
 
@@ -186,7 +188,7 @@ func loadSubFont(uPath, alias string) {
 	}
 }
 
-func NewScoreOverlay(ruleset *osu.OsuRuleSet, cursor *graphics.Cursor) *ScoreOverlay {
+func NewScoreOverlay(ruleset rulesets.Ruleset, cursor *graphics.Cursor) *ScoreOverlay {
 	loadFonts()
 
 	overlay := new(ScoreOverlay)
@@ -200,6 +202,7 @@ func NewScoreOverlay(ruleset *osu.OsuRuleSet, cursor *graphics.Cursor) *ScoreOve
 
 	overlay.results = play.NewHitResults(ruleset.GetBeatMap().Diff)
 	overlay.ruleset = ruleset
+	overlay.osuRuleset, _ = ruleset.(*osu.OsuRuleSet)
 	overlay.cursor = cursor
 
 	overlay.scoreGlider = animation.NewTargetGlider(0, 0)
@@ -326,7 +329,7 @@ func NewScoreOverlay(ruleset *osu.OsuRuleSet, cursor *graphics.Cursor) *ScoreOve
 
 	overlay.mods = sprite.NewManager()
 
-	if overlay.ruleset.GetBeatMap().Diff.Mods.Active(difficulty.Flashlight) {
+	if overlay.osuRuleset != nil && overlay.ruleset.GetBeatMap().Diff.Mods.Active(difficulty.Flashlight) {
 		overlay.flashlight = common.NewFlashlight(overlay.ruleset.GetBeatMap())
 	}
 
@@ -382,28 +385,21 @@ func (overlay *ScoreOverlay) initUnderlay() {
 func (overlay *ScoreOverlay) hitReceived(c *graphics.Cursor, judgementResult osu.JudgementResult, score osu.Score) {
 	object := overlay.ruleset.GetBeatMap().HitObjects[judgementResult.Number]
 
-	if judgementResult.HitResult&(osu.BaseHitsM) > 0 {
+	// Positional elements are meaningful only in osu!standard
+	positional := overlay.osuRuleset != nil
+
+	if positional && judgementResult.HitResult&(osu.BaseHitsM) > 0 {
 		overlay.results.AddResult(judgementResult.Time, judgementResult.HitResult, judgementResult.Position.Copy64(), object)
 	}
 
-	sliderChecks := osu.SliderStart | osu.PositionalMiss
-
-	playerDiff := overlay.ruleset.GetPlayerDifficulty(c)
-
-	if playerDiff.CheckModActive(difficulty.Lazer) {
-		classicConf, confFound := difficulty.GetModConfig[difficulty.ClassicSettings](playerDiff)
-
-		if !playerDiff.CheckModActive(difficulty.Classic) || !confFound || !classicConf.NoSliderHeadAccuracy {
-			sliderChecks |= osu.BaseHits
-		}
-	}
+	sliderChecks := osu.SliderAccuracyResults(overlay.ruleset.GetPlayerDifficulty(c)) | osu.PositionalMiss
 
 	_, hC := object.(*objects.Circle)
 	allowCircle := hC && (judgementResult.HitResult&(osu.BaseHits|osu.PositionalMiss) > 0)
 	_, sl := object.(*objects.Slider)
 	allowSlider := sl && (judgementResult.HitResult&sliderChecks) > 0
 
-	if allowCircle || allowSlider {
+	if positional && (allowCircle || allowSlider) {
 		timeDiff := float64(judgementResult.Time) - object.GetStartTime()
 
 		overlay.hitErrorMeter.Add(float64(judgementResult.Time), timeDiff, judgementResult.HitResult == osu.PositionalMiss)
@@ -603,12 +599,12 @@ func (overlay *ScoreOverlay) updateNormal(time float64) {
 		overlay.flashlight.Update(time)
 		overlay.flashlight.UpdatePosition(overlay.cursor.Position)
 
-		proc := overlay.ruleset.GetProcessed()
+		proc := overlay.osuRuleset.GetProcessed()
 
 		sliding := false
 		for _, p := range proc {
 			if o, ok := p.(*osu.Slider); ok {
-				sliding = sliding || o.IsSliding(overlay.ruleset.GetPlayer(overlay.cursor))
+				sliding = sliding || o.IsSliding(overlay.osuRuleset.GetPlayer(overlay.cursor))
 			}
 		}
 
@@ -677,6 +673,10 @@ func (overlay *ScoreOverlay) SetMusic(music bass.ITrack) {
 }
 
 func (overlay *ScoreOverlay) DrawBackground(batch *batch.QuadBatch, c []color2.Color, alpha float64) {
+	if overlay.osuRuleset == nil {
+		return
+	}
+
 	overlay.boundaries.Draw(batch.Projection, float32(overlay.ruleset.GetBeatMap().Diff.CircleRadius), float32(alpha*overlay.bgDim.GetValue()))
 }
 
@@ -0,0 +1,238 @@
+package states
+
+import (
+	"errors"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/rulesets"
+	"github.com/wieku/danser-go/app/settings"
+	"time"
+)
+
+// BeatmapStatus describes the beatmap that is currently played
+type BeatmapStatus struct {
+	MD5        string `json:"md5"`
+	ID         int64  `json:"id"`
+	SetID      int64  `json:"set_id"`
+	Artist     string `json:"artist"`
+	Title      string `json:"title"`
+	Difficulty string `json:"difficulty"`
+	Creator    string `json:"creator"`
+	Mods       string `json:"mods"`
+}
+
+// CursorStatus holds current score of a single cursor. Score fields are empty in cursordance mode.
+type CursorStatus struct {
+	Name       string  `json:"name"`
+	Mods       string  `json:"mods"`
+	Score      int64   `json:"score"`
+	Accuracy   float64 `json:"accuracy"`
+	Grade      string  `json:"grade"`
+	Combo      uint    `json:"combo"`
+	MaxCombo   uint    `json:"max_combo"`
+	Count300   uint    `json:"count_300"`
+	Count100   uint    `json:"count_100"`
+	Count50    uint    `json:"count_50"`
+	CountMiss  uint    `json:"count_miss"`
+	CountSB    uint    `json:"count_slider_breaks"`
+	PP         float64 `json:"pp"`
+	HP         float64 `json:"hp"`
+	Failed     bool    `json:"failed"`
+	KnockedOut bool    `json:"knocked_out"`
+}
+
+// PlayerStatus is a snapshot of the Player state
+type PlayerStatus struct {
+	Time    float64        `json:"time"`
+	EndTime float64        `json:"end_time"`
+	Started bool           `json:"started"`
+	Paused  bool           `json:"paused"`
+	Speed   float64        `json:"speed"`
+	Beatmap BeatmapStatus  `json:"beatmap"`
+	Cursors []CursorStatus `json:"cursors"`
+	// Alive contains names of cursors that are neither knocked out nor failed
+	Alive []string `json:"alive"`
+}
+
+// Schedule queues f to be run on the update thread before the next update
+func (player *Player) Schedule(f func()) {
+	player.commandMutex.Lock()
+	player.commands = append(player.commands, f)
+	player.commandMutex.Unlock()
+}
+
+// Call runs f on the update thread and waits for it to finish. Returns false if the player didn't run it within given timeout.
+func (player *Player) Call(f func(), timeout time.Duration) bool {
+	done := make(chan struct{})
+
+	player.Schedule(func() {
+		f()
+		close(done)
+	})
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (player *Player) runCommands() {
+	player.commandMutex.Lock()
+	commands := player.commands
+	player.commands = nil
+	player.commandMutex.Unlock()
+
+	for _, f := range commands {
+		f()
+	}
+}
+
+// Stop ends the update loop and stops the music. Player can't be started again.
+func (player *Player) Stop() {
+	player.stopped.Store(true)
+
+	if player.updateDone != nil {
+		<-player.updateDone
+	}
+}
+
+// SetPaused pauses or resumes the playback. Has to be called on the update thread.
+func (player *Player) SetPaused(paused bool) {
+	if player.paused == paused {
+		return
+	}
+
+	player.paused = paused
+
+	if !player.start || player.failed {
+		return
+	}
+
+	if paused {
+		player.musicPlayer.Pause()
+	} else {
+		player.musicPlayer.Resume()
+	}
+}
+
+// Seek skips forward to given beatmap time in milliseconds. Has to be called on the update thread.
+// Judgements can't be reverted, so seeking backwards is not supported.
+func (player *Player) Seek(time float64) error {
+	if !player.start {
+		return errors.New("playback hasn't started yet")
+	}
+
+	if player.failed || player.progressMsF >= player.mapEndL {
+		return errors.New("playback has already ended")
+	}
+
+	if time <= player.progressMsF {
+		return errors.New("seeking backwards is not supported")
+	}
+
+	time = min(time, player.mapEndL)
+
+	// Skipped objects are judged while the controller catches up, don't play their hitsounds
+	for _, o := range player.bMap.HitObjects {
+		if o.GetStartTime() > time {
+			break
+		}
+
+		o.DisableAudioSubmission(true)
+	}
+
+	if player.overlay != nil {
+		player.overlay.DisableAudioSubmission(true)
+	}
+
+	player.seeking = true
+
+	player.musicPlayer.SetPosition((player.rawPositionF + time - player.progressMsF) / 1000)
+
+	return nil
+}
+
+// SetSpeed changes playback speed multiplier, the same one -speed sets. Has to be called on the update thread.
+func (player *Player) SetSpeed(speed float64) error {
+	if speed <= 0 {
+		return errors.New("speed has to be greater than 0")
+	}
+
+	settings.SPEED = speed
+
+	return nil
+}
+
+// GetStatus returns a snapshot of the Player state. Has to be called on the update thread.
+func (player *Player) GetStatus() PlayerStatus {
+	status := PlayerStatus{
+		Time:    player.progressMsF,
+		EndTime: player.mapEndL,
+		Started: player.start,
+		Paused:  player.paused,
+		Speed:   settings.SPEED,
+		Beatmap: BeatmapStatus{
+			MD5:        player.bMap.MD5,
+			ID:         player.bMap.ID,
+			SetID:      player.bMap.SetID,
+			Artist:     player.bMap.Artist,
+			Title:      player.bMap.Name,
+			Difficulty: player.bMap.Difficulty,
+			Creator:    player.bMap.Creator,
+			Mods:       player.bMap.Diff.GetModString(),
+		},
+		Cursors: make([]CursorStatus, 0),
+		Alive:   make([]string, 0),
+	}
+
+	ruleset := player.getRuleset()
+
+	for _, cursor := range player.controller.GetCursors() {
+		cStatus := CursorStatus{
+			Name: cursor.Name,
+		}
+
+		if ruleset != nil {
+			score := ruleset.GetScore(cursor)
+
+			cStatus.Mods = ruleset.GetPlayerDifficulty(cursor).GetModString()
+			cStatus.Score = score.Score
+			cStatus.Accuracy = score.Accuracy
+			cStatus.Grade = score.Grade.String()
+			cStatus.Combo = score.CurrentCombo
+			cStatus.MaxCombo = score.Combo
+			cStatus.Count300 = score.Count300
+			cStatus.Count100 = score.Count100
+			cStatus.Count50 = score.Count50
+			cStatus.CountMiss = score.CountMiss
+			cStatus.CountSB = score.CountSB
+			cStatus.PP = score.PP.Total
+			cStatus.HP = ruleset.GetHP(cursor)
+			cStatus.Failed = ruleset.IsFailed(cursor)
+		}
+
+		if player.overlay != nil {
+			cStatus.KnockedOut = player.overlay.IsBroken(cursor)
+		}
+
+		if !cStatus.Failed && !cStatus.KnockedOut {
+			status.Alive = append(status.Alive, cursor.Name)
+		}
+
+		status.Cursors = append(status.Cursors, cStatus)
+	}
+
+	return status
+}
+
+func (player *Player) getRuleset() rulesets.Ruleset {
+	if rC, ok := player.controller.(*dance.ReplayController); ok {
+		return rC.GetRuleset()
+	} else if rP, ok := player.controller.(*dance.PlayerController); ok && rP.GetRuleset() != nil {
+		// Typed nil pointer would make the interface non-nil
+		return rP.GetRuleset()
+	}
+
+	return nil
+}
@@ -0,0 +1,441 @@
+package states
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/skin"
+	"github.com/wieku/danser-go/app/states/components/overlays"
+	"github.com/wieku/danser-go/app/utils"
+	"github.com/wieku/danser-go/framework/goroutines"
+	batch2 "github.com/wieku/danser-go/framework/graphics/batch"
+	"github.com/wieku/danser-go/framework/graphics/font"
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Time in milliseconds it takes to fade intermission cards and standings screen in and out
+const matchFadeTime = 500.0
+
+// MatchPick is a map of the match with its beatmap found in the database
+type MatchPick struct {
+	*settings.MatchMap
+
+	BeatMap  *beatmap.BeatMap
+	PlayMode int64
+}
+
+type matchPhase int
+
+const (
+	matchIntermission = matchPhase(iota)
+	matchPlaying
+	matchStandings
+)
+
+// matchSide is a team or a player without a team
+type matchSide struct {
+	name  string
+	color color2.Color
+
+	points float64
+	total  int64
+}
+
+type matchResult struct {
+	pick    string
+	winners []*matchSide
+}
+
+// Match plays maps of a tournament match back to back using knockout for each one.
+// Maps are separated by intermission cards with current standings, final standings are shown at the end.
+type Match struct {
+	config *settings.Match
+	picks  []*MatchPick
+
+	current   int
+	phase     matchPhase
+	phaseTime float64
+
+	// Time of the whole match and the time current pick's intermission started at, in milliseconds
+	time       float64
+	pickOffset float64
+
+	player *Player
+
+	sides   []*matchSide
+	sideMap map[string]*matchSide
+	results []*matchResult
+
+	batch *batch2.QuadBatch
+	font  *font.Font
+
+	ScaledWidth  float64
+	ScaledHeight float64
+}
+
+// NewMatch creates the match state, it has to be called on the main thread
+func NewMatch(config *settings.Match, picks []*MatchPick) *Match {
+	match := &Match{
+		config:  config,
+		picks:   picks,
+		sideMap: make(map[string]*matchSide),
+		font:    font.GetFont("Quicksand Bold"),
+	}
+
+	if settings.Graphics.Experimental.UsePersistentBuffers {
+		match.batch = batch2.NewQuadBatchPersistent()
+	} else {
+		match.batch = batch2.NewQuadBatch()
+	}
+
+	match.ScaledHeight = 1080.0
+	match.ScaledWidth = match.ScaledHeight * settings.Graphics.GetAspectRatio()
+
+	log.Println(fmt.Sprintf("Match: %s, %d maps, scoring: %s", config.Name, len(picks), config.Scoring))
+
+	return match
+}
+
+// Update advances the match by delta milliseconds, returns true if the match has ended
+func (match *Match) Update(delta float64) bool {
+	match.time += delta
+	match.phaseTime += delta
+
+	switch match.phase {
+	case matchIntermission:
+		if match.phaseTime >= match.config.Intermission*1000 {
+			goroutines.CallMain(match.loadPick)
+
+			match.setPhase(matchPlaying)
+		}
+	case matchPlaying:
+		if match.player.Update(delta) {
+			match.addResult()
+
+			// Free finished map's track, storyboard and textures before the next one is loaded
+			goroutines.CallMain(match.player.Dispose)
+
+			match.player = nil
+			match.current++
+			match.pickOffset = match.time
+
+			if match.current < len(match.picks) {
+				match.setPhase(matchIntermission)
+			} else {
+				match.logStandings()
+				match.setPhase(matchStandings)
+			}
+		}
+	case matchStandings:
+		return match.phaseTime >= match.config.StandingsTime*1000
+	}
+
+	return false
+}
+
+func (match *Match) setPhase(phase matchPhase) {
+	match.phase = phase
+	match.phaseTime = 0
+}
+
+// loadPick prepares the beatmap and knockout replays of the current pick, has to be called on the main thread
+func (match *Match) loadPick() {
+	pick := match.picks[match.current]
+	beatMap := pick.BeatMap
+
+	log.Println(fmt.Sprintf("Match: Loading %s (%d/%d): %s - %s [%s]", pick.Name, match.current+1, len(match.picks), beatMap.Artist, beatMap.Name, beatMap.Difficulty))
+
+	settings.REPLAY = ""
+	settings.PLAYMODE = pick.PlayMode
+	settings.KNOCKOUT = true
+	settings.KNOCKOUTREPLAYS = pick.Replays
+	settings.KNOCKOUTTEAMS = pick.Teams
+
+	// The same beatmap can be picked more than once
+	beatMap.Clear()
+	beatMap.Pauses = nil
+	beatMap.Diff.SetMods(difficulty.None)
+
+	skin.ClearBeatmapColors()
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, false, true)
+	beatMap.LoadCustomSamples()
+
+	match.player = NewPlayer(beatMap)
+}
+
+// addResult gives points for the finished map. Scores of team members are combined according to Knockout.TeamScoring.
+func (match *Match) addResult() {
+	controller, ok := match.player.controller.(*dance.ReplayController)
+	if !ok {
+		return
+	}
+
+	ruleset := controller.GetRuleset()
+	replays := controller.GetReplays()
+
+	values := make(map[*matchSide][]float64)
+	order := make([]*matchSide, 0)
+
+	for i, cursor := range controller.GetCursors() {
+		r := replays[i]
+
+		side := match.getSide(r.Name, color2.NewL(1))
+		if r.Team != nil {
+			side = match.getSide(r.Team.Name, r.Team.Color)
+		}
+
+		if _, ok = values[side]; !ok {
+			order = append(order, side)
+		}
+
+		values[side] = append(values[side], float64(ruleset.GetScore(cursor).Score))
+	}
+
+	result := &matchResult{
+		pick: match.picks[match.current].Name,
+	}
+
+	best := int64(-1)
+
+	for _, side := range order {
+		score := int64(overlays.AggregateTeam(values[side], false))
+
+		side.total += score
+
+		if match.config.Scoring == settings.MatchScoringCumulative {
+			side.points += float64(score)
+		}
+
+		if score > best {
+			best = score
+			result.winners = result.winners[:0]
+		}
+
+		if score == best {
+			result.winners = append(result.winners, side)
+		}
+	}
+
+	for _, side := range result.winners {
+		if match.config.Scoring == settings.MatchScoringWin {
+			side.points++
+		}
+	}
+
+	match.results = append(match.results, result)
+
+	log.Println(fmt.Sprintf("Match: %s won by %s", result.pick, winnerNames(result)))
+}
+
+func (match *Match) getSide(name string, color color2.Color) *matchSide {
+	side, ok := match.sideMap[name]
+	if !ok {
+		side = &matchSide{
+			name:  name,
+			color: color,
+		}
+
+		match.sideMap[name] = side
+		match.sides = append(match.sides, side)
+	}
+
+	return side
+}
+
+// standings returns sides sorted by points, ties are resolved by total score
+func (match *Match) standings() []*matchSide {
+	ranking := slices.Clone(match.sides)
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		if ranking[i].points != ranking[j].points {
+			return ranking[i].points > ranking[j].points
+		}
+
+		return ranking[i].total > ranking[j].total
+	})
+
+	return ranking
+}
+
+func (match *Match) logStandings() {
+	log.Println("Match: Final standings:")
+
+	for i, side := range match.standings() {
+		log.Println(fmt.Sprintf("#%d %s: %s", i+1, side.name, match.pointsText(side)))
+	}
+}
+
+func (match *Match) pointsText(side *matchSide) string {
+	if match.config.Scoring == settings.MatchScoringCumulative {
+		return utils.Humanize(int64(side.points))
+	}
+
+	if side.points == 1 {
+		return "1 point"
+	}
+
+	return fmt.Sprintf("%d points", int(side.points))
+}
+
+func winnerNames(result *matchResult) string {
+	names := make([]string, 0, len(result.winners))
+	for _, side := range result.winners {
+		names = append(names, side.name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func (match *Match) GetTimeOffset() float64 {
+	return match.time
+}
+
+// GetRunningTime returns the estimated length of the whole match, maps that haven't been loaded yet are estimated by their length
+func (match *Match) GetRunningTime() float64 {
+	running := match.pickOffset
+
+	for i := match.current; i < len(match.picks); i++ {
+		running += match.config.Intermission * 1000
+
+		if i == match.current && match.player != nil {
+			running += match.player.RunningTime
+		} else {
+			running += float64(match.picks[i].BeatMap.Length) + settings.Gameplay.ResultsScreenTime*1000
+		}
+	}
+
+	return running + match.config.StandingsTime*1000
+}
+
+func (match *Match) Draw(delta float64) {
+	switch match.phase {
+	case matchIntermission:
+		match.drawIntermission(match.fade(match.config.Intermission * 1000))
+	case matchPlaying:
+		match.player.Draw(delta)
+	case matchStandings:
+		match.drawStandings(match.fade(match.config.StandingsTime * 1000))
+	}
+}
+
+// fade returns the alpha of a card that is shown for given duration
+func (match *Match) fade(duration float64) float64 {
+	fadeTime := min(matchFadeTime, duration/2)
+	if fadeTime <= 0 {
+		return 1
+	}
+
+	return max(0, min(1, match.phaseTime/fadeTime, (duration-match.phaseTime)/fadeTime))
+}
+
+func (match *Match) beginDraw() {
+	match.batch.Begin()
+	match.batch.ResetTransform()
+	match.batch.SetCamera(mgl32.Ortho(0, float32(match.ScaledWidth), float32(match.ScaledHeight), 0, 1, -1))
+}
+
+func (match *Match) endDraw() {
+	match.batch.End()
+	match.batch.ResetTransform()
+	match.batch.SetColor(1, 1, 1, 1)
+}
+
+func (match *Match) drawIntermission(alpha float64) {
+	pick := match.picks[match.current]
+	bMap := pick.BeatMap
+
+	scl := match.ScaledHeight / 30
+
+	match.beginDraw()
+
+	match.batch.SetColor(1, 1, 1, alpha)
+	match.font.DrawOrigin(match.batch, match.ScaledWidth/2, scl*3, vector.Centre, scl*2, false, match.config.Name)
+
+	match.font.DrawOrigin(match.batch, match.ScaledWidth/2, scl*6, vector.Centre, scl*1.5, false, fmt.Sprintf("%s (%d/%d)", pick.Name, match.current+1, len(match.picks)))
+
+	match.batch.SetColor(0.8, 0.8, 0.8, alpha)
+	match.font.DrawOrigin(match.batch, match.ScaledWidth/2, scl*7.8, vector.Centre, scl, false, fmt.Sprintf("%s - %s [%s]", bMap.Artist, bMap.Name, bMap.Difficulty))
+	match.font.DrawOrigin(match.batch, match.ScaledWidth/2, scl*9, vector.Centre, scl*0.8, false, "Mapped by "+bMap.Creator)
+
+	if len(match.results) > 0 {
+		last := match.results[len(match.results)-1]
+
+		match.batch.SetColor(1, 1, 1, alpha)
+		match.font.DrawOrigin(match.batch, match.ScaledWidth/2, scl*11.5, vector.Centre, scl, false, fmt.Sprintf("%s won by %s", last.pick, winnerNames(last)))
+
+		match.drawTable(scl*13.5, alpha)
+	}
+
+	match.endDraw()
+}
+
+func (match *Match) drawStandings(alpha float64) {
+	scl := match.ScaledHeight / 30
+
+	match.beginDraw()
+
+	match.batch.SetColor(1, 1, 1, alpha)
+	match.font.DrawOrigin(match.batch, match.ScaledWidth/2, scl*3, vector.Centre, scl*2, false, match.config.Name)
+
+	match.batch.SetColor(0.8, 0.8, 0.8, alpha)
+	match.font.DrawOrigin(match.batch, match.ScaledWidth/2, scl*5.5, vector.Centre, scl*1.2, false, "Final standings")
+
+	match.drawTable(scl*8, alpha)
+
+	match.endDraw()
+}
+
+// drawTable draws current standings starting at given y, rows shrink if there are too many sides to fit
+func (match *Match) drawTable(y, alpha float64) {
+	scl := match.ScaledHeight / 30
+
+	ranking := match.standings()
+	if len(ranking) == 0 {
+		return
+	}
+
+	rowHeight := min(scl*1.5, (match.ScaledHeight-y-scl)/float64(len(ranking)))
+	size := rowHeight / 1.5
+
+	left := match.ScaledWidth * 0.25
+	right := match.ScaledWidth * 0.75
+
+	y += rowHeight / 2
+
+	for i, side := range ranking {
+		match.batch.SetColor(float64(side.color.R), float64(side.color.G), float64(side.color.B), alpha)
+		match.font.DrawOrigin(match.batch, left, y, vector.CentreLeft, size*1.2, false, fmt.Sprintf("#%d %s", i+1, side.name))
+
+		match.batch.SetColor(1, 1, 1, alpha)
+		match.font.DrawOrigin(match.batch, right, y, vector.CentreRight, size*1.2, true, match.pointsText(side))
+
+		if match.config.Scoring == settings.MatchScoringWin {
+			match.batch.SetColor(0.7, 0.7, 0.7, alpha)
+			match.font.DrawOrigin(match.batch, right-match.font.GetWidthMonospaced(size*1.2, match.pointsText(side))-size, y, vector.CentreRight, size*0.8, true, utils.Humanize(side.total))
+		}
+
+		y += rowHeight
+	}
+}
+
+func (match *Match) Show() {}
+
+func (match *Match) Hide() {}
+
+func (match *Match) Dispose() {
+	if match.player != nil {
+		match.player.Dispose()
+	}
+
+	match.batch.Dispose()
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/wieku/danser-go/app/input"
 	"github.com/wieku/danser-go/app/osuapi"
 	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/taiko"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/states/components/common"
 	"github.com/wieku/danser-go/app/states/components/containers"
@@ -43,6 +44,8 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -101,6 +104,9 @@ type Player struct {
 	pitchGlider     *animation.Glider
 	frequencyGlider *animation.Glider
 
+	// Difficulty used for the track rate, with variable rate mods it follows the first player
+	rateDiff *difficulty.Difficulty
+
 	startPoint  float64
 	startPointE float64
 
@@ -109,6 +115,7 @@ type Player struct {
 
 	objectsAlpha    *animation.Glider
 	objectContainer *containers.HitObjectContainer
+	taikoContainer  *containers.TaikoContainer
 
 	MapEnd      float64
 	RunningTime float64
@@ -138,6 +145,14 @@ type Player struct {
 	mBuffer   []byte
 	memTicker *time.Ticker
 	ftGraph   *shape.SteppingGraph
+
+	commandMutex sync.Mutex
+	commands     []func()
+
+	paused     bool
+	seeking    bool
+	stopped    atomic.Bool
+	updateDone chan struct{}
 }
 
 func NewPlayer(beatMap *beatmap.BeatMap) *Player {
@@ -236,6 +251,12 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 	player.background = common.NewBackground(true)
 	player.background.SetBeatmap(beatMap, true, true)
 
+	audio.ClearHitListeners()
+
+	if storyboard := player.background.GetStoryboard(); storyboard != nil {
+		audio.AddHitListener(storyboard.TriggerHitSound)
+	}
+
 	player.mainCamera = camera2.NewCamera()
 	player.mainCamera.SetOsuViewport(int(settings.Graphics.GetWidth()), int(settings.Graphics.GetHeight()), settings.Playfield.Scale, true, settings.Playfield.OsuShift)
 	player.mainCamera.Update()
@@ -290,10 +311,26 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 		player.controller.InitCursors()
 	}
 
+	player.rateDiff = player.bMap.Diff
+
+	if pController, ok := player.controller.(*dance.PlayerController); ok {
+		player.rateDiff = pController.GetRuleset().GetPlayerDifficulty(player.controller.GetCursors()[0])
+	} else if rController, ok := player.controller.(*dance.ReplayController); ok {
+		if oRuleset, ok1 := rController.GetRuleset().(*osu.OsuRuleSet); ok1 {
+			player.rateDiff = oRuleset.GetPlayerDifficulty(player.controller.GetCursors()[0])
+		}
+	}
+
 	player.lastTime = -1
 
 	player.objectContainer = containers.NewHitObjectContainer(beatMap)
 
+	if rC, ok := player.controller.(*dance.ReplayController); ok {
+		if tRuleset, ok1 := rC.GetRuleset().(*taiko.TaikoRuleSet); ok1 {
+			player.taikoContainer = containers.NewTaikoContainer(tRuleset, player.controller.GetCursors()[0])
+		}
+	}
+
 	player.Scl = 1
 	player.fadeOut = 1.0
 	player.fadeIn = 0.0
@@ -529,26 +566,37 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 		return player
 	}
 
+	player.updateDone = make(chan struct{})
+
 	goroutines.RunOS(func() {
+		defer close(player.updateDone)
+
 		var lastTimeNano = qpc.GetNanoTime()
 
-		for !input.Win.ShouldClose() {
+		for !input.Win.ShouldClose() && !player.stopped.Load() {
 			currentTimeNano := qpc.GetNanoTime()
 
 			delta := float64(currentTimeNano-lastTimeNano) / 1000000.0
 
 			player.profilerU.PutSample(delta)
 
+			player.runCommands()
+
 			musicState := player.musicPlayer.GetState()
 
 			speed := 1.0
 
+			clockDelta := delta
+			if player.paused {
+				clockDelta = 0
+			}
+
 			if musicState == bass.MusicStopped {
 				if player.rawPositionF < player.startPointE || player.start {
-					player.rawPositionF += delta
+					player.rawPositionF += clockDelta
 				} else {
-					speed = settings.SPEED * player.bMap.Diff.GetSpeed()
-					player.rawPositionF += delta * speed
+					speed = settings.SPEED * player.rateDiff.GetSpeedAt(player.progressMsF)
+					player.rawPositionF += clockDelta * speed
 				}
 			} else {
 				musicPos := player.musicPlayer.GetPosition() * 1000
@@ -592,15 +640,7 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 
 func (player *Player) trySetupFail() {
 	if sO, ok := player.overlay.(*overlays.ScoreOverlay); ok {
-		var ruleset *osu.OsuRuleSet
-
-		if rC, ok1 := player.controller.(*dance.ReplayController); ok1 {
-			ruleset = rC.GetRuleset()
-		} else if rP, ok2 := player.controller.(*dance.PlayerController); ok2 {
-			ruleset = rP.GetRuleset()
-		}
-
-		if ruleset != nil {
+		if ruleset := player.getRuleset(); ruleset != nil {
 			ruleset.SetFailListener(func(cursor *graphics.Cursor) {
 				if !settings.RECORD {
 					audio.PlayFailSound()
@@ -638,13 +678,27 @@ func (player *Player) trySetupFail() {
 	}
 }
 
+// updateStoryboardState switches storyboard between pass and fail state of the main cursor
+func (player *Player) updateStoryboardState() {
+	storyboard := player.background.GetStoryboard()
+	ruleset := player.getRuleset()
+
+	if storyboard == nil || ruleset == nil {
+		return
+	}
+
+	storyboard.SetPassing(ruleset.IsPassing(player.controller.GetCursors()[0]))
+}
+
 func (player *Player) Update(delta float64) bool {
+	player.runCommands()
+
 	speed := 1.0
 
 	if player.musicPlayer.GetState() == bass.MusicPlaying {
 		speed = player.musicPlayer.GetSpeed()
 	} else if !(player.progressMsF < player.startPointE || player.start) {
-		speed = settings.SPEED * player.bMap.Diff.GetSpeed()
+		speed = settings.SPEED * player.rateDiff.GetSpeedAt(player.progressMsF)
 	}
 
 	player.rawPositionF += delta * speed
@@ -676,6 +730,10 @@ func (player *Player) GetTimeOffset() float64 {
 	return player.progressMsF - player.startOffset
 }
 
+func (player *Player) GetRunningTime() float64 {
+	return player.RunningTime
+}
+
 func (player *Player) updateMain(delta float64) {
 	player.realTime += delta
 
@@ -722,8 +780,8 @@ func (player *Player) updateMain(delta float64) {
 	speedAdjust := mutils.Lerp(1, settings.SPEED, player.speedGlider.GetValue())
 	freqAdjust := 1.0
 
-	speedVal := mutils.Lerp(1, player.bMap.Diff.GetSpeed(), player.speedGlider.GetValue())
-	if player.bMap.Diff.AdjustsPitch() {
+	speedVal := mutils.Lerp(1, player.rateDiff.GetSpeedAt(player.progressMsF), player.speedGlider.GetValue())
+	if player.rateDiff.AdjustsPitch() {
 		freqAdjust = speedVal
 	} else {
 		speedAdjust *= speedVal
@@ -766,6 +824,8 @@ func (player *Player) updateMain(delta float64) {
 		player.overlay.Update(player.progressMsF)
 	}
 
+	player.updateStoryboardState()
+
 	player.updateMusic(delta)
 
 	player.coin.Update(player.progressMsF)
@@ -798,6 +858,15 @@ func (player *Player) updateMain(delta float64) {
 	if player.musicPlayer.GetState() == bass.MusicPlaying {
 		player.musicPlayer.SetVolumeRelative(player.volumeGlider.GetValue())
 	}
+
+	if player.seeking {
+		// Controller and overlay have caught up with the new position, so hitsounds can be played again
+		if player.overlay != nil {
+			player.overlay.DisableAudioSubmission(false)
+		}
+
+		player.seeking = false
+	}
 }
 
 func (player *Player) updateMusic(delta float64) {
@@ -844,6 +913,18 @@ func (player *Player) DrawMain(float64) {
 	objectCameras := player.objectCamera.GenRotated(settings.DIVIDES, -2*math.Pi/float64(settings.DIVIDES))
 	cursorCameras := player.mainCamera.GenRotated(settings.DIVIDES, -2*math.Pi/float64(settings.DIVIDES))
 
+	if s, ok := difficulty.GetModConfig[difficulty.BarrelRollSettings](player.bMap.Diff); ok {
+		// Cursor positions are in playfield space, so they rotate with the playfield and judgements are unaffected
+		barrelRoll := mgl32.Translate3D(camera2.OsuWidth/2, camera2.OsuHeight/2, 0).
+			Mul4(mgl32.HomogRotate3DZ(float32(s.GetRotation(player.progressMsF)))).
+			Mul4(mgl32.Translate3D(-camera2.OsuWidth/2, -camera2.OsuHeight/2, 0))
+
+		for i := range objectCameras {
+			objectCameras[i] = objectCameras[i].Mul4(barrelRoll)
+			cursorCameras[i] = cursorCameras[i].Mul4(barrelRoll)
+		}
+	}
+
 	bgAlpha := player.dimGlider.GetValue()
 	if settings.Playfield.Background.FlashToTheBeat {
 		bgAlpha = mutils.Clamp(bgAlpha*player.Scl, 0, 1)
@@ -859,6 +940,10 @@ func (player *Player) DrawMain(float64) {
 
 	cursorColors := settings.Cursor.GetColors(settings.DIVIDES, len(player.controller.GetCursors()), player.Scl, player.cursorGlider.GetValue())
 
+	if kO, ok := player.overlay.(*overlays.KnockoutOverlay); ok {
+		kO.ApplyTeamColors(cursorColors)
+	}
+
 	if player.overlay != nil {
 		player.drawOverlayPart(player.overlay.DrawBackground, cursorColors, cursorCameras[0], 1)
 	}
@@ -900,7 +985,11 @@ func (player *Player) DrawMain(float64) {
 		player.drawOverlayPart(player.overlay.DrawBeforeObjects, cursorColors, objectCameras[0], player.objectsAlphaFail.GetValue())
 	}
 
-	player.objectContainer.Draw(player.batch, player.mainCamera.GetProjectionView(), objectCameras, player.progressMsF, float32(player.Scl), float32(player.objectsAlpha.GetValue()*player.objectsAlphaFail.GetValue()))
+	if player.taikoContainer != nil {
+		player.taikoContainer.Draw(player.batch, player.uiCamera.GetProjectionView(), player.progressMsF, player.ScaledWidth, player.ScaledHeight, player.objectsAlpha.GetValue()*player.objectsAlphaFail.GetValue())
+	} else {
+		player.objectContainer.Draw(player.batch, player.mainCamera.GetProjectionView(), objectCameras, player.progressMsF, float32(player.Scl), float32(player.objectsAlpha.GetValue()*player.objectsAlphaFail.GetValue()))
+	}
 
 	if player.overlay != nil {
 		player.drawOverlayPart(player.overlay.DrawNormal, cursorColors, objectCameras[0], 1)
@@ -912,7 +1001,7 @@ func (player *Player) DrawMain(float64) {
 		player.drawOverlayPart(player.overlay.DrawHUD, cursorColors, player.uiCamera.GetProjectionView(), 1)
 	}
 
-	if settings.Playfield.DrawCursors {
+	if settings.Playfield.DrawCursors && player.taikoContainer == nil {
 		for _, g := range player.controller.GetCursors() {
 			g.UpdateRenderer()
 		}
@@ -1226,4 +1315,12 @@ func (player *Player) Show() {}
 
 func (player *Player) Hide() {}
 
-func (player *Player) Dispose() {}
+// Dispose frees the track, background with storyboard and render effects. Player has to be stopped beforehand, it's called on the main thread.
+func (player *Player) Dispose() {
+	player.musicPlayer.Dispose()
+	player.background.Dispose()
+
+	player.bloomEffect.Dispose()
+	player.blur.Dispose()
+	player.batch.Dispose()
+}
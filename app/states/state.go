@@ -6,3 +6,11 @@ type State interface {
 	Draw(delta float64)
 	Dispose()
 }
+
+// Recordable is a State that can be driven by the record loop
+type Recordable interface {
+	State
+	Update(delta float64) bool
+	GetTimeOffset() float64
+	GetRunningTime() float64
+}
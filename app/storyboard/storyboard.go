@@ -23,6 +23,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type Storyboard struct {
@@ -31,10 +33,16 @@ type Storyboard struct {
 	textures map[string]*texture.TextureRegion
 	atlas    *texture.TextureAtlas
 
+	// Textures too big for the atlas
+	singleTextures []*texture.TextureSingle
+
 	samples map[string]*bass.Sample
 
 	background  *sprite.Manager
 	pass        *sprite.Manager
+	fail        *sprite.Manager
+	passSamples *sprite.Manager
+	failSamples *sprite.Manager
 	foreground  *sprite.Manager
 	overlay     *sprite.Manager
 	zIndex      int64
@@ -49,6 +57,13 @@ type Storyboard struct {
 
 	videos     []sprite.ISprite
 	videoAlpha float64
+
+	triggers      []*spriteTriggers
+	triggerMutex  sync.Mutex
+	pendingEvents []TriggerEvent
+
+	// failing is true when the player is in the fail state, Fail layer is drawn instead of Pass layer then
+	failing atomic.Bool
 }
 
 func getSection(line string) string {
@@ -62,16 +77,19 @@ func getSection(line string) string {
 
 func NewStoryboard(beatMap *beatmap.BeatMap) *Storyboard {
 	storyboard := &Storyboard{
-		beatMap:    beatMap,
-		textures:   make(map[string]*texture.TextureRegion),
-		samples:    make(map[string]*bass.Sample),
-		zIndex:     -1,
-		background: sprite.NewManager(),
-		pass:       sprite.NewManager(),
-		foreground: sprite.NewManager(),
-		overlay:    sprite.NewManager(),
-		atlas:      nil,
-		videos:     make([]sprite.ISprite, 0),
+		beatMap:     beatMap,
+		textures:    make(map[string]*texture.TextureRegion),
+		samples:     make(map[string]*bass.Sample),
+		zIndex:      -1,
+		background:  sprite.NewManager(),
+		pass:        sprite.NewManager(),
+		fail:        sprite.NewManager(),
+		passSamples: sprite.NewManager(),
+		failSamples: sprite.NewManager(),
+		foreground:  sprite.NewManager(),
+		overlay:     sprite.NewManager(),
+		atlas:       nil,
+		videos:      make([]sprite.ISprite, 0),
 	}
 
 	files := []string{
@@ -150,7 +168,7 @@ func NewStoryboard(beatMap *beatmap.BeatMap) *Storyboard {
 
 					sbSprite := sprite.NewAudioSprite(storyboard.getSample(sample), startTime, volume/100)
 
-					storyboard.addSpriteToLayer(spl[2], sbSprite)
+					storyboard.addSampleToLayer(spl[2], sbSprite)
 
 					hasAudio = true
 				} else if settings.Playfield.Background.LoadVideos && (strings.HasPrefix(line, "Video") || strings.HasPrefix(line, "1")) {
@@ -281,13 +299,25 @@ func (storyboard *Storyboard) loadSprite(currentSprite string, commands []string
 	if len(textures) != 0 {
 		sbSprite := sprite.NewAnimation(textures, frameDelay, loopForever, float64(storyboard.zIndex), pos, origin)
 
-		transforms := parseCommands(commands)
+		transforms, triggerGroups := parseCommands(commands)
 
 		sbSprite.ShowForever(false)
 		sbSprite.AddTransforms(transforms)
 		sbSprite.AdjustTimesToTransformations()
 		sbSprite.ResetValuesToTransforms()
 
+		if len(triggerGroups) > 0 {
+			triggers := newSpriteTriggers(sbSprite, triggerGroups)
+			triggers.adjustTimes(len(transforms) > 0)
+
+			// Sprites animated only by triggers stay hidden until the first one fires
+			if len(transforms) == 0 {
+				sbSprite.SetAlpha(0)
+			}
+
+			storyboard.triggers = append(storyboard.triggers, triggers)
+		}
+
 		storyboard.addSpriteToLayer(spl[1], sbSprite)
 
 		storyboard.numSprites++
@@ -298,6 +328,8 @@ func (storyboard *Storyboard) addSpriteToLayer(layer string, sbSprite sprite.ISp
 	switch layer {
 	case "0", "Background":
 		storyboard.background.Add(sbSprite)
+	case "1", "Fail":
+		storyboard.fail.Add(sbSprite)
 	case "2", "Pass":
 		storyboard.pass.Add(sbSprite)
 	case "3", "Foreground":
@@ -307,6 +339,18 @@ func (storyboard *Storyboard) addSpriteToLayer(layer string, sbSprite sprite.ISp
 	}
 }
 
+// addSampleToLayer adds a storyboard sample, samples on Pass and Fail layers are played only in the matching state
+func (storyboard *Storyboard) addSampleToLayer(layer string, sample *sprite.AudioSprite) {
+	switch layer {
+	case "1", "Fail":
+		storyboard.failSamples.Add(sample)
+	case "2", "Pass":
+		storyboard.passSamples.Add(sample)
+	default:
+		storyboard.addSpriteToLayer(layer, sample)
+	}
+}
+
 func (storyboard *Storyboard) getTexture(image string) *texture.TextureRegion {
 	var texture1 *texture.TextureRegion
 
@@ -328,6 +372,9 @@ func (storyboard *Storyboard) getTexture(image string) *texture.TextureRegion {
 					tex := texture.NewTextureSingle(img.Width, img.Height, 0)
 					tex.Bind(0)
 					tex.SetData(0, 0, img.Width, img.Height, img.Data)
+
+					storyboard.singleTextures = append(storyboard.singleTextures, tex)
+
 					rg := tex.GetRegion()
 					texture1 = &rg
 				} else {
@@ -395,6 +442,25 @@ func (storyboard *Storyboard) IsThreadRunning() bool {
 	return storyboard.shouldRun
 }
 
+// Dispose stops the update thread, videos and frees storyboard's textures. Storyboard can't be used afterwards.
+func (storyboard *Storyboard) Dispose() {
+	storyboard.StopThread()
+
+	for _, v := range storyboard.videos {
+		if video, ok := v.(*video2.Video); ok {
+			video.Dispose()
+		}
+	}
+
+	if storyboard.atlas != nil {
+		storyboard.atlas.Dispose()
+	}
+
+	for _, tex := range storyboard.singleTextures {
+		tex.Dispose()
+	}
+}
+
 func (storyboard *Storyboard) UpdateTime(time float64) {
 	storyboard.currentTime = time
 }
@@ -407,9 +473,71 @@ func (storyboard *Storyboard) SetFPS(i int) {
 	storyboard.limiter.FPS = i
 }
 
+// TriggerHitSound fires HitSound triggers. Can be called from any thread, triggers start on the next storyboard update.
+func (storyboard *Storyboard) TriggerHitSound(sampleSet, additionSet, hitSound, customIndex int) {
+	storyboard.queueEvent(TriggerEvent{
+		Type:        HitSound,
+		SampleSet:   sampleSet,
+		AdditionSet: additionSet,
+		HitSound:    hitSound,
+		CustomIndex: customIndex,
+	})
+}
+
+// SetPassing switches between pass and fail state, firing Passing or Failing triggers if the state changed. Can be called from any thread.
+func (storyboard *Storyboard) SetPassing(passing bool) {
+	if storyboard.failing.Swap(!passing) == !passing {
+		return
+	}
+
+	eventType := Passing
+	if !passing {
+		eventType = Failing
+	}
+
+	storyboard.queueEvent(TriggerEvent{Type: eventType})
+}
+
+func (storyboard *Storyboard) IsPassing() bool {
+	return !storyboard.failing.Load()
+}
+
+func (storyboard *Storyboard) queueEvent(event TriggerEvent) {
+	if len(storyboard.triggers) == 0 {
+		return
+	}
+
+	storyboard.triggerMutex.Lock()
+	storyboard.pendingEvents = append(storyboard.pendingEvents, event)
+	storyboard.triggerMutex.Unlock()
+}
+
+func (storyboard *Storyboard) processTriggers(time float64) {
+	storyboard.triggerMutex.Lock()
+	events := storyboard.pendingEvents
+	storyboard.pendingEvents = nil
+	storyboard.triggerMutex.Unlock()
+
+	for _, event := range events {
+		for _, t := range storyboard.triggers {
+			t.fire(event, time)
+		}
+	}
+}
+
 func (storyboard *Storyboard) Update(time float64) {
+	storyboard.processTriggers(time)
+
 	storyboard.background.Update(time)
 	storyboard.pass.Update(time)
+	storyboard.fail.Update(time)
+
+	if storyboard.IsPassing() {
+		storyboard.passSamples.Update(time)
+	} else {
+		storyboard.failSamples.Update(time)
+	}
+
 	storyboard.foreground.Update(time)
 	storyboard.overlay.Update(time)
 
@@ -428,7 +556,13 @@ func (storyboard *Storyboard) Draw(time float64, batch *batch.QuadBatch) {
 	profiler.StartGroup("Storyboard.Draw", profiler.PDraw)
 	batch.SetTranslation(vector.NewVec2d(-64, -48))
 	storyboard.background.Draw(time, batch)
-	storyboard.pass.Draw(time, batch)
+
+	if storyboard.IsPassing() {
+		storyboard.pass.Draw(time, batch)
+	} else {
+		storyboard.fail.Draw(time, batch)
+	}
+
 	storyboard.foreground.Draw(time, batch)
 	batch.SetTranslation(vector.NewVec2d(0, 0))
 	profiler.EndGroup()
@@ -443,15 +577,15 @@ func (storyboard *Storyboard) DrawOverlay(time float64, batch *batch.QuadBatch)
 }
 
 func (storyboard *Storyboard) GetRenderedSprites() int {
-	return storyboard.background.GetNumRendered() + storyboard.pass.GetNumRendered() + storyboard.foreground.GetNumRendered() + storyboard.overlay.GetNumRendered()
+	return storyboard.background.GetNumRendered() + storyboard.pass.GetNumRendered() + storyboard.fail.GetNumRendered() + storyboard.foreground.GetNumRendered() + storyboard.overlay.GetNumRendered()
 }
 
 func (storyboard *Storyboard) GetProcessedSprites() int {
-	return storyboard.background.GetNumProcessed() + storyboard.pass.GetNumProcessed() + storyboard.foreground.GetNumProcessed() + storyboard.overlay.GetNumProcessed()
+	return storyboard.background.GetNumProcessed() + storyboard.pass.GetNumProcessed() + storyboard.fail.GetNumProcessed() + storyboard.foreground.GetNumProcessed() + storyboard.overlay.GetNumProcessed()
 }
 
 func (storyboard *Storyboard) GetQueueSprites() int {
-	return storyboard.background.GetNumInQueue() + storyboard.pass.GetNumInQueue() + storyboard.foreground.GetNumInQueue() + storyboard.overlay.GetNumInQueue()
+	return storyboard.background.GetNumInQueue() + storyboard.pass.GetNumInQueue() + storyboard.fail.GetNumInQueue() + storyboard.foreground.GetNumInQueue() + storyboard.overlay.GetNumInQueue()
 }
 
 func (storyboard *Storyboard) GetTotalSprites() int {
@@ -0,0 +1,273 @@
+package storyboard
+
+import (
+	"github.com/wieku/danser-go/framework/graphics/sprite"
+	"github.com/wieku/danser-go/framework/math/animation"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type TriggerType int
+
+const (
+	HitSound TriggerType = iota
+	Passing
+	Failing
+)
+
+// TriggerEvent is something that happened during gameplay and may start trigger groups
+type TriggerEvent struct {
+	Type TriggerType
+
+	// Used only by HitSound events
+	SampleSet   int
+	AdditionSet int
+	HitSound    int
+	CustomIndex int
+}
+
+// Trigger is a condition parsed from trigger name, e.g. HitSoundSoftWhistle or Failing
+type Trigger struct {
+	Type TriggerType
+
+	// 0 means any sample set
+	SampleSet   int
+	AdditionSet int
+
+	// 0 means any addition, otherwise a whistle/finish/clap bit of the hitsound
+	Addition int
+
+	// -1 means any custom sample index
+	CustomIndex int
+}
+
+var triggerSets = []struct {
+	name  string
+	value int
+}{
+	{"All", 0},
+	{"Normal", 1},
+	{"Soft", 2},
+	{"Drum", 3},
+}
+
+var triggerAdditions = []struct {
+	name  string
+	value int
+}{
+	{"Whistle", 2},
+	{"Finish", 4},
+	{"Clap", 8},
+}
+
+// ParseTrigger parses trigger name. HitSound triggers have the form HitSound[SampleSet][AdditionSet][Addition][CustomIndex].
+func ParseTrigger(name string) (*Trigger, bool) {
+	switch name {
+	case "Passing":
+		return &Trigger{Type: Passing}, true
+	case "Failing":
+		return &Trigger{Type: Failing}, true
+	}
+
+	rest, ok := strings.CutPrefix(name, "HitSound")
+	if !ok {
+		return nil, false
+	}
+
+	trigger := &Trigger{
+		Type:        HitSound,
+		CustomIndex: -1,
+	}
+
+	setsFound := 0
+
+	for setsFound < 2 {
+		found := false
+
+		for _, s := range triggerSets {
+			if after, ok2 := strings.CutPrefix(rest, s.name); ok2 {
+				if setsFound == 0 {
+					trigger.SampleSet = s.value
+				} else {
+					trigger.AdditionSet = s.value
+				}
+
+				rest = after
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			break
+		}
+
+		setsFound++
+	}
+
+	for _, a := range triggerAdditions {
+		if after, ok2 := strings.CutPrefix(rest, a.name); ok2 {
+			trigger.Addition = a.value
+			rest = after
+
+			break
+		}
+	}
+
+	if rest != "" {
+		index, err := strconv.ParseInt(rest, 10, 32)
+		if err != nil {
+			return nil, false
+		}
+
+		trigger.CustomIndex = int(index)
+	}
+
+	return trigger, true
+}
+
+func (trigger *Trigger) Matches(event TriggerEvent) bool {
+	if trigger.Type != event.Type {
+		return false
+	}
+
+	if trigger.Type != HitSound {
+		return true
+	}
+
+	return (trigger.SampleSet == 0 || trigger.SampleSet == event.SampleSet) &&
+		(trigger.AdditionSet == 0 || trigger.AdditionSet == event.AdditionSet) &&
+		(trigger.Addition == 0 || event.HitSound&trigger.Addition > 0) &&
+		(trigger.CustomIndex < 0 || trigger.CustomIndex == event.CustomIndex)
+}
+
+// TriggerGroup holds commands nested under a T command. Their times are relative to the moment trigger fires.
+type TriggerGroup struct {
+	trigger *Trigger
+
+	start, end float64
+	group      int64
+
+	transforms []*animation.Transformation
+}
+
+func NewTriggerGroup(data []string) *TriggerGroup {
+	trigger, ok := ParseTrigger(strings.TrimSpace(data[1]))
+	if !ok {
+		log.Println("Unknown storyboard trigger:", data[1])
+		return nil
+	}
+
+	group := &TriggerGroup{
+		trigger: trigger,
+		start:   -math.MaxFloat64,
+		end:     math.MaxFloat64,
+	}
+
+	var err error
+
+	if len(data) > 2 && data[2] != "" {
+		group.start, err = strconv.ParseFloat(data[2], 64)
+		if err != nil {
+			log.Println("Failed to parse: ", data)
+			panic(err)
+		}
+	}
+
+	if len(data) > 3 && data[3] != "" {
+		group.end, err = strconv.ParseFloat(data[3], 64)
+		if err != nil {
+			log.Println("Failed to parse: ", data)
+			panic(err)
+		}
+	}
+
+	if len(data) > 4 && data[4] != "" {
+		group.group, err = strconv.ParseInt(data[4], 10, 64)
+		if err != nil {
+			log.Println("Failed to parse: ", data)
+			panic(err)
+		}
+	}
+
+	return group
+}
+
+func (group *TriggerGroup) Add(command []string) {
+	if parsed := parseCommand(command); parsed != nil {
+		group.transforms = append(group.transforms, parsed...)
+	}
+}
+
+// GetDuration returns the time from firing to the end of the last nested command
+func (group *TriggerGroup) GetDuration() float64 {
+	duration := 0.0
+
+	for _, t := range group.transforms {
+		duration = max(duration, t.GetEndTime())
+	}
+
+	return duration
+}
+
+// spriteTriggers connects trigger groups with the sprite they animate
+type spriteTriggers struct {
+	sprite sprite.ISprite
+	groups []*TriggerGroup
+
+	// Transformations currently running on the sprite, by group number
+	active map[int64][]*animation.Transformation
+}
+
+func newSpriteTriggers(sbSprite sprite.ISprite, groups []*TriggerGroup) *spriteTriggers {
+	return &spriteTriggers{
+		sprite: sbSprite,
+		groups: groups,
+		active: make(map[int64][]*animation.Transformation),
+	}
+}
+
+// fire starts every group that listens to given event. Starting a group stops the previous run of groups with the same number.
+func (triggers *spriteTriggers) fire(event TriggerEvent, time float64) {
+	for _, group := range triggers.groups {
+		if time < group.start || time > group.end || !group.trigger.Matches(event) {
+			continue
+		}
+
+		for _, t := range triggers.active[group.group] {
+			triggers.sprite.RemoveTransform(t)
+		}
+
+		transforms := make([]*animation.Transformation, 0, len(group.transforms))
+
+		for _, t := range group.transforms {
+			transforms = append(transforms, t.Clone(time+t.GetStartTime(), time+t.GetEndTime()))
+		}
+
+		triggers.sprite.AddTransforms(transforms)
+
+		triggers.active[group.group] = transforms
+	}
+}
+
+// adjustTimes extends the lifetime of the sprite, so it stays processed while any of its triggers can fire
+func (triggers *spriteTriggers) adjustTimes(hasTransforms bool) {
+	startTime := triggers.sprite.GetStartTime()
+	endTime := triggers.sprite.GetEndTime()
+
+	if !hasTransforms {
+		startTime = math.MaxFloat64
+		endTime = -math.MaxFloat64
+	}
+
+	for _, group := range triggers.groups {
+		startTime = min(startTime, group.start)
+		endTime = max(endTime, group.end+group.GetDuration())
+	}
+
+	triggers.sprite.SetStartTime(startTime)
+	triggers.sprite.SetEndTime(endTime)
+}
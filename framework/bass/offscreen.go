@@ -7,9 +7,12 @@ package bass
 */
 import "C"
 import (
+	"io"
 	"unsafe"
 )
 
+const mixerFrameSize = 8 // 2 channels of 32-bit floats
+
 func GetMixerRequiredBufferSize(seconds float64) int {
 	return int(C.BASS_ChannelSeconds2Bytes(masterMixer, C.double(seconds)))
 }
@@ -17,3 +20,27 @@ func GetMixerRequiredBufferSize(seconds float64) int {
 func ProcessMixer(buffer []byte) {
 	C.BASS_ChannelGetData(masterMixer, unsafe.Pointer(&buffer[0]), C.DWORD(len(buffer)))
 }
+
+// MixerReader reads given amount of audio from the offscreen master mixer as 32-bit float stereo PCM
+type MixerReader struct {
+	remaining int
+}
+
+func NewMixerReader(seconds float64) *MixerReader {
+	return &MixerReader{remaining: GetMixerRequiredBufferSize(seconds)}
+}
+
+func (reader *MixerReader) Read(p []byte) (int, error) {
+	n := min(len(p), reader.remaining)
+	n -= n % mixerFrameSize
+
+	if n <= 0 {
+		return 0, io.EOF
+	}
+
+	ProcessMixer(p[:n])
+
+	reader.remaining -= n
+
+	return n, nil
+}
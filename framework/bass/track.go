@@ -35,4 +35,5 @@ type ITrack interface {
 	GetRightLevel() float64
 	GetBoost() float64
 	GetBeat() float64
+	Dispose()
 }
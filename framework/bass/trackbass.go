@@ -293,3 +293,10 @@ func (track *TrackBass) GetBoost() float64 {
 func (track *TrackBass) GetBeat() float64 {
 	return track.lowMax
 }
+
+// Dispose stops the track and frees the stream, track can't be used afterwards
+func (track *TrackBass) Dispose() {
+	track.Stop()
+
+	C.BASS_StreamFree(track.channel)
+}
@@ -187,3 +187,7 @@ func (track *TrackVirtual) GetBoost() float64 {
 func (track *TrackVirtual) GetBeat() float64 {
 	return 0
 }
+
+func (track *TrackVirtual) Dispose() {
+	track.Stop()
+}
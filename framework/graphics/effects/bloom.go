@@ -73,6 +73,14 @@ func NewBloomEffect(width, height int) *BloomEffect {
 	return effect
 }
 
+func (effect *BloomEffect) Dispose() {
+	effect.filterShader.Dispose()
+	effect.combineShader.Dispose()
+	effect.vao.Dispose()
+	effect.fbo.Dispose()
+	effect.blurEffect.Dispose()
+}
+
 func (effect *BloomEffect) SetThreshold(threshold float64) {
 	effect.threshold = threshold
 }
@@ -20,6 +20,8 @@ type ISprite interface {
 
 	SortTransformations()
 
+	RemoveTransform(transformation *animation.Transformation)
+
 	ClearTransformations()
 
 	ClearTransformationsOfType(transformationType animation.TransformationType)
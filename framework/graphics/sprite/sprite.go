@@ -162,6 +162,12 @@ func (sprite *Sprite) SortTransformations() {
 	})
 }
 
+func (sprite *Sprite) RemoveTransform(transformation *animation.Transformation) {
+	if i := slices.Index(sprite.transforms, transformation); i > -1 {
+		sprite.transforms = slices.Delete(sprite.transforms, i, i+1)
+	}
+}
+
 func (sprite *Sprite) ClearTransformations() {
 	sprite.transforms = make([]*animation.Transformation, 0)
 }
@@ -171,6 +171,23 @@ func (dec *VideoDecoder) StartFFmpeg(millis int64) {
 	})
 }
 
+// Stop kills ffmpeg and waits for the decoding goroutine to end, decoder can be started again with StartFFmpeg
+func (dec *VideoDecoder) Stop() {
+	if !dec.running {
+		return
+	}
+
+	dec.running = false
+
+	if dec.command != nil {
+		_ = dec.command.Process.Kill()
+	}
+
+	close(dec.decodingQueue)
+
+	dec.wg.Wait()
+}
+
 func (dec *VideoDecoder) GetFrame() Frame {
 	return <-dec.readyQueue
 }
@@ -87,6 +87,14 @@ func (video *Video) Update(time float64) {
 	}
 }
 
+// Dispose stops decoding and frees video's texture, video can't be used afterwards
+func (video *Video) Dispose() {
+	video.decoder.Stop()
+	video.decoder.finished = true
+
+	video.texture.Dispose()
+}
+
 func (video *Video) Draw(time float64, batch *batch.QuadBatch) {
 	video.mutex.Lock()
 	if video.dirty {
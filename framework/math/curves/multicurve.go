@@ -298,6 +298,11 @@ func (mCurve *MultiCurve) GetLines() []Linear {
 	return mCurve.lines
 }
 
+// GetPointsLazer returns path points used by lazer's calculations, trimmed to desired length
+func (mCurve *MultiCurve) GetPointsLazer() []vector.Vector2f {
+	return mCurve.points
+}
+
 func processPerfect(points []vector.Vector2f, lazer bool) (outPoints []vector.Vector2f) {
 	if len(points) > 3 {
 		outPoints = processBezier(points)
@@ -0,0 +1,103 @@
+package dotnet
+
+import "math"
+
+const (
+	randomSeed = 161803398
+	randomMax  = math.MaxInt32
+)
+
+// Random is a port of .NET's seeded System.Random (Knuth's subtractive generator).
+// Lazer's mods use it for generation, so the same seed has to give the same numbers.
+type Random struct {
+	seedArray [56]int32
+	inext     int
+	inextp    int
+}
+
+// NewRandom creates a generator that returns the same sequence as new System.Random(seed)
+func NewRandom(seed int32) *Random {
+	r := &Random{}
+
+	subtraction := int32(randomMax)
+	if seed != math.MinInt32 {
+		subtraction = seed
+		if subtraction < 0 {
+			subtraction = -subtraction
+		}
+	}
+
+	mj := randomSeed - subtraction
+	r.seedArray[55] = mj
+
+	mk := int32(1)
+	ii := 0
+
+	for i := 1; i < 55; i++ {
+		if ii += 21; ii >= 55 {
+			ii -= 55
+		}
+
+		r.seedArray[ii] = mk
+
+		mk = mj - mk
+		if mk < 0 {
+			mk += randomMax
+		}
+
+		mj = r.seedArray[ii]
+	}
+
+	for k := 1; k < 5; k++ {
+		for i := 1; i < 56; i++ {
+			n := i + 30
+			if n >= 55 {
+				n -= 55
+			}
+
+			r.seedArray[i] -= r.seedArray[1+n]
+			if r.seedArray[i] < 0 {
+				r.seedArray[i] += randomMax
+			}
+		}
+	}
+
+	r.inext = 0
+	r.inextp = 21
+
+	return r
+}
+
+func (r *Random) internalSample() int32 {
+	locINext := r.inext + 1
+	if locINext >= 56 {
+		locINext = 1
+	}
+
+	locINextp := r.inextp + 1
+	if locINextp >= 56 {
+		locINextp = 1
+	}
+
+	retVal := r.seedArray[locINext] - r.seedArray[locINextp]
+
+	if retVal == randomMax {
+		retVal--
+	}
+
+	if retVal < 0 {
+		retVal += randomMax
+	}
+
+	r.seedArray[locINext] = retVal
+
+	r.inext = locINext
+	r.inextp = locINextp
+
+	return retVal
+}
+
+// NextDouble returns a number in [0, 1) range
+func (r *Random) NextDouble() float64 {
+	return float64(r.internalSample()) * (1.0 / randomMax)
+}
@@ -0,0 +1,77 @@
+package expression
+
+import (
+	"math"
+)
+
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+type function struct {
+	args int // -1 means any number of arguments
+	call func(a []float64) float64
+}
+
+func unary(f func(float64) float64) function {
+	return function{args: 1, call: func(a []float64) float64 { return f(a[0]) }}
+}
+
+func binary(f func(float64, float64) float64) function {
+	return function{args: 2, call: func(a []float64) float64 { return f(a[0], a[1]) }}
+}
+
+// cond(c, a, b) returning a if c != 0 and b otherwise is handled by the compiler
+var functions = map[string]function{
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"asin":  unary(math.Asin),
+	"acos":  unary(math.Acos),
+	"atan":  unary(math.Atan),
+	"atan2": binary(math.Atan2),
+	"sqrt":  unary(math.Sqrt),
+	"abs":   unary(math.Abs),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"exp":   unary(math.Exp),
+	"log":   unary(math.Log),
+	"pow":   binary(math.Pow),
+	"mod":   binary(math.Mod),
+	"hypot": binary(math.Hypot),
+	"sign": unary(func(x float64) float64 {
+		if x == 0 {
+			return 0
+		}
+
+		return math.Copysign(1, x)
+	}),
+	"min": {args: -1, call: func(a []float64) float64 {
+		v := a[0]
+		for _, x := range a[1:] {
+			v = min(v, x)
+		}
+
+		return v
+	}},
+	"max": {args: -1, call: func(a []float64) float64 {
+		v := a[0]
+		for _, x := range a[1:] {
+			v = max(v, x)
+		}
+
+		return v
+	}},
+	"clamp": {args: 3, call: func(a []float64) float64 {
+		return min(a[2], max(a[1], a[0]))
+	}},
+	"lerp": {args: 3, call: func(a []float64) float64 {
+		return a[0] + (a[1]-a[0])*a[2]
+	}},
+	"smoothstep": unary(func(x float64) float64 {
+		x = min(1, max(0, x))
+		return x * x * (3 - 2*x)
+	}),
+}
@@ -0,0 +1,146 @@
+package expression
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"math"
+	"strconv"
+)
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func compileLiteral(lit *ast.BasicLit) (func() float64, error) {
+	if lit.Kind != token.INT && lit.Kind != token.FLOAT {
+		return nil, fmt.Errorf("unsupported literal %s", lit.Value)
+	}
+
+	value, err := strconv.ParseFloat(lit.Value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %s", lit.Value)
+	}
+
+	return func() float64 { return value }, nil
+}
+
+func (program *Program) compileUnary(e *ast.UnaryExpr) (func() float64, error) {
+	x, err := program.compile(e.X)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Op {
+	case token.ADD:
+		return x, nil
+	case token.SUB:
+		return func() float64 { return -x() }, nil
+	case token.NOT:
+		return func() float64 { return boolToFloat(x() == 0) }, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator %s", e.Op)
+}
+
+func (program *Program) compileBinary(e *ast.BinaryExpr) (func() float64, error) {
+	x, err := program.compile(e.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := program.compile(e.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Op {
+	case token.ADD:
+		return func() float64 { return x() + y() }, nil
+	case token.SUB:
+		return func() float64 { return x() - y() }, nil
+	case token.MUL:
+		return func() float64 { return x() * y() }, nil
+	case token.QUO:
+		return func() float64 { return x() / y() }, nil
+	case token.REM:
+		return func() float64 { return math.Mod(x(), y()) }, nil
+	case token.EQL:
+		return func() float64 { return boolToFloat(x() == y()) }, nil
+	case token.NEQ:
+		return func() float64 { return boolToFloat(x() != y()) }, nil
+	case token.LSS:
+		return func() float64 { return boolToFloat(x() < y()) }, nil
+	case token.LEQ:
+		return func() float64 { return boolToFloat(x() <= y()) }, nil
+	case token.GTR:
+		return func() float64 { return boolToFloat(x() > y()) }, nil
+	case token.GEQ:
+		return func() float64 { return boolToFloat(x() >= y()) }, nil
+	case token.LAND:
+		return func() float64 { return boolToFloat(x() != 0 && y() != 0) }, nil
+	case token.LOR:
+		return func() float64 { return boolToFloat(x() != 0 || y() != 0) }, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator %s", e.Op)
+}
+
+func (program *Program) compileCall(e *ast.CallExpr) (func() float64, error) {
+	ident, ok := e.Fun.(*ast.Ident)
+	if !ok {
+		return nil, fmt.Errorf("unsupported function call")
+	}
+
+	args := make([]func() float64, len(e.Args))
+
+	for i, arg := range e.Args {
+		var err error
+
+		if args[i], err = program.compile(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	// Evaluates only one of the branches
+	if ident.Name == "cond" {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("function cond takes 3 arguments, got %d", len(args))
+		}
+
+		return func() float64 {
+			if args[0]() != 0 {
+				return args[1]()
+			}
+
+			return args[2]()
+		}, nil
+	}
+
+	f, ok := functions[ident.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function \"%s\"", ident.Name)
+	}
+
+	if f.args > -1 && len(args) != f.args {
+		return nil, fmt.Errorf("function %s takes %d arguments, got %d", ident.Name, f.args, len(args))
+	}
+
+	if f.args < 0 && len(args) == 0 {
+		return nil, fmt.Errorf("function %s needs at least one argument", ident.Name)
+	}
+
+	values := make([]float64, len(args))
+
+	return func() float64 {
+		for i, arg := range args {
+			values[i] = arg()
+		}
+
+		return f.call(values)
+	}, nil
+}
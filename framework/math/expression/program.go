@@ -0,0 +1,169 @@
+// Package expression contains a small interpreter of math scripts.
+//
+// Script is a list of assignments separated by new lines or semicolons, e.g.:
+//
+//	# comment
+//	mid = lerp(startX, endX, 0.5)
+//	x = mid + sin(t * pi) * 50
+//	y = lerp(startY, endY, t)
+//
+// Expressions use Go syntax with all values being float64. Supported operators are + - * / %,
+// comparisons and logical operators which return 1 or 0, and unary - + !. There's no power operator, pow(x, y) has to be used. Available functions are listed in functions.go.
+package expression
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+type statement struct {
+	target int
+	eval   func() float64
+}
+
+// Program is a compiled script. It's not safe to run it from multiple goroutines at once.
+type Program struct {
+	slots []float64
+	names map[string]int
+
+	statements []statement
+}
+
+// Compile compiles the script. Inputs are names of variables that will be set with Set before running the program,
+// using any other variable before it's assigned is an error.
+func Compile(source string, inputs ...string) (*Program, error) {
+	program := &Program{
+		names: make(map[string]int),
+	}
+
+	for _, name := range inputs {
+		program.slot(name)
+	}
+
+	for name, value := range constants {
+		program.slots[program.slot(name)] = value
+	}
+
+	for i, line := range strings.Split(source, "\n") {
+		if index := strings.IndexByte(line, '#'); index > -1 {
+			line = line[:index]
+		}
+
+		if index := strings.Index(line, "//"); index > -1 {
+			line = line[:index]
+		}
+
+		for _, part := range strings.Split(line, ";") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+
+			if err := program.compileStatement(part, i+1); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return program, nil
+}
+
+func (program *Program) slot(name string) int {
+	if index, ok := program.names[name]; ok {
+		return index
+	}
+
+	program.names[name] = len(program.slots)
+	program.slots = append(program.slots, 0)
+
+	return len(program.slots) - 1
+}
+
+func (program *Program) compileStatement(source string, line int) error {
+	name, exprSource, ok := strings.Cut(source, "=")
+	if !ok {
+		return fmt.Errorf("line %d: expected assignment, got \"%s\"", line, strings.TrimSpace(source))
+	}
+
+	name = strings.TrimSpace(name)
+
+	if !token.IsIdentifier(name) {
+		return fmt.Errorf("line %d: \"%s\" is not a valid variable name", line, name)
+	}
+
+	if _, ok := constants[name]; ok {
+		return fmt.Errorf("line %d: can't assign to constant \"%s\"", line, name)
+	}
+
+	expr, err := parser.ParseExpr(exprSource)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+
+	eval, err := program.compile(expr)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+
+	program.statements = append(program.statements, statement{
+		target: program.slot(name),
+		eval:   eval,
+	})
+
+	return nil
+}
+
+// Set sets the value of a variable, does nothing if the program doesn't know it
+func (program *Program) Set(name string, value float64) {
+	if index, ok := program.names[name]; ok {
+		program.slots[index] = value
+	}
+}
+
+// Get returns the value of a variable, 0 if the program doesn't know it
+func (program *Program) Get(name string) float64 {
+	if index, ok := program.names[name]; ok {
+		return program.slots[index]
+	}
+
+	return 0
+}
+
+// Has returns true if the variable is an input or gets assigned in the script
+func (program *Program) Has(name string) bool {
+	_, ok := program.names[name]
+	return ok
+}
+
+// Run executes all statements in order
+func (program *Program) Run() {
+	for _, s := range program.statements {
+		program.slots[s.target] = s.eval()
+	}
+}
+
+func (program *Program) compile(expr ast.Expr) (func() float64, error) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return program.compile(e.X)
+	case *ast.BasicLit:
+		return compileLiteral(e)
+	case *ast.Ident:
+		index, ok := program.names[e.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable \"%s\"", e.Name)
+		}
+
+		return func() float64 { return program.slots[index] }, nil
+	case *ast.UnaryExpr:
+		return program.compileUnary(e)
+	case *ast.BinaryExpr:
+		return program.compileBinary(e)
+	case *ast.CallExpr:
+		return program.compileCall(e)
+	}
+
+	return nil, fmt.Errorf("unsupported expression at column %d", expr.Pos())
+}
@@ -0,0 +1,171 @@
+package expression
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// Allowed difference between expected and evaluated values
+const epsilon = 1e-9
+
+// evaluate compiles a single expression assigned to "result", sets inputs and returns the result
+func evaluate(t *testing.T, expr string, inputs map[string]float64) float64 {
+	t.Helper()
+
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+
+	program, err := Compile("result = "+expr, names...)
+	if err != nil {
+		t.Fatalf("%s: %s", expr, err)
+	}
+
+	for name, value := range inputs {
+		program.Set(name, value)
+	}
+
+	program.Run()
+
+	return program.Get("result")
+}
+
+func TestOperatorPrecedence(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"24 / 4 / 2", 3},
+		{"2 * 7 % 4", 2},
+		{"1 + 7 % 4", 4},
+		{"-2 * 3", -6},
+		{"-(2 + 3)", -5},
+		{"+4 - -1", 5},
+		{"-7 % 4", -3},
+		{"1 + 2 < 4", 1},
+		{"2 * 3 >= 7", 0},
+		{"1 == 1 && 2 != 2", 0},
+		{"0 || 1 && 0", 0},
+		{"1 || 1 && 0", 1},
+		{"!0 + 1", 2},
+		{"!(3 > 2)", 0},
+		{"1 < 2 == 1", 1},
+	}
+
+	for _, test := range tests {
+		if actual := evaluate(t, test.expr, nil); math.Abs(actual-test.expected) > epsilon {
+			t.Errorf("%s: expected %v, got %v", test.expr, test.expected, actual)
+		}
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected float64
+	}{
+		{"sin(pi / 2)", 1},
+		{"cos(pi)", -1},
+		{"atan2(1, 1)", math.Pi / 4},
+		{"sqrt(16)", 4},
+		{"abs(-3)", 3},
+		{"floor(-1.5)", -2},
+		{"ceil(1.2)", 2},
+		{"round(2.5)", 3},
+		{"log(e)", 1},
+		{"pow(2, 10)", 1024},
+		{"mod(7, 3)", 1},
+		{"hypot(3, 4)", 5},
+		{"sign(-0.5)", -1},
+		{"sign(0)", 0},
+		{"min(3, 1, 2)", 1},
+		{"max(3)", 3},
+		{"max(1, max(5, 2), 4)", 5},
+		{"clamp(5, 0, 2)", 2},
+		{"clamp(-1, 0, 2)", 0},
+		{"lerp(10, 20, 0.25)", 12.5},
+		{"smoothstep(0.5)", 0.5},
+		{"smoothstep(2)", 1},
+		{"cond(1, 2, 3)", 2},
+		{"cond(0, 2, 3)", 3},
+		{"cond(1, 2, 1 / 0)", 2},
+	}
+
+	for _, test := range tests {
+		if actual := evaluate(t, test.expr, nil); math.Abs(actual-test.expected) > epsilon {
+			t.Errorf("%s: expected %v, got %v", test.expr, test.expected, actual)
+		}
+	}
+}
+
+func TestVariableBinding(t *testing.T) {
+	program, err := Compile(`
+		# comment
+		mid = lerp(startX, endX, 0.5) // another comment
+		x = mid + t * 10; y = x * 2
+		t = t + 1
+	`, "startX", "endX", "t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	program.Set("startX", 0)
+	program.Set("endX", 100)
+	program.Set("t", 2)
+	program.Set("unknown", 5)
+
+	program.Run()
+
+	for name, expected := range map[string]float64{"mid": 50, "x": 70, "y": 140, "t": 3, "unknown": 0} {
+		if actual := program.Get(name); actual != expected {
+			t.Errorf("%s: expected %v, got %v", name, expected, actual)
+		}
+	}
+
+	if !program.Has("mid") || !program.Has("startX") || program.Has("unknown") {
+		t.Error("unexpected set of known variables")
+	}
+
+	// Inputs set again are picked up by the next run
+	program.Set("endX", 200)
+	program.Set("t", 0)
+
+	program.Run()
+
+	if actual := program.Get("x"); actual != 100 {
+		t.Errorf("x: expected 100, got %v", actual)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{"x = y", "line 1: unknown variable \"y\""},
+		{"a = 1\nx + 1", "line 2: expected assignment"},
+		{"1x = 2", "is not a valid variable name"},
+		{"pi = 3", "can't assign to constant \"pi\""},
+		{"x = foo(1)", "unknown function \"foo\""},
+		{"x = pow(1)", "function pow takes 2 arguments, got 1"},
+		{"x = min()", "function min needs at least one argument"},
+		{"x = cond(1, 2)", "function cond takes 3 arguments, got 2"},
+		{"x = \"a\"", "unsupported literal"},
+		{"x = 1 << 2", "unsupported operator <<"},
+		{"x = (1 + ", "line 1:"},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(test.source)
+		if err == nil {
+			t.Errorf("%q: expected error", test.source)
+		} else if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: expected error containing %q, got %q", test.source, test.err, err.Error())
+		}
+	}
+}
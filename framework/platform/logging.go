@@ -15,6 +15,8 @@ import (
 	"strings"
 )
 
+var logFile *os.File
+
 func StartLogging(logName string) {
 	log.Println(build.ProgramName, "version:", build.VERSION)
 
@@ -23,6 +25,8 @@ func StartLogging(logName string) {
 		panic(err)
 	}
 
+	logFile = file
+
 	log.SetOutput(file)
 
 	PrintPlatformInfo()
@@ -30,6 +34,16 @@ func StartLogging(logName string) {
 	log.SetOutput(io.MultiWriter(os.Stdout, file))
 }
 
+// RedirectLogsToStderr keeps stdout free for machine-readable output
+func RedirectLogsToStderr() {
+	if logFile == nil {
+		log.SetOutput(os.Stderr)
+		return
+	}
+
+	log.SetOutput(io.MultiWriter(os.Stderr, logFile))
+}
+
 func PrintPlatformInfo() {
 	osName, cpuName, ramAmount := "Unknown", "Unknown", "Unknown"
 